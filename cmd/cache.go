@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jetstack/seaglass/internal/cache"
+	"github.com/spf13/cobra"
+)
+
+var cachePruneOpts struct {
+	All    bool
+	MaxAge time.Duration
+}
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the cache of listings and API responses",
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove old entries from the cache",
+	Long: `Remove old entries from the cache.
+
+Listings are removed once they're older than --cache-ttl. API responses are
+revalidated with the registry rather than expiring, so they're removed once
+they haven't been revalidated for --max-age.`,
+	Example: `  seaglass cache prune
+  seaglass cache prune --all`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if rootOpts.CacheDir == "" {
+			return fmt.Errorf("no cache directory, use --cache-dir to set one")
+		}
+		c := cache.New(rootOpts.CacheDir)
+
+		if cachePruneOpts.All {
+			if err := c.Clear(); err != nil {
+				return fmt.Errorf("clearing cache: %w", err)
+			}
+			fmt.Printf("Removed all entries from %s\n", rootOpts.CacheDir)
+
+			return nil
+		}
+
+		result, err := c.Prune(cache.PruneOptions{
+			ListingsOlderThan:  rootOpts.CacheTTL,
+			ResponsesOlderThan: cachePruneOpts.MaxAge,
+		})
+		if err != nil {
+			return fmt.Errorf("pruning cache: %w", err)
+		}
+		fmt.Printf("Removed %d entries (%d bytes) from %s\n", result.Entries, result.Bytes, rootOpts.CacheDir)
+
+		return nil
+	},
+}
+
+func init() {
+	cachePruneCmd.Flags().BoolVar(&cachePruneOpts.All, "all", false, "Remove every entry from the cache")
+	cachePruneCmd.Flags().DurationVar(&cachePruneOpts.MaxAge, "max-age", 7*24*time.Hour, "Remove API responses that haven't been revalidated for this long")
+
+	cacheCmd.AddCommand(cachePruneCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
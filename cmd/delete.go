@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jetstack/seaglass/pkg/seaglass"
+	"github.com/spf13/cobra"
+)
+
+var deleteOpts struct {
+	Yes bool
+}
+
+var deleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete manifests and tags",
+	Long: `Delete manifests by digest, along with the tags that point to them, or delete
+tags, leaving the manifests that they point to.
+
+Asks for confirmation first, unless --yes is set. Not every registry supports
+deleting manifests or tags.`,
+	Example: `  seaglass delete ghcr.io/jetstack/tally@sha256:5b242a7716f9dc3d9a5cc3706933cd6fb942054e8e79890cef95e53d2366da65
+  seaglass delete gcr.io/my-project/app:pr-1 gcr.io/my-project/app:pr-2 --yes`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		type target struct {
+			ref, host, repo, tagOrDigest string
+		}
+		var targets []target
+		for _, arg := range args {
+			host, repo, ref, err := parseRef(arg)
+			if err != nil {
+				return fmt.Errorf("parsing reference: %w", err)
+			}
+
+			// parseRef defaults to latest, which is too easy to
+			// delete by accident
+			if !strings.HasSuffix(arg, ":"+ref) && !strings.HasSuffix(arg, "@"+ref) {
+				return fmt.Errorf("%s must have a tag or a digest", arg)
+			}
+
+			targets = append(targets, target{ref: arg, host: host, repo: repo, tagOrDigest: ref})
+		}
+
+		fmt.Fprintf(os.Stderr, "Deleting:\n")
+		for _, t := range targets {
+			fmt.Fprintf(os.Stderr, "  %s\n", t.ref)
+		}
+		ok, err := confirm(fmt.Sprintf("Delete %d manifests and tags?", len(targets)), deleteOpts.Yes)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("not confirmed")
+		}
+
+		// Clients that delete manifests by their tags find them from
+		// the registry, rather than a cached listing that may be out
+		// of date
+		var errs []error
+		for _, t := range targets {
+			c, err := newUncachedClient(t.host)
+			if err != nil {
+				return fmt.Errorf("creating client for %s: %w", t.host, err)
+			}
+
+			err = deleteRef(ctx, c, t.repo, t.tagOrDigest)
+			invalidateCache(t.host)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", t.ref, err))
+				continue
+			}
+			fmt.Printf("Deleted %s\n", t.ref)
+		}
+
+		if len(errs) > 0 {
+			fmt.Fprintf(os.Stderr, "\nErrors deleting %d references:\n", len(errs))
+			for _, err := range errs {
+				fmt.Fprintf(os.Stderr, "  %s\n", err)
+			}
+
+			return fmt.Errorf("failed to delete %d references", len(errs))
+		}
+
+		return nil
+	},
+}
+
+// deleteRef deletes the manifest with the digest, or the tag, from the
+// repository
+func deleteRef(ctx context.Context, c seaglass.Client, repo, tagOrDigest string) error {
+	d, ok := c.(seaglass.Deleter)
+	if !ok {
+		return seaglass.ErrNotSupported
+	}
+
+	var err error
+	if strings.Contains(tagOrDigest, ":") {
+		err = d.DeleteManifest(ctx, repo, tagOrDigest)
+	} else {
+		err = d.DeleteTag(ctx, repo, tagOrDigest)
+	}
+	if errors.Is(err, seaglass.ErrNotSupported) {
+		return fmt.Errorf("the registry doesn't support deleting it: %w", err)
+	}
+
+	return err
+}
+
+// confirm asks the user to confirm the prompt on stdin, unless yes is set
+func confirm(prompt string, yes bool) (bool, error) {
+	if yes {
+		return true, nil
+	}
+
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", prompt)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, fmt.Errorf("reading confirmation: %w", err)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+func init() {
+	deleteCmd.Flags().BoolVarP(&deleteOpts.Yes, "yes", "y", false, "Delete without asking for confirmation")
+
+	rootCmd.AddCommand(deleteCmd)
+}
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/jetstack/seaglass/internal/diff"
+	"github.com/jetstack/seaglass/internal/output"
+	"github.com/jetstack/seaglass/internal/traverse"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+	"github.com/spf13/cobra"
+)
+
+var diffOpts struct {
+	Recursive       bool
+	ContinueOnError bool
+	Repositories    seaglass.RepositoryListOptions
+	Filter          seaglass.ManifestListOptions
+}
+
+var diffFormat = output.Format[diff.TagChange]{
+	Text: func(c diff.TagChange) string {
+		tag := c.Tag
+		if c.Repository != "" {
+			tag = c.Repository + ":" + c.Tag
+		}
+		switch c.Change {
+		case diff.ChangeAdded:
+			return fmt.Sprintf("+ %s %s", tag, c.To)
+		case diff.ChangeRemoved:
+			return fmt.Sprintf("- %s %s", tag, c.From)
+		default:
+			return fmt.Sprintf("~ %s %s -> %s", tag, c.From, c.To)
+		}
+	},
+	Columns: []output.Column[diff.TagChange]{
+		{Header: "CHANGE", Value: func(c diff.TagChange) string { return string(c.Change) }},
+		{Header: "REPOSITORY", Value: func(c diff.TagChange) string { return valueColumn(c.Repository) }},
+		{Header: "TAG", Value: func(c diff.TagChange) string { return c.Tag }},
+		{Header: "FROM", Value: func(c diff.TagChange) string { return valueColumn(c.From) }},
+		{Header: "TO", Value: func(c diff.TagChange) string { return valueColumn(c.To) }},
+	},
+}
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compare the tags in two repositories",
+	Long: `Compare the tags in two repositories, or in a repository at two points in
+time, and print the tags that were added, removed or moved to another manifest.
+
+Either side can be a repository, which is listed from the registry, or a
+snapshot saved from the json or ndjson output of the manifests or tags
+commands. With --recursive, the repositories under each side are compared by
+their path relative to it. A snapshot is relative to the repository that every
+repository in it is under.`,
+	Example: `  seaglass diff registry.staging.example.com/app registry.example.com/app
+  seaglass manifests ghcr.io/org/app -o json > snapshot.json
+  seaglass diff snapshot.json ghcr.io/org/app`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		p, err := newPrinter(diffFormat)
+		if err != nil {
+			return err
+		}
+
+		a, err := diffTags(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		b, err := diffTags(ctx, args[1])
+		if err != nil {
+			return err
+		}
+
+		counts := map[diff.Change]int{}
+		for _, change := range diff.Compare(a, b) {
+			counts[change.Change]++
+			if err := p.Print(change); err != nil {
+				return err
+			}
+		}
+		if err := p.Flush(); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "\n%d tags added, %d removed, %d moved\n", counts[diff.ChangeAdded], counts[diff.ChangeRemoved], counts[diff.ChangeMoved])
+
+		return nil
+	},
+}
+
+// diffTags returns the tags for one side of the diff, from a snapshot if the
+// argument is a file or otherwise from the registry
+func diffTags(ctx context.Context, arg string) (diff.Tags, error) {
+	if info, err := os.Stat(arg); err == nil && info.Mode().IsRegular() {
+		return snapshotTags(arg)
+	}
+
+	registry, repo, err := parseRepo(arg)
+	if err != nil {
+		return nil, fmt.Errorf("parsing repository reference: %w", err)
+	}
+
+	c, err := newUncachedClient(registry)
+	if err != nil {
+		return nil, fmt.Errorf("creating client for %s: %w", registry, err)
+	}
+
+	tags := diff.Tags{}
+	err = walkManifests(ctx, c, repo, traverse.Options{
+		Recursive:             diffOpts.Recursive,
+		ContinueOnError:       diffOpts.ContinueOnError,
+		RepositoryListOptions: &diffOpts.Repositories,
+		ManifestListOptions:   &diffOpts.Filter,
+	}, func(page *traverse.Page) error {
+		for _, manifest := range page.Manifests {
+			tags.Add(strings.TrimPrefix(page.Repository, repo), manifest)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing %s: %w", arg, err)
+	}
+
+	return tags, nil
+}
+
+// snapshotTags returns the tags in the snapshot, filtered by the same options
+// as the registry is
+func snapshotTags(name string) (diff.Tags, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("opening snapshot: %w", err)
+	}
+	defer f.Close()
+
+	snapshot, err := diff.ReadSnapshot(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", name, err)
+	}
+
+	var repos []string
+	for repo := range snapshot {
+		repos = append(repos, repo)
+	}
+	slices.Sort(repos)
+	root := diff.Root(repos)
+
+	tags := diff.Tags{}
+	for _, repo := range repos {
+		rel := strings.TrimPrefix(repo, root)
+		if rel != "" && !diffOpts.Recursive {
+			continue
+		}
+
+		var pages iter.Seq2[*seaglass.ManifestList, error] = func(yield func(*seaglass.ManifestList, error) bool) {
+			yield(&seaglass.ManifestList{Manifests: snapshot[repo]}, nil)
+		}
+		for page, err := range seaglass.FilterManifestPages(seaglass.GroupArtifactPages(pages, &diffOpts.Filter), &diffOpts.Filter) {
+			if err != nil {
+				return nil, fmt.Errorf("filtering %s: %w", name, err)
+			}
+			for _, manifest := range page.Manifests {
+				tags.Add(rel, manifest)
+			}
+		}
+	}
+
+	return tags, nil
+}
+
+func init() {
+	diffCmd.Flags().BoolVar(&diffOpts.Recursive, "recursive", false, "Compare every repository under each side too")
+	diffCmd.Flags().BoolVar(&diffOpts.ContinueOnError, "continue-on-error", false, "Continue listing other repositories after an error and summarise the errors at the end")
+	addRepositoryFilterFlags(diffCmd.Flags(), &diffOpts.Repositories)
+	addFilterFlags(diffCmd.Flags(), &diffOpts.Filter)
+
+	rootCmd.AddCommand(diffCmd)
+}
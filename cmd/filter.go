@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jetstack/seaglass/pkg/seaglass"
+	"github.com/spf13/pflag"
+)
+
+// addRepositoryFilterFlags adds flags to the flag set that filter the
+// repositories listed with the options
+func addRepositoryFilterFlags(fs *pflag.FlagSet, opts *seaglass.RepositoryListOptions) {
+	fs.IntVar(&opts.MaxDepth, "depth", 0, "Maximum depth of the repositories to list recursively, where 1 is the direct children. Implies --recursive")
+	fs.StringSliceVar(&opts.Include, "include", nil, "Only list repositories with a relative path that matches the glob pattern (can be repeated)")
+	fs.StringSliceVar(&opts.Exclude, "exclude", nil, "Don't list repositories with a relative path that matches the glob pattern (can be repeated)")
+	fs.StringVar(&opts.NamePrefix, "name-prefix", "", "Only list repositories with a relative path that starts with the prefix")
+	fs.StringVar(&opts.NameContains, "name-contains", "", "Only list repositories with a relative path that contains the string")
+}
+
+// addFilterFlags adds flags to the flag set that filter the manifests listed
+// with the options
+func addFilterFlags(fs *pflag.FlagSet, opts *seaglass.ManifestListOptions) {
+	fs.StringSliceVar(&opts.IncludeTags, "include-tag", nil, "Only list tags that match the glob pattern (can be repeated)")
+	fs.StringSliceVar(&opts.ExcludeTags, "exclude-tag", nil, "Don't list tags that match the glob pattern (can be repeated)")
+	fs.StringSliceVar(&opts.IncludeTagsRegexp, "include-tag-regex", nil, "Only list tags that match the regular expression (can be repeated)")
+	fs.StringSliceVar(&opts.ExcludeTagsRegexp, "exclude-tag-regex", nil, "Don't list tags that match the regular expression (can be repeated)")
+	fs.Var(&timeValue{&opts.CreatedBefore}, "created-before", "Only list manifests created before the time")
+	fs.Var(&timeValue{&opts.CreatedAfter}, "created-after", "Only list manifests created after the time")
+	fs.Var(&timeValue{&opts.UploadedBefore}, "uploaded-before", "Only list manifests uploaded before the time")
+	fs.Var(&timeValue{&opts.UploadedAfter}, "uploaded-after", "Only list manifests uploaded after the time")
+	fs.Var(&timeValue{&opts.UpdatedBefore}, "updated-before", "Only list manifests updated before the time")
+	fs.Var(&timeValue{&opts.UpdatedAfter}, "updated-after", "Only list manifests updated after the time")
+	fs.StringSliceVar(&opts.MediaTypes, "media-type", nil, "Only list manifests with the media type (can be repeated)")
+	fs.BoolVar(&opts.Tagged, "tagged", false, "Only list manifests that have tags")
+	fs.BoolVar(&opts.Untagged, "untagged", false, "Only list manifests that don't have any tags")
+	fs.BoolVar(&opts.ResolvePlatforms, "show-platforms", false, "Fetch the platforms of images and indexes when the registry doesn't list them, which costs extra requests")
+	fs.BoolVar(&opts.Referrers, "referrers", false, "List the referrers of each manifest, like signatures, SBOMs and attestations, which costs extra requests")
+	fs.StringSliceVar(&opts.Platforms, "platform", nil, "Only list images for the platform, in the form os/arch[/variant], and indexes that contain one (can be repeated)")
+	fs.BoolVar(&opts.HideArtifacts, "hide-artifacts", false, "Don't list the sha256-<hex>.sig, .att and .sbom tags of artifacts, and add the artifacts to the referrers of their subject instead. Each repository is listed in full before it's printed")
+	fs.Var(&notValue{&opts.HideArtifacts}, "show-artifacts", "List the sha256-<hex>.sig, .att and .sbom tags of artifacts like any other tag (the default)")
+	fs.Lookup("show-artifacts").NoOptDefVal = "true"
+}
+
+// notValue is a boolean flag value that sets the opposite of the flag, so that
+// a pair of flags can turn an option on and off
+type notValue struct {
+	b *bool
+}
+
+func (v *notValue) String() string {
+	if v.b == nil {
+		return "false"
+	}
+	return strconv.FormatBool(!*v.b)
+}
+
+func (v *notValue) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	*v.b = !b
+
+	return nil
+}
+
+func (v *notValue) Type() string {
+	return "bool"
+}
+
+// timeValue is a flag value for a time. The time can be an RFC3339 timestamp,
+// a date, or a duration before now, like 720h or 30d.
+type timeValue struct {
+	t **time.Time
+}
+
+func (v *timeValue) String() string {
+	if v.t == nil || *v.t == nil {
+		return ""
+	}
+
+	return (*v.t).Format(time.RFC3339)
+}
+
+func (v *timeValue) Set(s string) error {
+	t, err := parseTime(s, time.Now())
+	if err != nil {
+		return err
+	}
+	*v.t = &t
+
+	return nil
+}
+
+func (v *timeValue) Type() string {
+	return "time"
+}
+
+func parseTime(s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %q: must be an RFC3339 timestamp, a date or a duration", s)
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jetstack/seaglass/internal/index"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+	"github.com/spf13/cobra"
+)
+
+var indexOpts struct {
+	Repositories     seaglass.RepositoryListOptions
+	ResolvePlatforms bool
+}
+
+var indexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "Crawl registries into a local index",
+	Long: `Crawl one or more repositories, and every repository under them, into a local
+SQLite database that can be queried with the query command.
+
+Crawling the same repository again updates the index incrementally: only the
+differences are written, and repositories, manifests and tags that can't be
+found any more are removed. A repository that fails to list keeps what was
+indexed for it before.`,
+	Example: `  seaglass index ghcr.io/jetstack gcr.io/my-project`,
+	Args:    cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		idx, err := openIndex()
+		if err != nil {
+			return err
+		}
+		defer idx.Close()
+
+		var errs []error
+		for _, arg := range args {
+			registry, repo, err := parseRepo(arg)
+			if err != nil {
+				return fmt.Errorf("parsing repository reference: %w", err)
+			}
+
+			c, err := newUncachedClient(registry)
+			if err != nil {
+				return fmt.Errorf("creating client for %s: %w", registry, err)
+			}
+
+			result, err := idx.Crawl(ctx, c, registry, repo, &index.CrawlOptions{
+				Concurrency:           rootOpts.Concurrency,
+				RepositoryListOptions: &indexOpts.Repositories,
+				ManifestListOptions: &seaglass.ManifestListOptions{
+					Concurrency:      rootOpts.Concurrency,
+					ResolvePlatforms: indexOpts.ResolvePlatforms,
+				},
+			})
+			if err != nil {
+				return fmt.Errorf("indexing %s: %w", arg, err)
+			}
+
+			fmt.Printf(
+				"Indexed %s: %d repositories, %d manifests, %d tags added, %d moved, %d removed, %d repositories removed\n",
+				arg, result.Repositories, result.Manifests, result.AddedTags, result.MovedTags, result.RemovedTags, result.RemovedRepositories,
+			)
+			errs = append(errs, result.Errors...)
+		}
+
+		if len(errs) > 0 {
+			fmt.Fprintf(os.Stderr, "\nErrors indexing %d repositories:\n", len(errs))
+			for _, err := range errs {
+				fmt.Fprintf(os.Stderr, "  %s\n", err)
+			}
+
+			return fmt.Errorf("failed to index %d repositories", len(errs))
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	indexCmd.Flags().BoolVar(&indexOpts.ResolvePlatforms, "show-platforms", false, "Fetch the platforms of images and indexes when the registry doesn't list them, which costs extra requests")
+	addRepositoryFilterFlags(indexCmd.Flags(), &indexOpts.Repositories)
+	addIndexFlags(indexCmd.Flags())
+
+	rootCmd.AddCommand(indexCmd)
+}
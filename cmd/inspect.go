@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jetstack/seaglass/internal/output"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+	"github.com/spf13/cobra"
+)
+
+// inspectItem is a manifest in the output of the inspect command
+type inspectItem struct {
+	// Reference is the reference that was inspected, including the
+	// registry host
+	Reference string `json:"reference"`
+
+	seaglass.ManifestDetail
+}
+
+var inspectFormat = output.Format[inspectItem]{
+	Text: inspectText,
+	Columns: []output.Column[inspectItem]{
+		{Header: "REFERENCE", Value: func(i inspectItem) string { return i.Reference }},
+		{Header: "DIGEST", Value: func(i inspectItem) string { return i.Digest }},
+		{Header: "MEDIA TYPE", Value: func(i inspectItem) string { return i.MediaType }},
+		{Header: "SIZE", Value: func(i inspectItem) string { return fmt.Sprint(i.TotalSize) }},
+		{Header: "PLATFORMS", Value: func(i inspectItem) string { return platformsColumn(i.ManifestDetail) }},
+	},
+}
+
+var inspectCmd = &cobra.Command{
+	Use:   "inspect",
+	Short: "Show the details of a manifest",
+	Long: `Show the details of a manifest, referenced by tag or digest, including its
+layers, size, config labels, annotations and, for an index, its platforms.`,
+	Example: `  seaglass inspect ghcr.io/jetstack/tally:latest
+  seaglass inspect ghcr.io/jetstack/tally@sha256:87f4f96fc7493d7e77c628583e0cf776a90bf95fd83168e9c0e8fd6db5624656`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		p, err := newPrinter(inspectFormat)
+		if err != nil {
+			return err
+		}
+
+		registry, repo, ref, err := parseRef(args[0])
+		if err != nil {
+			return fmt.Errorf("parsing reference: %w", err)
+		}
+
+		c, err := newClient(registry)
+		if err != nil {
+			return fmt.Errorf("creating client for %s: %w", registry, err)
+		}
+
+		detail, err := c.GetManifest(ctx, repo, ref)
+		if err != nil {
+			return fmt.Errorf("getting manifest: %w", err)
+		}
+
+		if err := p.Print(inspectItem{Reference: args[0], ManifestDetail: *detail}); err != nil {
+			return err
+		}
+
+		return p.Flush()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(inspectCmd)
+}
+
+// parseRef parses a reference of the form <host>/<repository>:<tag> or
+// <host>/<repository>@<digest>. The tag defaults to latest.
+func parseRef(ref string) (host, repo, tagOrDigest string, err error) {
+	host, repo, err = parseRepo(ref)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	if r, digest, ok := strings.Cut(repo, "@"); ok {
+		return host, r, digest, nil
+	}
+
+	// A colon after the last slash separates the tag
+	if i := strings.LastIndex(repo, ":"); i > strings.LastIndex(repo, "/") {
+		return host, repo[:i], repo[i+1:], nil
+	}
+
+	return host, repo, "latest", nil
+}
+
+func inspectText(i inspectItem) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Reference:  %s\n", i.Reference)
+	fmt.Fprintf(&b, "Digest:     %s\n", i.Digest)
+	fmt.Fprintf(&b, "Media Type: %s\n", i.MediaType)
+	if i.ArtifactType != "" {
+		fmt.Fprintf(&b, "Artifact:   %s\n", i.ArtifactType)
+	}
+	fmt.Fprintf(&b, "Size:       %d\n", i.TotalSize)
+
+	if c := i.Config; c != nil {
+		if c.Platform != nil {
+			fmt.Fprintf(&b, "Platform:   %s\n", c.Platform)
+		}
+		fmt.Fprintf(&b, "Created:    %s\n", output.Time(c.Created))
+		writeMap(&b, "Labels", c.Labels)
+	}
+	writeMap(&b, "Annotations", i.Annotations)
+
+	if i.Subject != nil {
+		fmt.Fprintf(&b, "Subject:    %s\n", i.Subject.Digest)
+	}
+
+	if len(i.Manifests) > 0 {
+		fmt.Fprintf(&b, "Manifests:\n")
+		for _, m := range i.Manifests {
+			platform := "-"
+			if m.Platform != nil {
+				platform = m.Platform.String()
+			}
+			fmt.Fprintf(&b, "  %s  %s  %d\n", m.Digest, platform, m.Size)
+		}
+	}
+
+	if len(i.Layers) > 0 {
+		fmt.Fprintf(&b, "Layers:\n")
+		for _, l := range i.Layers {
+			fmt.Fprintf(&b, "  %s  %d\n", l.Digest, l.Size)
+		}
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func writeMap(b *strings.Builder, name string, m map[string]string) {
+	if len(m) == 0 {
+		return
+	}
+
+	fmt.Fprintf(b, "%s:\n", name)
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(b, "  %s=%s\n", k, m[k])
+	}
+}
+
+func platformsColumn(d seaglass.ManifestDetail) string {
+	var platforms []string
+	for _, m := range d.Manifests {
+		if m.Platform != nil {
+			platforms = append(platforms, m.Platform.String())
+		}
+	}
+	if d.Config != nil && d.Config.Platform != nil {
+		platforms = append(platforms, d.Config.Platform.String())
+	}
+	if len(platforms) == 0 {
+		return "-"
+	}
+
+	return strings.Join(platforms, ",")
+}
@@ -1,17 +1,19 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
-	"os"
+	"sort"
 
-	v1 "github.com/jetstack/seaglass/internal/v1"
-	"github.com/jetstack/seaglass/internal/v1/clients/seaglass"
+	"github.com/jetstack/seaglass/internal/traverse"
+	"github.com/jetstack/seaglass/pkg/seaglass"
 	"github.com/spf13/cobra"
 )
 
 var manifestsOpts struct {
-	Recursive bool
+	Recursive       bool
+	ContinueOnError bool
+	Repositories    seaglass.RepositoryListOptions
+	Filter          seaglass.ManifestListOptions
 }
 
 var manifestsCmd = &cobra.Command{
@@ -19,48 +21,104 @@ var manifestsCmd = &cobra.Command{
 	Short: "List manifests",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := context.Background()
+		ctx := cmd.Context()
+
+		p, err := newPrinter(manifestFormat)
+		if err != nil {
+			return err
+		}
 
 		registry, repo, err := parseRepo(args[0])
 		if err != nil {
 			return fmt.Errorf("parsing repository reference: %w", err)
 		}
 
-		c, err := seaglass.NewClient(registry)
+		c, err := newClient(registry)
 		if err != nil {
 			return fmt.Errorf("creating client for %s: %w", registry, err)
 		}
 
-		repos := []string{repo}
+		// A manifest may appear in more than one page of a repository,
+		// with a different subset of its tags. The default output only
+		// includes the digest, so each manifest can be printed as soon as
+		// it's first seen. Otherwise, the pages for each repository are
+		// merged before they're printed. The pages for each repository
+		// are contiguous, so only the current repository needs to be
+		// tracked.
+		var (
+			current   string
+			seen      map[string]int
+			manifests []seaglass.Manifest
+		)
+		flush := func() error {
+			for _, manifest := range manifests {
+				sort.Strings(manifest.Tags)
 
-		if manifestsOpts.Recursive {
-			repoList, err := c.ListRepositories(ctx, repo, &v1.RepositoryListOptions{Recursive: true})
-			if err != nil {
-				return fmt.Errorf("listing repositories for %s: %w", repo, err)
-			}
+				item := manifestItem{
+					Repository: fmt.Sprintf("%s/%s", registry, current),
+					Manifest:   manifest,
+				}
+				if err := p.Print(item); err != nil {
+					return err
+				}
 
-			for _, r := range repoList.Repositories {
-				repos = append(repos, fmt.Sprintf("%s/%s", repoList.Name, r))
+				// Manifests that have already been printed are
+				// skipped if they're seen again
+				seen[manifest.Digest] = -1
 			}
+			manifests = nil
+
+			return nil
 		}
 
-		for _, r := range repos {
-			manifestList, err := c.ListManifests(ctx, r, nil)
-			if err != nil {
-				return fmt.Errorf("listing manifests for %s: %w", repo, err)
+		err = walkManifests(ctx, c, repo, traverse.Options{
+			Recursive:             manifestsOpts.Recursive,
+			ContinueOnError:       manifestsOpts.ContinueOnError,
+			RepositoryListOptions: &manifestsOpts.Repositories,
+			ManifestListOptions:   &manifestsOpts.Filter,
+		}, func(page *traverse.Page) error {
+			if page.Repository != current || seen == nil {
+				if err := flush(); err != nil {
+					return err
+				}
+				current = page.Repository
+				seen = map[string]int{}
 			}
 
-			for _, manifest := range manifestList.Manifests {
-				fmt.Fprintf(os.Stdout, "%s/%s@%s\n", registry, r, manifest.Digest)
+			for _, manifest := range page.Manifests {
+				i, ok := seen[manifest.Digest]
+				if ok {
+					if i >= 0 {
+						manifests[i].Merge(manifest)
+					}
+					continue
+				}
+				seen[manifest.Digest] = len(manifests)
+				manifests = append(manifests, manifest)
 			}
+
+			if rootOpts.Output == "" {
+				return flush()
+			}
+
+			return nil
+		})
+		if ferr := flush(); ferr != nil && err == nil {
+			err = ferr
+		}
+		if ferr := p.Flush(); ferr != nil {
+			return ferr
 		}
 
-		return nil
+		return err
 	},
 }
 
 func init() {
 	manifestsCmd.PersistentFlags().BoolVar(&manifestsOpts.Recursive, "recursive", false, "List manifests recursively")
+	manifestsCmd.PersistentFlags().BoolVar(&manifestsOpts.ContinueOnError, "continue-on-error", false, "Continue listing other repositories after an error and summarise the errors at the end")
+	addRepositoryFilterFlags(manifestsCmd.PersistentFlags(), &manifestsOpts.Repositories)
+	addFilterFlags(manifestsCmd.PersistentFlags(), &manifestsOpts.Filter)
 
 	rootCmd.AddCommand(manifestsCmd)
 }
@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	githubauthn "github.com/google/go-containerregistry/pkg/authn/github"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/google"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/jetstack/seaglass/internal/mirror"
+	"github.com/jetstack/seaglass/internal/output"
+	"github.com/jetstack/seaglass/internal/traverse"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+	"github.com/spf13/cobra"
+)
+
+var mirrorOpts struct {
+	Recursive       bool
+	ContinueOnError bool
+	DryRun          bool
+	ProgressFile    string
+	Repositories    seaglass.RepositoryListOptions
+	Filter          seaglass.ManifestListOptions
+}
+
+var mirrorFormat = output.Format[mirror.Result]{
+	Text: func(r mirror.Result) string {
+		s := fmt.Sprintf("%s %s@%s -> %s", r.Action, r.Source, r.Digest, r.Destination)
+		if len(r.Tags) > 0 {
+			s += fmt.Sprintf(" (tags: %s)", strings.Join(r.Tags, ","))
+		}
+		return s
+	},
+	Columns: []output.Column[mirror.Result]{
+		{Header: "ACTION", Value: func(r mirror.Result) string { return string(r.Action) }},
+		{Header: "SOURCE", Value: func(r mirror.Result) string { return r.Source }},
+		{Header: "DESTINATION", Value: func(r mirror.Result) string { return r.Destination }},
+		{Header: "DIGEST", Value: func(r mirror.Result) string { return r.Digest }},
+		{Header: "TAGS", Value: func(r mirror.Result) string { return tagsColumn(r.Tags) }},
+	},
+}
+
+var mirrorCmd = &cobra.Command{
+	Use:   "mirror",
+	Short: "Mirror repositories to another registry",
+	Long: `Copy the manifests and tags in a repository, and every repository under it, to
+another registry. The repositories keep their paths relative to the source and
+destination.
+
+Manifests that are already at the destination aren't copied again, but any of
+their tags that are missing or point to another manifest are set. Artifact
+tags, like cosign's sha256-<hex>.sig, are mirrored like any other tag.
+
+Use --progress-file to record the manifests that have been mirrored, so that
+an interrupted mirror can resume without checking them at the destination
+again.`,
+	Example: `  seaglass mirror ghcr.io/org/x harbor.example.com/mirror/x
+  seaglass mirror ghcr.io/org/x 123456789012.dkr.ecr.eu-west-1.amazonaws.com/x --include-tag 'v*' --dry-run
+  seaglass mirror ghcr.io/org/x harbor.example.com/mirror/x --progress-file mirror.progress`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		p, err := newPrinter(mirrorFormat)
+		if err != nil {
+			return err
+		}
+
+		registry, repo, err := parseRepo(args[0])
+		if err != nil {
+			return fmt.Errorf("parsing source repository reference: %w", err)
+		}
+		src, err := name.NewRepository(fmt.Sprintf("%s/%s", registry, repo))
+		if err != nil {
+			return fmt.Errorf("parsing source repository reference: %w", err)
+		}
+		dst, err := name.NewRepository(strings.TrimSuffix(args[1], "/"))
+		if err != nil {
+			return fmt.Errorf("parsing destination repository reference: %w", err)
+		}
+
+		c, err := newUncachedClient(registry)
+		if err != nil {
+			return fmt.Errorf("creating client for %s: %w", registry, err)
+		}
+
+		m, err := mirror.New(&mirror.Options{
+			DryRun:       mirrorOpts.DryRun,
+			ProgressFile: mirrorOpts.ProgressFile,
+			RemoteOptions: []remote.Option{
+				remote.WithAuthFromKeychain(authn.NewMultiKeychain(
+					authn.DefaultKeychain,
+					google.Keychain,
+					githubauthn.Keychain,
+				)),
+			},
+		})
+		if err != nil {
+			return err
+		}
+		defer m.Close()
+
+		// Every tag is copied, including the tags of artifacts
+		filter := mirrorOpts.Filter
+		filter.HideArtifacts = false
+
+		counts := map[mirror.Action]int{}
+
+		// The pages for each repository are merged, so that each
+		// manifest is mirrored once with all of its tags. The pages for
+		// each repository are contiguous, so only the current
+		// repository needs to be tracked.
+		var (
+			current   string
+			seen      map[string]int
+			manifests []seaglass.Manifest
+		)
+		flush := func() error {
+			if manifests == nil {
+				return nil
+			}
+			for i := range manifests {
+				sort.Strings(manifests[i].Tags)
+			}
+
+			rel := strings.TrimPrefix(strings.TrimPrefix(current, repo), "/")
+			srcRepo, dstRepo := src, dst
+			if rel != "" {
+				srcRepo = src.Registry.Repo(src.RepositoryStr(), rel)
+				dstRepo = dst.Registry.Repo(dst.RepositoryStr(), rel)
+			}
+
+			err := m.Repository(ctx, srcRepo, dstRepo, manifests, func(r *mirror.Result) error {
+				counts[r.Action]++
+				return p.Print(*r)
+			})
+			manifests = nil
+
+			return err
+		}
+
+		err = walkManifests(ctx, c, repo, traverse.Options{
+			Recursive:             mirrorOpts.Recursive,
+			ContinueOnError:       mirrorOpts.ContinueOnError,
+			RepositoryListOptions: &mirrorOpts.Repositories,
+			ManifestListOptions:   &filter,
+		}, func(page *traverse.Page) error {
+			if page.Repository != current || seen == nil {
+				if err := flush(); err != nil {
+					return err
+				}
+				current = page.Repository
+				seen = map[string]int{}
+			}
+
+			for _, manifest := range page.Manifests {
+				if i, ok := seen[manifest.Digest]; ok {
+					manifests[i].Merge(manifest)
+					continue
+				}
+				seen[manifest.Digest] = len(manifests)
+				manifests = append(manifests, manifest)
+			}
+
+			return nil
+		})
+		if ferr := flush(); ferr != nil && err == nil {
+			err = ferr
+		}
+		if ferr := p.Flush(); ferr != nil {
+			return ferr
+		}
+
+		summary := fmt.Sprintf("Copied %d manifests, tagged %d and skipped %d", counts[mirror.ActionCopy], counts[mirror.ActionTag], counts[mirror.ActionSkip])
+		if mirrorOpts.DryRun {
+			summary = fmt.Sprintf("Would copy %d manifests, tag %d and skip %d (dry run)", counts[mirror.ActionCopy], counts[mirror.ActionTag], counts[mirror.ActionSkip])
+		}
+		fmt.Fprintf(os.Stderr, "\n%s\n", summary)
+
+		return err
+	},
+}
+
+func init() {
+	mirrorCmd.Flags().BoolVar(&mirrorOpts.Recursive, "recursive", true, "Mirror every repository under the repository too")
+	mirrorCmd.Flags().BoolVar(&mirrorOpts.ContinueOnError, "continue-on-error", false, "Continue with other repositories after an error listing them and summarise the errors at the end")
+	mirrorCmd.Flags().BoolVar(&mirrorOpts.DryRun, "dry-run", false, "Print what would be copied, without writing anything to the destination")
+	mirrorCmd.Flags().StringVar(&mirrorOpts.ProgressFile, "progress-file", "", "File to record the manifests that have been mirrored in, so that an interrupted mirror can resume")
+	addRepositoryFilterFlags(mirrorCmd.Flags(), &mirrorOpts.Repositories)
+	addFilterFlags(mirrorCmd.Flags(), &mirrorOpts.Filter)
+
+	rootCmd.AddCommand(mirrorCmd)
+}
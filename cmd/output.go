@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jetstack/seaglass/internal/output"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+)
+
+// repositoryItem is a repository in the output of the repos command
+type repositoryItem struct {
+	// Repository is the full reference to the repository, including the
+	// registry host
+	Repository string `json:"repository"`
+}
+
+var repositoryFormat = output.Format[repositoryItem]{
+	Text: func(r repositoryItem) string {
+		return r.Repository
+	},
+	Columns: []output.Column[repositoryItem]{
+		{Header: "REPOSITORY", Value: func(r repositoryItem) string { return r.Repository }},
+	},
+}
+
+// manifestItem is a manifest in the output of the manifests command
+type manifestItem struct {
+	// Repository is the full reference to the repository, including the
+	// registry host
+	Repository string `json:"repository"`
+
+	seaglass.Manifest
+}
+
+var manifestFormat = output.Format[manifestItem]{
+	Text: func(m manifestItem) string {
+		return m.Repository + "@" + m.Digest
+	},
+	Columns: []output.Column[manifestItem]{
+		{Header: "REPOSITORY", Value: func(m manifestItem) string { return m.Repository }},
+		{Header: "DIGEST", Value: func(m manifestItem) string { return m.Digest }},
+		{Header: "TAGS", Value: func(m manifestItem) string { return tagsColumn(m.Tags) }},
+		{Header: "PLATFORMS", Value: func(m manifestItem) string { return tagsColumn(manifestPlatforms(m.Manifest)) }},
+		{Header: "REFERRERS", Value: func(m manifestItem) string { return referrersColumn(m.Referrers) }},
+		{Header: "CREATED", Value: func(m manifestItem) string { return output.Time(m.Created) }},
+		{Header: "UPLOADED", Value: func(m manifestItem) string { return output.Time(m.Uploaded) }},
+		{Header: "UPDATED", Value: func(m manifestItem) string { return output.Time(m.Updated) }},
+	},
+}
+
+// tagItem is a tag in the output of the tags command
+type tagItem struct {
+	// Repository is the full reference to the repository, including the
+	// registry host
+	Repository string `json:"repository"`
+
+	// Tag is the name of the tag
+	Tag string `json:"tag"`
+
+	// Digest is the digest of the manifest that the tag points to
+	Digest string `json:"digest"`
+
+	// MediaType is the media type of the manifest
+	MediaType string `json:"mediaType,omitempty"`
+
+	// Platforms are the platforms of the manifest, if they're known
+	Platforms []string `json:"platforms,omitempty"`
+
+	// Referrers are the referrers of the manifest, if they were listed
+	Referrers []seaglass.Referrer `json:"referrers,omitempty"`
+
+	// Created, Uploaded and Updated are the timestamps of the manifest.
+	// See seaglass.Manifest for what they mean.
+	Created  *time.Time `json:"timeCreated,omitempty"`
+	Uploaded *time.Time `json:"timeUploaded,omitempty"`
+	Updated  *time.Time `json:"timeUpdated,omitempty"`
+}
+
+func newTagItem(repo, tag string, m seaglass.Manifest) tagItem {
+	return tagItem{
+		Repository: repo,
+		Tag:        tag,
+		Digest:     m.Digest,
+		MediaType:  m.MediaType,
+		Platforms:  manifestPlatforms(m),
+		Referrers:  m.Referrers,
+		Created:    m.Created,
+		Uploaded:   m.Uploaded,
+		Updated:    m.Updated,
+	}
+}
+
+var tagFormat = output.Format[tagItem]{
+	Text: func(t tagItem) string {
+		return t.Repository + ":" + t.Tag
+	},
+	Columns: []output.Column[tagItem]{
+		{Header: "REPOSITORY", Value: func(t tagItem) string { return t.Repository }},
+		{Header: "TAG", Value: func(t tagItem) string { return t.Tag }},
+		{Header: "DIGEST", Value: func(t tagItem) string { return t.Digest }},
+		{Header: "PLATFORMS", Value: func(t tagItem) string { return tagsColumn(t.Platforms) }},
+		{Header: "CREATED", Value: func(t tagItem) string { return output.Time(t.Created) }},
+		{Header: "UPLOADED", Value: func(t tagItem) string { return output.Time(t.Uploaded) }},
+		{Header: "UPDATED", Value: func(t tagItem) string { return output.Time(t.Updated) }},
+	},
+}
+
+// manifestPlatforms returns the platform of an image, or the platforms of the
+// manifests in an index
+func manifestPlatforms(m seaglass.Manifest) []string {
+	var platforms []string
+	if m.Platform != nil {
+		platforms = append(platforms, m.Platform.String())
+	}
+	for _, d := range m.Manifests {
+		if d.Platform != nil {
+			platforms = append(platforms, d.Platform.String())
+		}
+	}
+
+	return platforms
+}
+
+// referrersColumn summarises the referrers of a manifest by counting them,
+// or returns "-" if they weren't listed
+func referrersColumn(referrers []seaglass.Referrer) string {
+	if referrers == nil {
+		return "-"
+	}
+
+	return strconv.Itoa(len(referrers))
+}
+
+func valueColumn(v string) string {
+	if v == "" {
+		return "-"
+	}
+
+	return v
+}
+
+func tagsColumn(tags []string) string {
+	if len(tags) == 0 {
+		return "-"
+	}
+
+	return strings.Join(tags, ",")
+}
+
+// newPrinter returns a printer to stdout for the format chosen with --output
+func newPrinter[T any](f output.Format[T]) (output.Printer[T], error) {
+	return output.NewPrinter(os.Stdout, rootOpts.Output, f)
+}
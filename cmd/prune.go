@@ -0,0 +1,249 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jetstack/seaglass/internal/output"
+	"github.com/jetstack/seaglass/internal/prune"
+	"github.com/jetstack/seaglass/internal/traverse"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+	"github.com/spf13/cobra"
+)
+
+var pruneOpts struct {
+	Policy          string
+	ShowKept        bool
+	Delete          bool
+	Yes             bool
+	Recursive       bool
+	ContinueOnError bool
+	Repositories    seaglass.RepositoryListOptions
+}
+
+// pruneItem is a manifest in the plan printed by the prune command
+type pruneItem struct {
+	// Repository is the full reference to the repository, including the
+	// registry host
+	Repository string `json:"repository"`
+
+	prune.Decision
+}
+
+var pruneFormat = output.Format[pruneItem]{
+	Text: func(p pruneItem) string {
+		return fmt.Sprintf("%s %s@%s (%s)", p.Action, p.Repository, p.Digest, p.Reason)
+	},
+	Columns: []output.Column[pruneItem]{
+		{Header: "ACTION", Value: func(p pruneItem) string { return string(p.Action) }},
+		{Header: "REPOSITORY", Value: func(p pruneItem) string { return p.Repository }},
+		{Header: "DIGEST", Value: func(p pruneItem) string { return p.Digest }},
+		{Header: "TAGS", Value: func(p pruneItem) string { return tagsColumn(p.Tags) }},
+		{Header: "REFERRERS", Value: func(p pruneItem) string { return referrersColumn(p.Referrers) }},
+		{Header: "REASON", Value: func(p pruneItem) string { return p.Reason }},
+	},
+}
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Plan which manifests to delete with a retention policy",
+	Long: `Apply a retention policy to the manifests in a repository and print the
+manifests that it would delete, and why.
+
+A manifest is deleted if one of the policy's delete rules matches it and none
+of its keep rules do. The images in an index that's kept, and the referrers of
+a manifest that's kept, are always kept. For example:
+
+  time: uploaded
+  keep:
+    lastTagged: 10
+    tags: ["latest", "stable"]
+    semver: true
+    referrers: true
+    newerThan: 7d
+  delete:
+    untaggedOlderThan: 30d
+    taggedOlderThan: 365d
+    tags: ["pr-*"]
+
+The plan is a dry run, unless --delete is set. Then the manifests in the plan
+are deleted, along with their tags and referrers, once the plan is confirmed.`,
+	Example: `  seaglass prune ghcr.io/jetstack/tally --policy policy.yaml
+  seaglass prune gcr.io/my-project --recursive --policy policy.yaml --show-kept -o table
+  seaglass prune gcr.io/my-project/app --policy policy.yaml --delete`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		policy, err := prune.LoadPolicy(pruneOpts.Policy)
+		if err != nil {
+			return err
+		}
+
+		p, err := newPrinter(pruneFormat)
+		if err != nil {
+			return err
+		}
+
+		registry, repo, err := parseRepo(args[0])
+		if err != nil {
+			return fmt.Errorf("parsing repository reference: %w", err)
+		}
+
+		// A cached listing may be out of date, and a manifest that
+		// looks untagged in it may have been tagged since, so the
+		// manifests are listed from the registry when they're going to
+		// be deleted
+		newPruneClient := newClient
+		if pruneOpts.Delete {
+			newPruneClient = newUncachedClient
+		}
+		c, err := newPruneClient(registry)
+		if err != nil {
+			return fmt.Errorf("creating client for %s: %w", registry, err)
+		}
+
+		now := time.Now()
+
+		// The policy applies to each repository as a whole, so the
+		// pages for each one are merged and planned together. The pages
+		// for each repository are contiguous, so only the current
+		// repository needs to be tracked.
+		var (
+			current             string
+			seen                map[string]int
+			manifests           []seaglass.Manifest
+			repositories, total int
+			deletions           []pruneItem
+		)
+		flush := func() error {
+			if manifests == nil {
+				return nil
+			}
+			for i := range manifests {
+				sort.Strings(manifests[i].Tags)
+			}
+
+			repositories++
+			for _, d := range prune.Plan(policy, manifests, now) {
+				total++
+				item := pruneItem{
+					Repository: fmt.Sprintf("%s/%s", registry, current),
+					Decision:   d,
+				}
+				if d.Action == prune.ActionDelete {
+					deletions = append(deletions, item)
+				} else if !pruneOpts.ShowKept {
+					continue
+				}
+
+				if err := p.Print(item); err != nil {
+					return err
+				}
+			}
+			manifests = nil
+
+			return nil
+		}
+
+		err = walkManifests(ctx, c, repo, traverse.Options{
+			Recursive:             pruneOpts.Recursive,
+			ContinueOnError:       pruneOpts.ContinueOnError,
+			RepositoryListOptions: &pruneOpts.Repositories,
+			ManifestListOptions:   policy.ListOptions(),
+		}, func(page *traverse.Page) error {
+			if page.Repository != current || seen == nil {
+				if err := flush(); err != nil {
+					return err
+				}
+				current = page.Repository
+				seen = map[string]int{}
+			}
+
+			for _, manifest := range page.Manifests {
+				if i, ok := seen[manifest.Digest]; ok {
+					manifests[i].Merge(manifest)
+					continue
+				}
+				seen[manifest.Digest] = len(manifests)
+				manifests = append(manifests, manifest)
+			}
+
+			return nil
+		})
+		if ferr := flush(); ferr != nil && err == nil {
+			err = ferr
+		}
+		if ferr := p.Flush(); ferr != nil {
+			return ferr
+		}
+		if err != nil {
+			return err
+		}
+
+		if !pruneOpts.Delete || len(deletions) == 0 {
+			fmt.Fprintf(os.Stderr, "\nWould delete %d of %d manifests in %d repositories (dry run)\n", len(deletions), total, repositories)
+			return nil
+		}
+
+		ok, err := confirm(fmt.Sprintf("\nDelete %d of %d manifests in %d repositories?", len(deletions), total, repositories), pruneOpts.Yes)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("not confirmed")
+		}
+
+		defer invalidateCache(registry)
+
+		var errs []error
+		for _, item := range deletions {
+			if err := deleteManifest(ctx, c, strings.TrimPrefix(item.Repository, registry+"/"), item.Manifest); err != nil {
+				errs = append(errs, fmt.Errorf("%s@%s: %w", item.Repository, item.Digest, err))
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "Deleted %s@%s\n", item.Repository, item.Digest)
+		}
+
+		if len(errs) > 0 {
+			fmt.Fprintf(os.Stderr, "\nErrors deleting %d manifests:\n", len(errs))
+			for _, err := range errs {
+				fmt.Fprintf(os.Stderr, "  %s\n", err)
+			}
+
+			return fmt.Errorf("failed to delete %d manifests", len(errs))
+		}
+
+		return nil
+	},
+}
+
+// deleteManifest deletes the manifest and its referrers. The referrers are
+// deleted first, so that they're never left without their subject.
+func deleteManifest(ctx context.Context, c seaglass.Client, repo string, m seaglass.Manifest) error {
+	for _, r := range m.Referrers {
+		if err := deleteRef(ctx, c, repo, r.Digest); err != nil && !errors.Is(err, seaglass.ErrNotFound) {
+			return fmt.Errorf("deleting referrer %s: %w", r.Digest, err)
+		}
+	}
+
+	return deleteRef(ctx, c, repo, m.Digest)
+}
+
+func init() {
+	pruneCmd.Flags().StringVar(&pruneOpts.Policy, "policy", "", "Path to the retention policy, in YAML")
+	pruneCmd.Flags().BoolVar(&pruneOpts.ShowKept, "show-kept", false, "Print the manifests that are kept too, with the reason they're kept")
+	pruneCmd.Flags().BoolVar(&pruneOpts.Delete, "delete", false, "Delete the manifests in the plan, instead of a dry run")
+	pruneCmd.Flags().BoolVarP(&pruneOpts.Yes, "yes", "y", false, "Delete without asking for confirmation")
+	pruneCmd.Flags().BoolVar(&pruneOpts.Recursive, "recursive", false, "Apply the policy to every repository under the repository too")
+	pruneCmd.Flags().BoolVar(&pruneOpts.ContinueOnError, "continue-on-error", false, "Continue with other repositories after an error and summarise the errors at the end")
+	addRepositoryFilterFlags(pruneCmd.Flags(), &pruneOpts.Repositories)
+	_ = pruneCmd.MarkFlagRequired("policy")
+
+	rootCmd.AddCommand(pruneCmd)
+}
@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jetstack/seaglass/internal/index"
+	"github.com/jetstack/seaglass/internal/output"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var queryOpts struct {
+	IndexFile string
+	SQL       string
+}
+
+var queryCmd = &cobra.Command{
+	Use:   "query [condition...]",
+	Short: "Query the local index",
+	Long: fmt.Sprintf(`Query the index built by the index command, without querying the registries.
+
+Conditions are in the form <field><operator><value> and every one of them
+must match. Strings are matched against glob patterns with = and !=. Times can
+be compared with =, !=, <, <=, > and >=, and are RFC3339 timestamps, dates like
+2024-01-31, or durations before now like 720h or 365d.
+
+Fields: %s
+
+Alternatively, --sql runs an SQL query against the index. The tables are
+repositories, manifests, tags and crawls, and the images view joins them with
+a row for each tag.`, strings.Join(fieldNames(), ", ")),
+	Example: `  seaglass query 'created<365d'
+  seaglass query 'host=ghcr.io' 'repository=jetstack/*' 'tag=v1.*'
+  seaglass query --sql 'SELECT DISTINCT host, repository FROM images WHERE created_at < date("now", "-1 year")'`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		if queryOpts.SQL != "" && len(args) > 0 {
+			return fmt.Errorf("conditions can't be used with --sql")
+		}
+
+		idx, err := index.OpenReadOnly(queryOpts.IndexFile)
+		if err != nil {
+			return fmt.Errorf("opening index, it may need to be created with the index command: %w", err)
+		}
+		defer idx.Close()
+
+		if queryOpts.SQL != "" {
+			columns, rows, err := idx.Query(ctx, queryOpts.SQL)
+			if err != nil {
+				return err
+			}
+
+			p, err := newPrinter(sqlFormat(columns))
+			if err != nil {
+				return err
+			}
+			for _, row := range rows {
+				item := sqlRow{}
+				for n, c := range columns {
+					item[c] = sqlValue(row[n])
+				}
+				if err := p.Print(item); err != nil {
+					return err
+				}
+			}
+
+			return p.Flush()
+		}
+
+		var conds []index.Condition
+		now := time.Now()
+		for _, arg := range args {
+			cond, err := parseCondition(arg, now)
+			if err != nil {
+				return fmt.Errorf("parsing condition %q: %w", arg, err)
+			}
+			conds = append(conds, cond)
+		}
+
+		manifests, err := idx.Manifests(ctx, conds)
+		if err != nil {
+			return err
+		}
+
+		p, err := newPrinter(manifestFormat)
+		if err != nil {
+			return err
+		}
+		for _, m := range manifests {
+			item := manifestItem{
+				Repository: fmt.Sprintf("%s/%s", m.Host, m.Repository),
+				Manifest:   m.Manifest,
+			}
+			if err := p.Print(item); err != nil {
+				return err
+			}
+		}
+
+		return p.Flush()
+	},
+}
+
+func init() {
+	queryCmd.Flags().StringVar(&queryOpts.SQL, "sql", "", "SQL query to run against the index")
+	addIndexFlags(queryCmd.Flags())
+
+	rootCmd.AddCommand(queryCmd)
+}
+
+// addIndexFlags adds the flag for the location of the index to the flag set
+func addIndexFlags(fs *pflag.FlagSet) {
+	path, _ := index.DefaultPath()
+	fs.StringVar(&queryOpts.IndexFile, "index-file", path, "Path of the SQLite database that the index is stored in")
+}
+
+// openIndex opens the index at the path set with --index-file
+func openIndex() (*index.Index, error) {
+	if queryOpts.IndexFile == "" {
+		return nil, fmt.Errorf("no index file, use --index-file to set one")
+	}
+	idx, err := index.Open(queryOpts.IndexFile)
+	if err != nil {
+		return nil, fmt.Errorf("opening index: %w", err)
+	}
+
+	return idx, nil
+}
+
+// parseCondition parses a condition in the form <field><operator><value>
+func parseCondition(s string, now time.Time) (index.Condition, error) {
+	i := strings.IndexAny(s, "=!<>")
+	if i < 1 {
+		return index.Condition{}, fmt.Errorf("must be in the form <field><operator><value>")
+	}
+
+	cond := index.Condition{Field: index.Field(s[:i])}
+	if !slices.Contains(index.Fields, cond.Field) {
+		return index.Condition{}, fmt.Errorf("unknown field %q, must be one of %s", cond.Field, strings.Join(fieldNames(), ", "))
+	}
+	for _, op := range index.Operators {
+		if rest, ok := strings.CutPrefix(s[i:], string(op)); ok {
+			cond.Operator = op
+			cond.Value = rest
+			break
+		}
+	}
+	if cond.Operator == "" {
+		return index.Condition{}, fmt.Errorf("unknown operator")
+	}
+
+	if cond.Field.IsTime() {
+		t, err := parseTime(cond.Value.(string), now)
+		if err != nil {
+			return index.Condition{}, err
+		}
+		cond.Value = t
+	}
+
+	return cond, nil
+}
+
+func fieldNames() []string {
+	var names []string
+	for _, f := range index.Fields {
+		names = append(names, string(f))
+	}
+
+	return names
+}
+
+// sqlRow is a row in the output of an SQL query, keyed by column
+type sqlRow map[string]any
+
+// sqlFormat returns the format for the rows of an SQL query with the columns
+func sqlFormat(columns []string) output.Format[sqlRow] {
+	f := output.Format[sqlRow]{
+		Text: func(r sqlRow) string {
+			values := make([]string, len(columns))
+			for n, c := range columns {
+				values[n] = fmt.Sprint(r[c])
+			}
+			return strings.Join(values, "\t")
+		},
+	}
+	for _, c := range columns {
+		f.Columns = append(f.Columns, output.Column[sqlRow]{
+			Header: strings.ToUpper(c),
+			Value: func(r sqlRow) string {
+				if r[c] == nil {
+					return "-"
+				}
+				return fmt.Sprint(r[c])
+			},
+		})
+	}
+
+	return f
+}
+
+// sqlValue converts a value read from SQLite to one that can be printed
+func sqlValue(v any) any {
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+
+	return v
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jetstack/seaglass/internal/output"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+	"github.com/spf13/cobra"
+)
+
+var referrersOpts struct {
+	ArtifactType string
+}
+
+// referrerItem is a referrer in the output of the referrers command
+type referrerItem struct {
+	// Repository is the full reference to the repository, including the
+	// registry host
+	Repository string `json:"repository"`
+
+	// Subject is the digest of the manifest that the referrer refers to
+	Subject string `json:"subject"`
+
+	seaglass.Referrer
+}
+
+var referrerFormat = output.Format[referrerItem]{
+	Text: func(r referrerItem) string {
+		return r.Repository + "@" + r.Digest
+	},
+	Columns: []output.Column[referrerItem]{
+		{Header: "REPOSITORY", Value: func(r referrerItem) string { return r.Repository }},
+		{Header: "DIGEST", Value: func(r referrerItem) string { return r.Digest }},
+		{Header: "ARTIFACT TYPE", Value: func(r referrerItem) string { return valueColumn(r.ArtifactType) }},
+		{Header: "TAG", Value: func(r referrerItem) string { return valueColumn(r.Tag) }},
+		{Header: "SIZE", Value: func(r referrerItem) string { return fmt.Sprint(r.Size) }},
+	},
+}
+
+var referrersCmd = &cobra.Command{
+	Use:   "referrers",
+	Short: "List the referrers of a manifest",
+	Long: `List the manifests that refer to a manifest, like signatures, SBOMs and
+attestations.
+
+Referrers are listed with the OCI referrers API, falling back to the tag
+scheme from the OCI distribution spec when the registry doesn't support it.
+Referrers stored in cosign's tags, like sha256-<hex>.sig, .att and .sbom, are
+listed too.`,
+	Example: `  seaglass referrers ghcr.io/jetstack/tally:latest
+  seaglass referrers ghcr.io/jetstack/tally:latest --artifact-type application/vnd.dev.cosign.artifact.sig.v1+json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		p, err := newPrinter(referrerFormat)
+		if err != nil {
+			return err
+		}
+
+		registry, repo, ref, err := parseRef(args[0])
+		if err != nil {
+			return fmt.Errorf("parsing reference: %w", err)
+		}
+
+		c, err := newClient(registry)
+		if err != nil {
+			return fmt.Errorf("creating client for %s: %w", registry, err)
+		}
+
+		// Referrers are listed by digest, so a tag has to be resolved
+		// first
+		digest := ref
+		if !strings.Contains(ref, ":") {
+			detail, err := c.GetManifest(ctx, repo, ref)
+			if err != nil {
+				return fmt.Errorf("getting manifest: %w", err)
+			}
+			digest = detail.Digest
+		}
+
+		referrers, err := c.ListReferrers(ctx, repo, digest, referrersOpts.ArtifactType)
+		if err != nil {
+			return fmt.Errorf("listing referrers: %w", err)
+		}
+
+		for _, r := range referrers {
+			item := referrerItem{
+				Repository: fmt.Sprintf("%s/%s", registry, repo),
+				Subject:    digest,
+				Referrer:   r,
+			}
+			if err := p.Print(item); err != nil {
+				return err
+			}
+		}
+
+		return p.Flush()
+	},
+}
+
+func init() {
+	referrersCmd.Flags().StringVar(&referrersOpts.ArtifactType, "artifact-type", "", "Only list referrers with the artifact type")
+	rootCmd.AddCommand(referrersCmd)
+}
@@ -1,19 +1,17 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
-	"os"
 	"sort"
 	"strings"
 
-	v1 "github.com/jetstack/seaglass/internal/v1"
-	"github.com/jetstack/seaglass/internal/v1/clients/seaglass"
+	"github.com/jetstack/seaglass/pkg/seaglass"
 	"github.com/spf13/cobra"
 )
 
 var repoOpts struct {
 	Recursive bool
+	Filter    seaglass.RepositoryListOptions
 }
 
 var reposCmd = &cobra.Command{
@@ -21,39 +19,49 @@ var reposCmd = &cobra.Command{
 	Short: "List child repositories",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := context.Background()
+		ctx := cmd.Context()
+
+		p, err := newPrinter(repositoryFormat)
+		if err != nil {
+			return err
+		}
 
 		registry, repo, err := parseRepo(args[0])
 		if err != nil {
 			return fmt.Errorf("parsing repository reference: %w", err)
 		}
 
-		c, err := seaglass.NewClient(registry)
+		c, err := newClient(registry)
 		if err != nil {
 			return fmt.Errorf("creating client for %s: %w", registry, err)
 		}
 
-		repoList, err := c.ListRepositories(ctx, repo, &v1.RepositoryListOptions{
-			Recursive: repoOpts.Recursive,
-		})
-		if err != nil {
-			return fmt.Errorf("listing repositories: %w", err)
-		}
+		// Print each page as it arrives, rather than waiting for the
+		// whole list
+		listOpts := repoOpts.Filter
+		listOpts.Recursive = repoOpts.Recursive || listOpts.MaxDepth > 0
+		pages := c.ListRepositoryPages(ctx, repo, &listOpts)
+		for repoList, err := range pages {
+			if err != nil {
+				return fmt.Errorf("listing repositories: %w", err)
+			}
 
-		sort.Slice(repoList.Repositories, func(i, j int) bool {
-			return repoList.Repositories[i] < repoList.Repositories[j]
-		})
+			sort.Strings(repoList.Repositories)
 
-		for _, n := range repoList.Repositories {
-			fmt.Fprintf(os.Stdout, "%s/%s/%s\n", registry, repo, n)
+			for _, n := range repoList.Repositories {
+				if err := p.Print(repositoryItem{Repository: fmt.Sprintf("%s/%s/%s", registry, repo, n)}); err != nil {
+					return err
+				}
+			}
 		}
 
-		return nil
+		return p.Flush()
 	},
 }
 
 func init() {
 	reposCmd.PersistentFlags().BoolVar(&repoOpts.Recursive, "recursive", false, "List repositories recursively")
+	addRepositoryFilterFlags(reposCmd.PersistentFlags(), &repoOpts.Filter)
 
 	rootCmd.AddCommand(reposCmd)
 }
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jetstack/seaglass/internal/output"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+	"github.com/spf13/cobra"
+)
+
+var resolveFormat = output.Format[tagItem]{
+	Text: func(t tagItem) string {
+		return fmt.Sprintf("%s:%s@%s", t.Repository, t.Tag, t.Digest)
+	},
+	Columns: tagFormat.Columns,
+}
+
+var resolveCmd = &cobra.Command{
+	Use:   "resolve",
+	Short: "Resolve tags to the digests they point to",
+	Long: `Print the digest that each tag points to, as a reference pinned to the
+digest.
+
+Clients resolve the tag with a single request where the registry allows it,
+like a HEAD request to the v2 API or Docker Hub's API for a tag, and otherwise
+list the repository.`,
+	Example: `  seaglass resolve ghcr.io/jetstack/tally:latest
+  seaglass resolve ghcr.io/jetstack/tally:latest ghcr.io/jetstack/tally:v0.0.1 -o template='{{.Digest}}'`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		p, err := newPrinter(resolveFormat)
+		if err != nil {
+			return err
+		}
+
+		for _, arg := range args {
+			registry, repo, tag, err := parseRef(arg)
+			if err != nil {
+				return fmt.Errorf("parsing reference: %w", err)
+			}
+			if strings.Contains(tag, ":") {
+				return fmt.Errorf("%s is a digest, use tags-for to find its tags", arg)
+			}
+
+			c, err := newUncachedClient(registry)
+			if err != nil {
+				return fmt.Errorf("creating client for %s: %w", registry, err)
+			}
+
+			manifest, err := seaglass.ResolveTag(ctx, c, repo, tag)
+			if err != nil {
+				return fmt.Errorf("resolving %s: %w", arg, err)
+			}
+
+			if err := p.Print(newTagItem(fmt.Sprintf("%s/%s", registry, repo), tag, *manifest)); err != nil {
+				return err
+			}
+		}
+
+		return p.Flush()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(resolveCmd)
+}
@@ -1,21 +1,152 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"time"
 
+	"github.com/jetstack/seaglass/internal/cache"
+	"github.com/jetstack/seaglass/internal/output"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+	"github.com/jetstack/seaglass/pkg/seaglass/clients"
 	"github.com/spf13/cobra"
 )
 
+var rootOpts struct {
+	ClientTypes map[string]string
+	Concurrency int
+	Output      string
+	NoCache     bool
+	CacheTTL    time.Duration
+	CacheDir    string
+}
+
+// clientCache is the cache that clients store listings and responses in, or
+// nil if caching is disabled
+var clientCache *cache.Cache
+
 var rootCmd = &cobra.Command{
 	Use:   "seaglass",
 	Short: "Discover container images efficiently.",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if rootOpts.NoCache || rootOpts.CacheDir == "" {
+			return
+		}
+		clientCache = cache.New(rootOpts.CacheDir)
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	err := rootCmd.Execute()
+	// Cancel any requests that are in flight when interrupted
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err := rootCmd.ExecuteContext(ctx)
 	if err != nil {
 		os.Exit(1)
 	}
 }
+
+func init() {
+	rootCmd.PersistentFlags().StringToStringVar(
+		&rootOpts.ClientTypes,
+		"client-type",
+		nil,
+		fmt.Sprintf(
+			"Use a specific client for a registry host, in the form <host>=<type>. Supported types: %s",
+			strings.Join(clients.ClientTypes(), ", "),
+		),
+	)
+	rootCmd.PersistentFlags().StringVarP(
+		&rootOpts.Output,
+		"output",
+		"o",
+		"",
+		fmt.Sprintf(
+			"Output format. One of: %s, template=<go template>",
+			strings.Join(output.Formats, ", "),
+		),
+	)
+	rootCmd.PersistentFlags().BoolVar(
+		&rootOpts.NoCache,
+		"no-cache",
+		false,
+		"Don't read or write the cache of listings and API responses",
+	)
+	rootCmd.PersistentFlags().DurationVar(
+		&rootOpts.CacheTTL,
+		"cache-ttl",
+		5*time.Minute,
+		"How long listings are cached for before they're listed again. API responses are revalidated with the registry when it supports it",
+	)
+	cacheDir, _ := cache.DefaultDir()
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.CacheDir,
+		"cache-dir",
+		cacheDir,
+		"Directory to cache listings and API responses in",
+	)
+	rootCmd.PersistentFlags().IntVar(
+		&rootOpts.Concurrency,
+		"concurrency",
+		10,
+		"Maximum number of repositories to list at once with --recursive, and of tags to resolve at once for clients that fetch each tag individually",
+	)
+}
+
+// newClient returns a client for the registry host, respecting any client type
+// that has been configured for the host with --client-type
+func newClient(host string) (seaglass.Client, error) {
+	c, err := newUncachedClient(host)
+	if err != nil {
+		return nil, err
+	}
+
+	if clientCache == nil {
+		return c, nil
+	}
+
+	return cache.NewClient(c, host, clientCache, rootOpts.CacheTTL), nil
+}
+
+// newUncachedClient returns a client for the registry host, like newClient,
+// that lists from the registry every time rather than from the cache
+func newUncachedClient(host string) (seaglass.Client, error) {
+	if clientType, ok := rootOpts.ClientTypes[host]; ok {
+		return clients.NewClientOfType(host, clientType, clientOptions()...)
+	}
+
+	return clients.NewClient(host, clientOptions()...)
+}
+
+// invalidateCache removes the cached listings for the host. Commands that
+// delete with an uncached client call this, so that later listings don't show
+// what was deleted.
+func invalidateCache(host string) {
+	if clientCache == nil {
+		return
+	}
+	_ = clientCache.Invalidate(host)
+}
+
+// clientOptions returns the options that every client is created with
+func clientOptions() []seaglass.Option {
+	opts := []seaglass.Option{
+		seaglass.WithUserAgent("seaglass"),
+	}
+	if clientCache != nil {
+		// Revalidate the responses from the registry APIs that list
+		// repositories and tags with the cache
+		opts = append(opts, seaglass.WithAPITransport(func(rt http.RoundTripper) http.RoundTripper {
+			return cache.NewTransport(rt, clientCache)
+		}))
+	}
+
+	return opts
+}
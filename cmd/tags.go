@@ -1,17 +1,18 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
-	"os"
 
-	v1 "github.com/jetstack/seaglass/internal/v1"
-	"github.com/jetstack/seaglass/internal/v1/clients/seaglass"
+	"github.com/jetstack/seaglass/internal/traverse"
+	"github.com/jetstack/seaglass/pkg/seaglass"
 	"github.com/spf13/cobra"
 )
 
 var tagsOpts struct {
-	Recursive bool
+	Recursive       bool
+	ContinueOnError bool
+	Repositories    seaglass.RepositoryListOptions
+	Filter          seaglass.ManifestListOptions
 }
 
 var tagsCmd = &cobra.Command{
@@ -19,50 +20,71 @@ var tagsCmd = &cobra.Command{
 	Short: "List tags",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := context.Background()
+		ctx := cmd.Context()
+
+		p, err := newPrinter(tagFormat)
+		if err != nil {
+			return err
+		}
 
 		registry, repo, err := parseRepo(args[0])
 		if err != nil {
 			return fmt.Errorf("parsing repository reference: %w", err)
 		}
 
-		c, err := seaglass.NewClient(registry)
+		c, err := newClient(registry)
 		if err != nil {
 			return fmt.Errorf("creating client for %s: %w", registry, err)
 		}
 
-		repos := []string{repo}
-
-		if tagsOpts.Recursive {
-			repoList, err := c.ListRepositories(ctx, repo, &v1.RepositoryListOptions{Recursive: true})
-			if err != nil {
-				return fmt.Errorf("listing repositories for %s: %w", repo, err)
-			}
-
-			for _, r := range repoList.Repositories {
-				repos = append(repos, fmt.Sprintf("%s/%s", repoList.Name, r))
-			}
-		}
+		// The pages for each repository are contiguous, so only the
+		// current repository needs to be tracked
+		var (
+			current string
+			seen    map[string]struct{}
+		)
 
-		for _, r := range repos {
-			manifestList, err := c.ListManifests(ctx, r, nil)
-			if err != nil {
-				return fmt.Errorf("listing tags for %s: %w", repo, err)
+		err = walkManifests(ctx, c, repo, traverse.Options{
+			Recursive:             tagsOpts.Recursive,
+			ContinueOnError:       tagsOpts.ContinueOnError,
+			RepositoryListOptions: &tagsOpts.Repositories,
+			ManifestListOptions:   &tagsOpts.Filter,
+		}, func(page *traverse.Page) error {
+			if page.Repository != current || seen == nil {
+				current = page.Repository
+				seen = map[string]struct{}{}
 			}
 
-			for _, manifest := range manifestList.Manifests {
+			for _, manifest := range page.Manifests {
 				for _, tag := range manifest.Tags {
-					fmt.Fprintf(os.Stdout, "%s/%s:%s\n", registry, r, tag)
+					// A tag may appear in more than one page
+					if _, ok := seen[tag]; ok {
+						continue
+					}
+					seen[tag] = struct{}{}
+
+					item := newTagItem(fmt.Sprintf("%s/%s", registry, page.Repository), tag, manifest)
+					if err := p.Print(item); err != nil {
+						return err
+					}
 				}
 			}
+
+			return nil
+		})
+		if ferr := p.Flush(); ferr != nil {
+			return ferr
 		}
 
-		return nil
+		return err
 	},
 }
 
 func init() {
 	tagsCmd.PersistentFlags().BoolVar(&tagsOpts.Recursive, "recursive", false, "List tags recursively")
+	tagsCmd.PersistentFlags().BoolVar(&tagsOpts.ContinueOnError, "continue-on-error", false, "Continue listing other repositories after an error and summarise the errors at the end")
+	addRepositoryFilterFlags(tagsCmd.PersistentFlags(), &tagsOpts.Repositories)
+	addFilterFlags(tagsCmd.PersistentFlags(), &tagsOpts.Filter)
 
 	rootCmd.AddCommand(tagsCmd)
 }
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jetstack/seaglass/pkg/seaglass"
+	"github.com/spf13/cobra"
+)
+
+var tagsForCmd = &cobra.Command{
+	Use:   "tags-for",
+	Short: "List the tags that point to a digest",
+	Long: `List the tags in a repository that point to the manifest with a digest.
+
+Registries don't have a way to look up the tags of a manifest, so this lists
+the repository.`,
+	Example: `  seaglass tags-for ghcr.io/jetstack/tally@sha256:87f4f96fc7493d7e77c628583e0cf776a90bf95fd83168e9c0e8fd6db5624656`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		p, err := newPrinter(tagFormat)
+		if err != nil {
+			return err
+		}
+
+		registry, repo, digest, err := parseRef(args[0])
+		if err != nil {
+			return fmt.Errorf("parsing reference: %w", err)
+		}
+		if !strings.Contains(digest, ":") {
+			return fmt.Errorf("reference must be of the form '<host>/<repository>@<digest>'")
+		}
+
+		c, err := newUncachedClient(registry)
+		if err != nil {
+			return fmt.Errorf("creating client for %s: %w", registry, err)
+		}
+
+		manifest, err := seaglass.FindManifest(ctx, c, repo, digest)
+		if err != nil {
+			return fmt.Errorf("finding %s: %w", args[0], err)
+		}
+
+		if len(manifest.Tags) == 0 {
+			fmt.Fprintf(os.Stderr, "No tags point to %s\n", args[0])
+		}
+		for _, tag := range manifest.Tags {
+			if err := p.Print(newTagItem(fmt.Sprintf("%s/%s", registry, repo), tag, *manifest)); err != nil {
+				return err
+			}
+		}
+
+		return p.Flush()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tagsForCmd)
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/jetstack/seaglass/internal/traverse"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+)
+
+// walkManifests calls fn for each page of manifests in the repository and, if
+// recursive, every repository under it, as set in the options. An error
+// returned by fn stops the walk.
+//
+// If ContinueOnError is set, then errors for individual repositories are
+// collected and printed in a summary at the end, rather than stopping at the
+// first one.
+func walkManifests(ctx context.Context, c seaglass.Client, repo string, opts traverse.Options, fn func(page *traverse.Page) error) error {
+	// Check the filters up front, rather than failing for every
+	// repository
+	if opts.RepositoryListOptions != nil {
+		if opts.RepositoryListOptions.MaxDepth > 0 {
+			opts.Recursive = true
+		}
+		f, err := seaglass.NewRepositoryFilter(opts.RepositoryListOptions)
+		if err != nil {
+			return fmt.Errorf("invalid filter: %w", err)
+		}
+		filters := f.Filters() || len(opts.RepositoryListOptions.Exclude) > 0
+		if filters && !opts.Recursive {
+			return fmt.Errorf("--include, --exclude, --name-prefix and --name-contains can only be used with --recursive")
+		}
+	}
+	if opts.ManifestListOptions != nil {
+		if _, err := seaglass.NewManifestFilter(opts.ManifestListOptions); err != nil {
+			return fmt.Errorf("invalid filter: %w", err)
+		}
+	}
+
+	listOpts := seaglass.ManifestListOptions{}
+	if opts.ManifestListOptions != nil {
+		listOpts = *opts.ManifestListOptions
+	}
+	listOpts.Concurrency = rootOpts.Concurrency
+	opts.ManifestListOptions = &listOpts
+	opts.Concurrency = rootOpts.Concurrency
+
+	pages := traverse.Manifests(ctx, c, repo, &opts)
+
+	var errs []error
+	for page, err := range pages {
+		if err != nil {
+			if !opts.ContinueOnError {
+				return fmt.Errorf("listing manifests: %w", err)
+			}
+			errs = append(errs, err)
+			continue
+		}
+
+		if err := fn(page); err != nil {
+			return err
+		}
+	}
+
+	if len(errs) > 0 {
+		fmt.Fprintf(os.Stderr, "\nErrors listing %d repositories:\n", len(errs))
+		for _, err := range errs {
+			fmt.Fprintf(os.Stderr, "  %s\n", err)
+		}
+
+		return fmt.Errorf("failed to list %d repositories", len(errs))
+	}
+
+	return nil
+}
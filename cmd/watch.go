@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jetstack/seaglass/internal/output"
+	"github.com/jetstack/seaglass/internal/traverse"
+	"github.com/jetstack/seaglass/internal/watch"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+	"github.com/spf13/cobra"
+)
+
+var watchOpts struct {
+	Interval     time.Duration
+	MaxBackoff   time.Duration
+	Webhook      string
+	Recursive    bool
+	Repositories seaglass.RepositoryListOptions
+	Filter       seaglass.ManifestListOptions
+}
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Watch a repository for changes to its tags and manifests",
+	Long: `Poll a repository and emit an event for each change to its tags and
+manifests: tag.created, tag.moved, tag.deleted and manifest.pushed.
+
+Events are printed to stdout as ndjson, or posted to --webhook as JSON. The
+first poll records the current state of the repository, so events are only
+emitted for the changes after it.
+
+After an error, the interval doubles with each poll that fails, up to
+--max-backoff. Events that fail to post are posted again after the next poll.
+
+Listings aren't read from the cache, but requests are still subject to the
+rate limits of each client.`,
+	Example: `  seaglass watch ghcr.io/org/app
+  seaglass watch ghcr.io/org/app --include-tag 'v*' --webhook https://ci.example.com/hooks/deploy
+  seaglass watch ghcr.io/org --recursive --interval 5m`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		if watchOpts.Interval <= 0 {
+			return fmt.Errorf("--interval must be positive")
+		}
+
+		registry, repo, err := parseRepo(args[0])
+		if err != nil {
+			return fmt.Errorf("parsing repository reference: %w", err)
+		}
+
+		// The same client is used for every poll, so that its rate
+		// limits apply across them
+		c, err := newUncachedClient(registry)
+		if err != nil {
+			return fmt.Errorf("creating client for %s: %w", registry, err)
+		}
+
+		sink, err := watchSink()
+		if err != nil {
+			return err
+		}
+
+		poll := func(ctx context.Context) (watch.State, error) {
+			state := watch.State{}
+			err := walkManifests(ctx, c, repo, traverse.Options{
+				Recursive:             watchOpts.Recursive,
+				RepositoryListOptions: &watchOpts.Repositories,
+				ManifestListOptions:   &watchOpts.Filter,
+			}, func(page *traverse.Page) error {
+				for _, manifest := range page.Manifests {
+					state.Add(fmt.Sprintf("%s/%s", registry, page.Repository), manifest)
+				}
+				return nil
+			})
+
+			return state, err
+		}
+
+		w := watch.New(poll, sink, watch.Options{
+			Interval:   watchOpts.Interval,
+			MaxBackoff: watchOpts.MaxBackoff,
+			OnError: func(err error, wait time.Duration) {
+				fmt.Fprintf(os.Stderr, "Error: %s, polling again in %s\n", err, wait)
+			},
+		})
+
+		fmt.Fprintf(os.Stderr, "Watching %s/%s every %s\n", registry, repo, watchOpts.Interval)
+
+		if err := w.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			return err
+		}
+
+		return nil
+	},
+}
+
+// watchSink returns the sink that events are sent to: the webhook, if there
+// is one, or otherwise stdout
+func watchSink() (watch.Sink, error) {
+	if watchOpts.Webhook != "" {
+		return watch.NewWebhook(watchOpts.Webhook, &http.Client{Timeout: 30 * time.Second}), nil
+	}
+
+	// Events are printed as they happen, so the formats that are
+	// buffered until the end aren't supported
+	format := rootOpts.Output
+	if format == "" {
+		format = "ndjson"
+	}
+	if format != "ndjson" && !strings.HasPrefix(format, "template=") {
+		return nil, fmt.Errorf("unsupported output format %q for watch, must be ndjson or template=<template>", format)
+	}
+
+	p, err := output.NewPrinter(os.Stdout, format, output.Format[watch.Event]{})
+	if err != nil {
+		return nil, err
+	}
+
+	return watch.SinkFunc(func(ctx context.Context, event watch.Event) error {
+		return p.Print(event)
+	}), nil
+}
+
+func init() {
+	watchCmd.Flags().DurationVar(&watchOpts.Interval, "interval", time.Minute, "How long to wait between polls")
+	watchCmd.Flags().DurationVar(&watchOpts.MaxBackoff, "max-backoff", 10*time.Minute, "Longest to wait between polls after errors")
+	watchCmd.Flags().StringVar(&watchOpts.Webhook, "webhook", "", "URL to post each event to as JSON, rather than printing it")
+	watchCmd.Flags().BoolVar(&watchOpts.Recursive, "recursive", false, "Watch every repository under the repository too")
+	addRepositoryFilterFlags(watchCmd.Flags(), &watchOpts.Repositories)
+	addFilterFlags(watchCmd.Flags(), &watchOpts.Filter)
+
+	rootCmd.AddCommand(watchCmd)
+}
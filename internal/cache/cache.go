@@ -0,0 +1,195 @@
+// Package cache caches the repositories and manifests listed by a client on
+// disk, and the responses from registry APIs that can be revalidated with an
+// ETag.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// listingsDir is the directory that listings are stored in
+	listingsDir = "listings"
+
+	// responsesDir is the directory that responses are stored in
+	responsesDir = "responses"
+)
+
+// Cache is a cache on disk. Each entry is a JSON file and the time that it was
+// stored, or last revalidated, is the modification time of the file.
+type Cache struct {
+	dir string
+}
+
+// New returns a cache that stores entries in the directory. The directory is
+// created when the first entry is stored.
+func New(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// DefaultDir returns the default directory for the cache, under the user's
+// cache directory
+func DefaultDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("finding user cache directory: %w", err)
+	}
+
+	return filepath.Join(dir, "seaglass"), nil
+}
+
+// PruneOptions are options for pruning the cache
+type PruneOptions struct {
+	// ListingsOlderThan removes listings that were stored longer ago than
+	// this.
+	ListingsOlderThan time.Duration
+
+	// ResponsesOlderThan removes responses that were stored, or last
+	// revalidated, longer ago than this.
+	ResponsesOlderThan time.Duration
+}
+
+// PruneResult describes the entries removed from the cache
+type PruneResult struct {
+	// Entries is the number of entries that were removed.
+	Entries int `json:"entries"`
+
+	// Bytes is the total size of the entries that were removed.
+	Bytes int64 `json:"bytes"`
+}
+
+// Prune removes the entries in the cache that are older than the options
+// allow
+func (c *Cache) Prune(opts PruneOptions) (*PruneResult, error) {
+	result := &PruneResult{}
+	now := time.Now()
+
+	for dir, maxAge := range map[string]time.Duration{
+		listingsDir:  opts.ListingsOlderThan,
+		responsesDir: opts.ResponsesOlderThan,
+	} {
+		err := filepath.WalkDir(filepath.Join(c.dir, dir), func(path string, d fs.DirEntry, err error) error {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			if now.Sub(info.ModTime()) <= maxAge {
+				return nil
+			}
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+			result.Entries++
+			result.Bytes += info.Size()
+
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("pruning %s: %w", dir, err)
+		}
+	}
+
+	return result, nil
+}
+
+// Clear removes every entry from the cache
+func (c *Cache) Clear() error {
+	for _, dir := range []string{listingsDir, responsesDir} {
+		if err := os.RemoveAll(filepath.Join(c.dir, dir)); err != nil {
+			return fmt.Errorf("removing %s: %w", dir, err)
+		}
+	}
+
+	return nil
+}
+
+// Invalidate removes the listings for the host, so that they're listed again
+func (c *Cache) Invalidate(host string) error {
+	return os.RemoveAll(filepath.Join(c.dir, listingsDir, strings.ReplaceAll(host, ":", "_")))
+}
+
+// path returns the path of the entry for the key. Entries are grouped by the
+// host that they're for.
+func (c *Cache) path(dir, host, key string) string {
+	sum := sha256.Sum256([]byte(key))
+
+	return filepath.Join(c.dir, dir, strings.ReplaceAll(host, ":", "_"), hex.EncodeToString(sum[:])+".json")
+}
+
+// read decodes the entry at the path into v. Returns false if there isn't an
+// entry, or if it's older than maxAge. A negative maxAge never expires.
+func (c *Cache) read(path string, maxAge time.Duration, v any) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	if maxAge >= 0 && time.Since(info.ModTime()) > maxAge {
+		return false
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+
+	return json.Unmarshal(b, v) == nil
+}
+
+// write stores v as the entry at the path. The entry is written to a
+// temporary file first, so that concurrent readers never see a partial entry.
+func (c *Cache) write(path string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encoding entry: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("creating directory: %w", err)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("creating file: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return fmt.Errorf("writing file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing file: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("renaming file: %w", err)
+	}
+
+	return nil
+}
+
+// touch marks the entry at the path as stored now
+func (c *Cache) touch(path string) error {
+	now := time.Now()
+
+	return os.Chtimes(path, now, now)
+}
@@ -0,0 +1,166 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"time"
+
+	"github.com/jetstack/seaglass/pkg/seaglass"
+)
+
+// Client is a client that caches the repositories and manifests listed by
+// another client. Listings are keyed by the host, the repository and the
+// options they were listed with.
+//
+// Other methods are passed through to the client. Deleting a manifest or a tag
+// invalidates the listings for the host.
+type Client struct {
+	seaglass.Client
+
+	cache *Cache
+	host  string
+	ttl   time.Duration
+}
+
+// NewClient returns a client that caches the listings of the client for the
+// host, for as long as the ttl
+func NewClient(c seaglass.Client, host string, cache *Cache, ttl time.Duration) *Client {
+	return &Client{
+		Client: c,
+		cache:  cache,
+		host:   host,
+		ttl:    ttl,
+	}
+}
+
+// ListRepositories lists repositories from the cache, or the client if they
+// aren't cached
+func (c *Client) ListRepositories(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) (*seaglass.RepositoryList, error) {
+	return seaglass.CollectRepositories(repo, c.ListRepositoryPages(ctx, repo, opts))
+}
+
+// ListRepositoryPages lists repositories from the cache, or the client if they
+// aren't cached
+func (c *Client) ListRepositoryPages(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) iter.Seq2[*seaglass.RepositoryList, error] {
+	key, err := c.key("repositories", repo, opts)
+	if err != nil {
+		return func(yield func(*seaglass.RepositoryList, error) bool) {
+			yield(nil, err)
+		}
+	}
+
+	return cachedPages(c.cache, c.cache.path(listingsDir, c.host, key), c.ttl, c.Client.ListRepositoryPages(ctx, repo, opts))
+}
+
+// ListManifests lists manifests from the cache, or the client if they aren't
+// cached
+func (c *Client) ListManifests(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) (*seaglass.ManifestList, error) {
+	return seaglass.CollectManifests(c.ListManifestPages(ctx, repo, opts))
+}
+
+// ListManifestPages lists manifests from the cache, or the client if they
+// aren't cached
+func (c *Client) ListManifestPages(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) iter.Seq2[*seaglass.ManifestList, error] {
+	// The concurrency doesn't change what's listed
+	var keyOpts *seaglass.ManifestListOptions
+	if opts != nil {
+		o := *opts
+		o.Concurrency = 0
+		keyOpts = &o
+	}
+	key, err := c.key("manifests", repo, keyOpts)
+	if err != nil {
+		return func(yield func(*seaglass.ManifestList, error) bool) {
+			yield(nil, err)
+		}
+	}
+
+	return cachedPages(c.cache, c.cache.path(listingsDir, c.host, key), c.ttl, c.Client.ListManifestPages(ctx, repo, opts))
+}
+
+// DeleteManifest deletes the manifest with the client, if it's a seaglass.Deleter
+func (c *Client) DeleteManifest(ctx context.Context, repo, digest string) error {
+	d, ok := c.Client.(seaglass.Deleter)
+	if !ok {
+		return seaglass.ErrNotSupported
+	}
+	defer c.cache.Invalidate(c.host)
+
+	return d.DeleteManifest(ctx, repo, digest)
+}
+
+// DeleteTag deletes the tag with the client, if it's a seaglass.Deleter
+func (c *Client) DeleteTag(ctx context.Context, repo, tag string) error {
+	d, ok := c.Client.(seaglass.Deleter)
+	if !ok {
+		return seaglass.ErrNotSupported
+	}
+	defer c.cache.Invalidate(c.host)
+
+	return d.DeleteTag(ctx, repo, tag)
+}
+
+// ResolveTag resolves the tag with the client, if it's a seaglass.TagResolver. The
+// result isn't cached, because it's used to find what a tag points to now.
+func (c *Client) ResolveTag(ctx context.Context, repo, tag string) (*seaglass.Manifest, error) {
+	r, ok := c.Client.(seaglass.TagResolver)
+	if !ok {
+		return nil, seaglass.ErrNotSupported
+	}
+
+	return r.ResolveTag(ctx, repo, tag)
+}
+
+func (c *Client) key(kind, repo string, opts any) (string, error) {
+	b, err := json.Marshal(opts)
+	if err != nil {
+		return "", fmt.Errorf("encoding options: %w", err)
+	}
+
+	return fmt.Sprintf("%s\n%s\n%s\n%s", kind, c.host, repo, b), nil
+}
+
+// cachedPages yields the pages stored at the path, if they're newer than the
+// ttl. Otherwise, it yields the pages from the client and stores them once
+// they've all been listed.
+//
+// Each page is encoded before it's yielded, so that changes made to it by the
+// consumer aren't stored.
+func cachedPages[T any](c *Cache, path string, ttl time.Duration, pages iter.Seq2[*T, error]) iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		var cached []*T
+		if c.read(path, ttl, &cached) {
+			for _, page := range cached {
+				if !yield(page, nil) {
+					return
+				}
+			}
+			return
+		}
+
+		var listed []json.RawMessage
+		for page, err := range pages {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			b, err := json.Marshal(page)
+			if err != nil {
+				yield(nil, fmt.Errorf("encoding page: %w", err))
+				return
+			}
+			listed = append(listed, b)
+
+			if !yield(page, nil) {
+				return
+			}
+		}
+
+		// The cache is an optimisation, so failing to store the pages
+		// shouldn't fail the listing
+		_ = c.write(path, listed)
+	}
+}
@@ -0,0 +1,272 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+)
+
+func TestClientListManifests(t *testing.T) {
+	pages := []*seaglass.ManifestList{
+		{Manifests: []seaglass.Manifest{{Digest: "sha256:aaaaaaa", Tags: []string{"latest"}}}},
+		{Manifests: []seaglass.Manifest{{Digest: "sha256:bbbbbbb", Tags: []string{"v1.0.0"}}}},
+	}
+
+	t.Run("cached listing", func(t *testing.T) {
+		ctx := context.Background()
+
+		fc := &fakeClient{pages: pages}
+		c := NewClient(fc, "example.com", New(t.TempDir()), time.Hour)
+
+		for range 2 {
+			got, err := c.ListManifests(ctx, "foo/bar", nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			want := &seaglass.ManifestList{
+				Manifests: []seaglass.Manifest{pages[0].Manifests[0], pages[1].Manifests[0]},
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("unexpected result:\n%s", diff)
+			}
+		}
+
+		if fc.calls != 1 {
+			t.Errorf("unexpected number of calls to the client: %d", fc.calls)
+		}
+	})
+
+	t.Run("expired listing", func(t *testing.T) {
+		ctx := context.Background()
+
+		fc := &fakeClient{pages: pages}
+		c := NewClient(fc, "example.com", New(t.TempDir()), 0)
+
+		for range 2 {
+			if _, err := c.ListManifests(ctx, "foo/bar", nil); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+		}
+
+		if fc.calls != 2 {
+			t.Errorf("unexpected number of calls to the client: %d", fc.calls)
+		}
+	})
+
+	t.Run("keyed by repository and options", func(t *testing.T) {
+		ctx := context.Background()
+
+		fc := &fakeClient{pages: pages}
+		c := NewClient(fc, "example.com", New(t.TempDir()), time.Hour)
+
+		calls := []struct {
+			repo string
+			opts *seaglass.ManifestListOptions
+		}{
+			{"foo/bar", nil},
+			{"foo/baz", nil},
+			{"foo/bar", &seaglass.ManifestListOptions{IncludeTags: []string{"v*"}}},
+			// The concurrency doesn't change the key
+			{"foo/bar", &seaglass.ManifestListOptions{IncludeTags: []string{"v*"}, Concurrency: 5}},
+		}
+		for _, call := range calls {
+			if _, err := c.ListManifests(ctx, call.repo, call.opts); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+		}
+
+		if fc.calls != 3 {
+			t.Errorf("unexpected number of calls to the client: %d", fc.calls)
+		}
+	})
+
+	t.Run("partial listing isn't cached", func(t *testing.T) {
+		ctx := context.Background()
+
+		fc := &fakeClient{pages: pages}
+		c := NewClient(fc, "example.com", New(t.TempDir()), time.Hour)
+
+		for range c.ListManifestPages(ctx, "foo/bar", nil) {
+			break
+		}
+		if _, err := c.ListManifests(ctx, "foo/bar", nil); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if fc.calls != 2 {
+			t.Errorf("unexpected number of calls to the client: %d", fc.calls)
+		}
+	})
+
+	t.Run("error isn't cached", func(t *testing.T) {
+		ctx := context.Background()
+
+		fc := &fakeClient{err: seaglass.ErrNotFound}
+		c := NewClient(fc, "example.com", New(t.TempDir()), time.Hour)
+
+		for range 2 {
+			if _, err := c.ListManifests(ctx, "foo/bar", nil); !errors.Is(err, seaglass.ErrNotFound) {
+				t.Fatalf("unexpected error: %s", err)
+			}
+		}
+
+		if fc.calls != 2 {
+			t.Errorf("unexpected number of calls to the client: %d", fc.calls)
+		}
+	})
+}
+
+func TestClientListRepositories(t *testing.T) {
+	ctx := context.Background()
+
+	fc := &fakeClient{repositories: []string{"bar", "baz"}}
+	c := NewClient(fc, "example.com", New(t.TempDir()), time.Hour)
+
+	for range 2 {
+		got, err := c.ListRepositories(ctx, "foo", &seaglass.RepositoryListOptions{Recursive: true})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		want := &seaglass.RepositoryList{
+			Name:         "foo",
+			Repositories: []string{"bar", "baz"},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	}
+
+	if fc.calls != 1 {
+		t.Errorf("unexpected number of calls to the client: %d", fc.calls)
+	}
+}
+
+func TestCachePrune(t *testing.T) {
+	c := New(t.TempDir())
+
+	listing := c.path(listingsDir, "example.com", "listing")
+	response := c.path(responsesDir, "example.com", "response")
+	for _, path := range []string{listing, response} {
+		if err := c.write(path, "entry"); err != nil {
+			t.Fatalf("unexpected error writing entry: %s", err)
+		}
+	}
+
+	result, err := c.Prune(PruneOptions{
+		ListingsOlderThan:  -1,
+		ResponsesOlderThan: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if result.Entries != 1 {
+		t.Errorf("unexpected number of entries removed: %d", result.Entries)
+	}
+
+	var entry string
+	if c.read(listing, -1, &entry) {
+		t.Errorf("expected listing to be removed")
+	}
+	if !c.read(response, -1, &entry) {
+		t.Errorf("expected response to be kept")
+	}
+}
+
+func TestClientDelete(t *testing.T) {
+	pages := []*seaglass.ManifestList{
+		{Manifests: []seaglass.Manifest{{Digest: "sha256:aaaaaaa", Tags: []string{"latest"}}}},
+	}
+
+	t.Run("deleting invalidates listings", func(t *testing.T) {
+		ctx := context.Background()
+
+		fc := &fakeDeleter{fakeClient: fakeClient{pages: pages}}
+		c := NewClient(fc, "example.com", New(t.TempDir()), time.Hour)
+
+		if _, err := c.ListManifests(ctx, "foo/bar", nil); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if err := c.DeleteTag(ctx, "foo/bar", "latest"); err != nil {
+			t.Fatalf("unexpected error deleting tag: %s", err)
+		}
+		if _, err := c.ListManifests(ctx, "foo/bar", nil); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if diff := cmp.Diff([]string{"foo/bar:latest"}, fc.deleted); diff != "" {
+			t.Errorf("unexpected deletions:\n%s", diff)
+		}
+		if fc.calls != 2 {
+			t.Errorf("unexpected number of calls to the client: %d", fc.calls)
+		}
+	})
+
+	t.Run("client that can't delete", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := NewClient(&fakeClient{pages: pages}, "example.com", New(t.TempDir()), time.Hour)
+
+		if err := c.DeleteManifest(ctx, "foo/bar", "sha256:aaaaaaa"); !errors.Is(err, seaglass.ErrNotSupported) {
+			t.Errorf("unexpected error: %s", err)
+		}
+	})
+}
+
+// fakeClient is a client that lists the same pages for every repository and
+// counts the number of times it's called
+type fakeClient struct {
+	seaglass.Client
+
+	pages        []*seaglass.ManifestList
+	repositories []string
+	err          error
+	calls        int
+}
+
+func (c *fakeClient) ListRepositoryPages(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) iter.Seq2[*seaglass.RepositoryList, error] {
+	return func(yield func(*seaglass.RepositoryList, error) bool) {
+		c.calls++
+		if c.err != nil {
+			yield(nil, c.err)
+			return
+		}
+		yield(&seaglass.RepositoryList{Name: repo, Repositories: c.repositories}, nil)
+	}
+}
+
+func (c *fakeClient) ListManifestPages(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) iter.Seq2[*seaglass.ManifestList, error] {
+	return func(yield func(*seaglass.ManifestList, error) bool) {
+		c.calls++
+		if c.err != nil {
+			yield(nil, c.err)
+			return
+		}
+		for _, page := range c.pages {
+			if !yield(page, nil) {
+				return
+			}
+		}
+	}
+}
+
+// fakeDeleter is a fakeClient that records what's deleted
+type fakeDeleter struct {
+	fakeClient
+
+	deleted []string
+}
+
+func (c *fakeDeleter) DeleteManifest(ctx context.Context, repo, digest string) error {
+	c.deleted = append(c.deleted, repo+"@"+digest)
+	return nil
+}
+
+func (c *fakeDeleter) DeleteTag(ctx context.Context, repo, tag string) error {
+	c.deleted = append(c.deleted, repo+":"+tag)
+	return nil
+}
@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// NewTransport returns a http.RoundTripper that stores the responses to GET
+// requests that have an ETag and revalidates them with If-None-Match. When the
+// server responds with 304 Not Modified, the stored response is returned
+// instead.
+//
+// The server only responds with 304 Not Modified if the ETag matches what it
+// would have returned for the request, so responses are keyed by the URL
+// alone.
+func NewTransport(rt http.RoundTripper, c *Cache) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+
+	return &transport{
+		rt:    rt,
+		cache: c,
+	}
+}
+
+type transport struct {
+	rt    http.RoundTripper
+	cache *Cache
+}
+
+// response is a response stored in the cache
+type response struct {
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+// RoundTrip makes the request, revalidating the stored response if there is
+// one
+func (t *transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.Method != http.MethodGet || r.Header.Get("Range") != "" || r.Header.Get("If-None-Match") != "" {
+		return t.rt.RoundTrip(r)
+	}
+
+	path := t.cache.path(responsesDir, r.URL.Host, r.URL.String())
+
+	var cached response
+	ok := t.cache.read(path, -1, &cached)
+
+	req := r
+	if ok {
+		req = r.Clone(r.Context())
+		req.Header.Set("If-None-Match", cached.Header.Get("ETag"))
+	}
+
+	resp, err := t.rt.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		_ = t.cache.touch(path)
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", http.StatusOK, http.StatusText(http.StatusOK)),
+			StatusCode:    http.StatusOK,
+			Proto:         resp.Proto,
+			ProtoMajor:    resp.ProtoMajor,
+			ProtoMinor:    resp.ProtoMinor,
+			Header:        cached.Header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(cached.Body)),
+			ContentLength: int64(len(cached.Body)),
+			Request:       r,
+		}, nil
+	}
+
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("ETag") == "" {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("reading body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	// The cache is an optimisation, so failing to store the response
+	// shouldn't fail the request
+	_ = t.cache.write(path, response{
+		Header: resp.Header,
+		Body:   body,
+	})
+
+	return resp, nil
+}
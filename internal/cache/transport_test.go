@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTransport(t *testing.T) {
+	var requests, notModified int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path == "/no-etag" {
+			w.Write([]byte("no etag"))
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("body"))
+	}))
+	t.Cleanup(srv.Close)
+
+	c := &http.Client{Transport: NewTransport(nil, New(t.TempDir()))}
+
+	get := func(path string) string {
+		t.Helper()
+
+		resp, err := c.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("unexpected status code: %d", resp.StatusCode)
+		}
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("unexpected error reading body: %s", err)
+		}
+
+		return string(b)
+	}
+
+	t.Run("revalidated with etag", func(t *testing.T) {
+		for range 3 {
+			if got := get("/etag"); got != "body" {
+				t.Errorf("unexpected body: %s", got)
+			}
+		}
+		if notModified != 2 {
+			t.Errorf("unexpected number of revalidated requests: %d", notModified)
+		}
+	})
+
+	t.Run("not stored without etag", func(t *testing.T) {
+		requests, notModified = 0, 0
+		for range 2 {
+			if got := get("/no-etag"); got != "no etag" {
+				t.Errorf("unexpected body: %s", got)
+			}
+		}
+		if requests != 2 || notModified != 0 {
+			t.Errorf("unexpected requests: %d, %d revalidated", requests, notModified)
+		}
+	})
+}
@@ -0,0 +1,95 @@
+// Package diff compares which manifests the tags in two sets of repositories
+// point to.
+package diff
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/jetstack/seaglass/pkg/seaglass"
+)
+
+// Change is how a tag differs between two sets of repositories
+type Change string
+
+const (
+	// ChangeAdded is a tag that only exists in the second set
+	ChangeAdded Change = "added"
+
+	// ChangeRemoved is a tag that only exists in the first set
+	ChangeRemoved Change = "removed"
+
+	// ChangeMoved is a tag that points to a different manifest in each
+	// set
+	ChangeMoved Change = "moved"
+)
+
+// TagChange is a tag that differs between two sets of repositories
+type TagChange struct {
+	// Repository is the path of the repository, relative to the root of
+	// each set. It's empty for the root itself.
+	Repository string `json:"repository"`
+
+	// Tag is the name of the tag
+	Tag string `json:"tag"`
+
+	// Change is how the tag differs
+	Change Change `json:"change"`
+
+	// From is the digest that the tag points to in the first set, unless
+	// it was added
+	From string `json:"from,omitempty"`
+
+	// To is the digest that the tag points to in the second set, unless it
+	// was removed
+	To string `json:"to,omitempty"`
+}
+
+// Tags maps the repositories in a set, by their path relative to the root of
+// the set, to the digest that each of their tags points to
+type Tags map[string]map[string]string
+
+// Add adds the tags of the manifest in the repository, which is relative to
+// the root of the set
+func (t Tags) Add(repo string, manifest seaglass.Manifest) {
+	repo = strings.Trim(repo, "/")
+	if t[repo] == nil {
+		t[repo] = map[string]string{}
+	}
+	for _, tag := range manifest.Tags {
+		t[repo][tag] = manifest.Digest
+	}
+}
+
+// Compare returns the tags that differ between a and b, sorted by repository
+// and tag
+func Compare(a, b Tags) []TagChange {
+	var changes []TagChange
+	for repo, tags := range a {
+		for tag, from := range tags {
+			to, ok := b[repo][tag]
+			switch {
+			case !ok:
+				changes = append(changes, TagChange{Repository: repo, Tag: tag, Change: ChangeRemoved, From: from})
+			case from != to:
+				changes = append(changes, TagChange{Repository: repo, Tag: tag, Change: ChangeMoved, From: from, To: to})
+			}
+		}
+	}
+	for repo, tags := range b {
+		for tag, to := range tags {
+			if _, ok := a[repo][tag]; !ok {
+				changes = append(changes, TagChange{Repository: repo, Tag: tag, Change: ChangeAdded, To: to})
+			}
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Repository != changes[j].Repository {
+			return changes[i].Repository < changes[j].Repository
+		}
+		return changes[i].Tag < changes[j].Tag
+	})
+
+	return changes
+}
@@ -0,0 +1,131 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+)
+
+func TestCompare(t *testing.T) {
+	a := Tags{}
+	a.Add("", seaglass.Manifest{Digest: "sha256:aaaaaaa", Tags: []string{"latest", "v1.0.0"}})
+	a.Add("", seaglass.Manifest{Digest: "sha256:bbbbbbb", Tags: []string{"v0.9.0"}})
+	a.Add("api", seaglass.Manifest{Digest: "sha256:ccccccc", Tags: []string{"latest"}})
+
+	b := Tags{}
+	b.Add("", seaglass.Manifest{Digest: "sha256:aaaaaaa", Tags: []string{"v1.0.0"}})
+	b.Add("", seaglass.Manifest{Digest: "sha256:ddddddd", Tags: []string{"latest", "v1.1.0"}})
+	b.Add("/web/", seaglass.Manifest{Digest: "sha256:eeeeeee", Tags: []string{"latest"}})
+
+	want := []TagChange{
+		{Repository: "", Tag: "latest", Change: ChangeMoved, From: "sha256:aaaaaaa", To: "sha256:ddddddd"},
+		{Repository: "", Tag: "v0.9.0", Change: ChangeRemoved, From: "sha256:bbbbbbb"},
+		{Repository: "", Tag: "v1.1.0", Change: ChangeAdded, To: "sha256:ddddddd"},
+		{Repository: "api", Tag: "latest", Change: ChangeRemoved, From: "sha256:ccccccc"},
+		{Repository: "web", Tag: "latest", Change: ChangeAdded, To: "sha256:eeeeeee"},
+	}
+	if diff := cmp.Diff(want, Compare(a, b)); diff != "" {
+		t.Errorf("unexpected changes:\n%s", diff)
+	}
+
+	if changes := Compare(a, a); len(changes) != 0 {
+		t.Errorf("unexpected changes comparing with itself: %v", changes)
+	}
+}
+
+func TestReadSnapshot(t *testing.T) {
+	want := map[string][]seaglass.Manifest{
+		"ghcr.io/org/app": {
+			{Digest: "sha256:aaaaaaa", Tags: []string{"latest", "v1.0.0"}},
+			{Digest: "sha256:bbbbbbb", Tags: []string{"v0.9.0"}},
+		},
+		"ghcr.io/org/app/api": {
+			{Digest: "sha256:ccccccc", Tags: []string{"latest"}},
+		},
+	}
+
+	testCases := map[string]struct {
+		snapshot string
+		want     map[string][]seaglass.Manifest
+		wantErr  bool
+	}{
+		"manifests json": {
+			snapshot: `[
+  {"repository": "ghcr.io/org/app", "digest": "sha256:aaaaaaa", "tags": ["latest", "v1.0.0"]},
+  {"repository": "ghcr.io/org/app", "digest": "sha256:bbbbbbb", "tags": ["v0.9.0"]},
+  {"repository": "ghcr.io/org/app/api", "digest": "sha256:ccccccc", "tags": ["latest"]}
+]`,
+			want: want,
+		},
+		"tags ndjson": {
+			snapshot: `{"repository": "ghcr.io/org/app", "tag": "latest", "digest": "sha256:aaaaaaa"}
+{"repository": "ghcr.io/org/app", "tag": "v1.0.0", "digest": "sha256:aaaaaaa"}
+{"repository": "ghcr.io/org/app", "tag": "v0.9.0", "digest": "sha256:bbbbbbb"}
+{"repository": "ghcr.io/org/app/api", "tag": "latest", "digest": "sha256:ccccccc"}
+`,
+			want: want,
+		},
+		"empty": {
+			snapshot: "[]",
+			want:     map[string][]seaglass.Manifest{},
+		},
+		"missing digest": {
+			snapshot: `[{"repository": "ghcr.io/org/app", "tag": "latest"}]`,
+			wantErr:  true,
+		},
+		"invalid json": {
+			snapshot: `{"repository": `,
+			wantErr:  true,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := ReadSnapshot(strings.NewReader(tc.snapshot))
+			if err != nil {
+				if !tc.wantErr {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			if tc.wantErr {
+				t.Fatalf("expected error")
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("unexpected snapshot:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRoot(t *testing.T) {
+	testCases := map[string]struct {
+		repos []string
+		want  string
+	}{
+		"single repository": {
+			repos: []string{"ghcr.io/org/app"},
+			want:  "ghcr.io/org/app",
+		},
+		"nested repositories": {
+			repos: []string{"ghcr.io/org/app", "ghcr.io/org/app/api"},
+			want:  "ghcr.io/org/app",
+		},
+		"siblings": {
+			repos: []string{"ghcr.io/org/api", "ghcr.io/org/app"},
+			want:  "ghcr.io/org",
+		},
+		"no repositories": {
+			want: "",
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if got := Root(tc.repos); got != tc.want {
+				t.Errorf("unexpected root: %q", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jetstack/seaglass/pkg/seaglass"
+)
+
+// snapshotEntry is an item in the JSON output of the manifests or tags
+// commands
+type snapshotEntry struct {
+	// Repository is the full reference to the repository, including the
+	// registry host
+	Repository string `json:"repository"`
+
+	// Tag is set by the tags command, which has an item for each tag
+	// rather than each manifest
+	Tag string `json:"tag"`
+
+	seaglass.Manifest
+}
+
+// ReadSnapshot reads a snapshot of repositories, saved with the json or ndjson
+// output of the manifests or tags commands. Returns the manifests in each
+// repository, by the full reference to the repository.
+func ReadSnapshot(r io.Reader) (map[string][]seaglass.Manifest, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading snapshot: %w", err)
+	}
+
+	var entries []snapshotEntry
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("[")) {
+		if err := json.Unmarshal(b, &entries); err != nil {
+			return nil, fmt.Errorf("decoding snapshot: %w", err)
+		}
+	} else {
+		dec := json.NewDecoder(bytes.NewReader(b))
+		for {
+			var entry snapshotEntry
+			if err := dec.Decode(&entry); err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return nil, fmt.Errorf("decoding snapshot: %w", err)
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	manifests := map[string][]seaglass.Manifest{}
+	index := map[string]map[string]int{}
+	for _, entry := range entries {
+		if entry.Repository == "" || entry.Digest == "" {
+			return nil, fmt.Errorf("decoding snapshot: every item must have a repository and a digest")
+		}
+		if entry.Tag != "" {
+			entry.Tags = append(entry.Tags, entry.Tag)
+		}
+
+		if index[entry.Repository] == nil {
+			index[entry.Repository] = map[string]int{}
+		}
+		i, ok := index[entry.Repository][entry.Digest]
+		if ok {
+			manifests[entry.Repository][i].Merge(entry.Manifest)
+			continue
+		}
+		index[entry.Repository][entry.Digest] = len(manifests[entry.Repository])
+		manifests[entry.Repository] = append(manifests[entry.Repository], entry.Manifest)
+	}
+
+	return manifests, nil
+}
+
+// Root returns the longest path that all of the repositories are under, which
+// is the repository that a snapshot was taken from if it was taken
+// recursively
+func Root(repos []string) string {
+	if len(repos) == 0 {
+		return ""
+	}
+
+	root := strings.Split(repos[0], "/")
+	for _, repo := range repos[1:] {
+		parts := strings.Split(repo, "/")
+		n := 0
+		for n < len(root) && n < len(parts) && root[n] == parts[n] {
+			n++
+		}
+		root = root[:n]
+	}
+
+	return strings.Join(root, "/")
+}
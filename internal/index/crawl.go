@@ -0,0 +1,333 @@
+package index
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jetstack/seaglass/internal/traverse"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+)
+
+// CrawlOptions are options for crawling a registry
+type CrawlOptions struct {
+	// Concurrency is the maximum number of repositories to list at once.
+	Concurrency int
+
+	// RepositoryListOptions filter the repositories under the root. When
+	// they select repositories by name, repositories that aren't found
+	// aren't removed from the index, since they may have been filtered
+	// out.
+	RepositoryListOptions *seaglass.RepositoryListOptions
+
+	// ManifestListOptions are passed to the client when listing
+	// manifests.
+	ManifestListOptions *seaglass.ManifestListOptions
+}
+
+// CrawlResult describes the changes that a crawl made to the index
+type CrawlResult struct {
+	// Repositories is the number of repositories that were crawled.
+	Repositories int `json:"repositories"`
+
+	// RemovedRepositories is the number of repositories that were removed
+	// because they weren't found.
+	RemovedRepositories int `json:"removedRepositories"`
+
+	// Manifests is the number of manifests that were found.
+	Manifests int `json:"manifests"`
+
+	// AddedTags, MovedTags and RemovedTags are the number of tags that
+	// were added, moved to another manifest or removed.
+	AddedTags   int `json:"addedTags"`
+	MovedTags   int `json:"movedTags"`
+	RemovedTags int `json:"removedTags"`
+
+	// Errors are the errors listing individual repositories. The
+	// repositories keep what was indexed for them by the last crawl.
+	Errors []error `json:"-"`
+}
+
+// Crawl lists the root repository of the registry host, and every repository
+// under it, into the index.
+//
+// Crawls are incremental. Each repository's manifests and tags are compared
+// with what's already indexed and only the differences are written, so the
+// time that each manifest and tag was first seen is kept. Manifests, tags and
+// repositories that aren't found any more are removed. A repository that
+// can't be listed keeps what was indexed for it by the last crawl.
+func (i *Index) Crawl(ctx context.Context, c seaglass.Client, host, root string, opts *CrawlOptions) (*CrawlResult, error) {
+	if opts == nil {
+		opts = &CrawlOptions{}
+	}
+	started := time.Now()
+	result := &CrawlResult{}
+
+	res, err := i.db.ExecContext(ctx, `INSERT INTO crawls (host, root, started_at) VALUES (?, ?, ?)`, host, root, formatTime(&started))
+	if err != nil {
+		return nil, fmt.Errorf("recording crawl: %w", err)
+	}
+	crawlID, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("recording crawl: %w", err)
+	}
+
+	// The pages for each repository are contiguous, so each repository
+	// is written once the pages for the next one start. A repository
+	// that fails part way through isn't written at all, since the pages
+	// that weren't listed would be removed from the index.
+	var (
+		current   string
+		manifests []seaglass.Manifest
+		seen      map[string]int
+		failed    = map[string]struct{}{}
+	)
+	flush := func() error {
+		if current == "" {
+			return nil
+		}
+		if _, ok := failed[current]; ok {
+			return nil
+		}
+		if err := i.writeRepository(ctx, crawlID, host, current, manifests, result); err != nil {
+			return fmt.Errorf("indexing %s: %w", current, err)
+		}
+		result.Repositories++
+		result.Manifests += len(manifests)
+
+		return nil
+	}
+
+	pages := traverse.Manifests(ctx, c, root, &traverse.Options{
+		Recursive:             true,
+		Concurrency:           opts.Concurrency,
+		ContinueOnError:       true,
+		RepositoryListOptions: opts.RepositoryListOptions,
+		ManifestListOptions:   opts.ManifestListOptions,
+	})
+	for page, err := range pages {
+		if err != nil {
+			var repoErr *traverse.RepositoryError
+			if errors.As(err, &repoErr) {
+				failed[repoErr.Repository] = struct{}{}
+			}
+			result.Errors = append(result.Errors, err)
+			continue
+		}
+		if page.Repository != current {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			current = page.Repository
+			manifests = nil
+			seen = map[string]int{}
+		}
+
+		for _, m := range page.Manifests {
+			if n, ok := seen[m.Digest]; ok {
+				manifests[n].Merge(m)
+				continue
+			}
+			seen[m.Digest] = len(manifests)
+			manifests = append(manifests, m)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	// Repositories that weren't found can only be removed if every
+	// repository was listed
+	filtered := false
+	if opts.RepositoryListOptions != nil {
+		f, err := seaglass.NewRepositoryFilter(opts.RepositoryListOptions)
+		if err != nil {
+			return nil, err
+		}
+		filtered = f.Filters() || len(opts.RepositoryListOptions.Exclude) > 0 || opts.RepositoryListOptions.MaxDepth > 0
+	}
+	if len(result.Errors) == 0 && !filtered {
+		n, err := i.removeRepositories(ctx, crawlID, host, root)
+		if err != nil {
+			return nil, err
+		}
+		result.RemovedRepositories = n
+	}
+
+	_, err = i.db.ExecContext(ctx,
+		`UPDATE crawls SET finished_at = ?, repositories = ?, errors = ? WHERE id = ?`,
+		formatTime(ptr(time.Now())), result.Repositories, len(result.Errors), crawlID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("recording crawl: %w", err)
+	}
+
+	return result, nil
+}
+
+// writeRepository writes the differences between the manifests listed for the
+// repository and what's indexed for it
+func (i *Index) writeRepository(ctx context.Context, crawlID int64, host, repo string, manifests []seaglass.Manifest, result *CrawlResult) error {
+	now := time.Now()
+
+	tx, err := i.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id int64
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO repositories (host, name, crawl_id, crawled_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (host, name) DO UPDATE SET crawl_id = excluded.crawl_id, crawled_at = excluded.crawled_at
+		RETURNING id`,
+		host, repo, crawlID, formatTime(&now),
+	).Scan(&id)
+	if err != nil {
+		return fmt.Errorf("writing repository: %w", err)
+	}
+
+	indexed := map[string]string{}
+	rows, err := tx.QueryContext(ctx, `SELECT name, digest FROM tags WHERE repository_id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("reading tags: %w", err)
+	}
+	for rows.Next() {
+		var tag, digest string
+		if err := rows.Scan(&tag, &digest); err != nil {
+			rows.Close()
+			return fmt.Errorf("reading tags: %w", err)
+		}
+		indexed[tag] = digest
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("reading tags: %w", err)
+	}
+
+	for _, m := range manifests {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO manifests (repository_id, digest, media_type, size, platforms, created_at, uploaded_at, updated_at, pulled_at, first_seen, last_seen)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (repository_id, digest) DO UPDATE SET
+				media_type = excluded.media_type,
+				size = excluded.size,
+				platforms = COALESCE(excluded.platforms, manifests.platforms),
+				created_at = excluded.created_at,
+				uploaded_at = excluded.uploaded_at,
+				updated_at = excluded.updated_at,
+				pulled_at = excluded.pulled_at,
+				last_seen = excluded.last_seen`,
+			id, m.Digest, nullString(m.MediaType), m.Size, nullString(platforms(m)),
+			formatTime(m.Created), formatTime(m.Uploaded), formatTime(m.Updated), formatTime(m.Pulled),
+			formatTime(&now), formatTime(&now),
+		)
+		if err != nil {
+			return fmt.Errorf("writing manifest %s: %w", m.Digest, err)
+		}
+
+		for _, tag := range m.Tags {
+			digest, ok := indexed[tag]
+			switch {
+			case !ok:
+				result.AddedTags++
+			case digest != m.Digest:
+				result.MovedTags++
+			}
+			delete(indexed, tag)
+
+			// A tag that moves is seen for the first time on the
+			// new manifest
+			_, err := tx.ExecContext(ctx,
+				`INSERT INTO tags (repository_id, name, digest, first_seen, last_seen)
+				VALUES (?, ?, ?, ?, ?)
+				ON CONFLICT (repository_id, name) DO UPDATE SET
+					first_seen = CASE WHEN tags.digest = excluded.digest THEN tags.first_seen ELSE excluded.first_seen END,
+					digest = excluded.digest,
+					last_seen = excluded.last_seen`,
+				id, tag, m.Digest, formatTime(&now), formatTime(&now),
+			)
+			if err != nil {
+				return fmt.Errorf("writing tag %s: %w", tag, err)
+			}
+		}
+	}
+	result.RemovedTags += len(indexed)
+
+	// Whatever wasn't listed has been removed from the registry
+	var digests []string
+	for _, m := range manifests {
+		digests = append(digests, m.Digest)
+	}
+	for tag := range indexed {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM tags WHERE repository_id = ? AND name = ?`, id, tag); err != nil {
+			return fmt.Errorf("removing tag %s: %w", tag, err)
+		}
+	}
+	b, err := json.Marshal(digests)
+	if err != nil {
+		return fmt.Errorf("encoding digests: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `DELETE FROM manifests WHERE repository_id = ? AND digest NOT IN (SELECT value FROM json_each(?))`, id, string(b))
+	if err != nil {
+		return fmt.Errorf("removing manifests: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+// removeRepositories removes the root and the repositories under it that
+// weren't found by the crawl
+func (i *Index) removeRepositories(ctx context.Context, crawlID int64, host, root string) (int, error) {
+	query := `DELETE FROM repositories WHERE host = ? AND crawl_id != ?`
+	args := []any{host, crawlID}
+	if root != "" {
+		query += ` AND (name = ? OR substr(name, 1, ?) = ?)`
+		args = append(args, root, len(root)+1, root+"/")
+	}
+
+	res, err := i.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("removing repositories: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("removing repositories: %w", err)
+	}
+
+	return int(n), nil
+}
+
+// platforms returns the platforms of the manifest, separated by commas
+func platforms(m seaglass.Manifest) string {
+	var platforms []string
+	if m.Platform != nil {
+		platforms = append(platforms, m.Platform.String())
+	}
+	for _, d := range m.Manifests {
+		if d.Platform != nil {
+			platforms = append(platforms, d.Platform.String())
+		}
+	}
+
+	return strings.Join(platforms, ",")
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
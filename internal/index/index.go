@@ -0,0 +1,203 @@
+// Package index stores an inventory of the repositories, manifests and tags
+// in registries in a local SQLite database, so that it can be queried without
+// listing everything from the registries again.
+package index
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// timeFormat is the format that times are stored in. It sorts in time order
+// and can be read by the SQLite date and time functions.
+const timeFormat = "2006-01-02T15:04:05Z"
+
+const schema = `
+CREATE TABLE IF NOT EXISTS repositories (
+	id INTEGER PRIMARY KEY,
+	host TEXT NOT NULL,
+	name TEXT NOT NULL,
+	crawl_id INTEGER NOT NULL,
+	crawled_at TEXT NOT NULL,
+	UNIQUE (host, name)
+);
+
+CREATE TABLE IF NOT EXISTS manifests (
+	repository_id INTEGER NOT NULL REFERENCES repositories (id) ON DELETE CASCADE,
+	digest TEXT NOT NULL,
+	media_type TEXT,
+	size INTEGER,
+	platforms TEXT,
+	created_at TEXT,
+	uploaded_at TEXT,
+	updated_at TEXT,
+	pulled_at TEXT,
+	first_seen TEXT NOT NULL,
+	last_seen TEXT NOT NULL,
+	PRIMARY KEY (repository_id, digest)
+);
+
+CREATE TABLE IF NOT EXISTS tags (
+	repository_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	digest TEXT NOT NULL,
+	first_seen TEXT NOT NULL,
+	last_seen TEXT NOT NULL,
+	PRIMARY KEY (repository_id, name),
+	FOREIGN KEY (repository_id, digest) REFERENCES manifests (repository_id, digest) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS crawls (
+	id INTEGER PRIMARY KEY,
+	host TEXT NOT NULL,
+	root TEXT NOT NULL,
+	started_at TEXT NOT NULL,
+	finished_at TEXT,
+	repositories INTEGER NOT NULL DEFAULT 0,
+	errors INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE VIEW IF NOT EXISTS images AS
+SELECT
+	r.host,
+	r.name AS repository,
+	m.digest,
+	t.name AS tag,
+	m.media_type,
+	m.size,
+	m.platforms,
+	m.created_at,
+	m.uploaded_at,
+	m.updated_at,
+	m.pulled_at
+FROM manifests m
+JOIN repositories r ON r.id = m.repository_id
+LEFT JOIN tags t ON t.repository_id = m.repository_id AND t.digest = m.digest;
+`
+
+// Index is an inventory of registries in a SQLite database
+type Index struct {
+	db *sql.DB
+}
+
+// Open opens the index in the file, creating it if it doesn't exist
+func Open(path string) (*Index, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("creating directory: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", dsn(path, false))
+	if err != nil {
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating schema: %w", err)
+	}
+
+	return &Index{db: db}, nil
+}
+
+// OpenReadOnly opens an existing index that can only be queried
+func OpenReadOnly(path string) (*Index, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", dsn(path, true))
+	if err != nil {
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+
+	return &Index{db: db}, nil
+}
+
+// DefaultPath returns the default path of the index, under the user's cache
+// directory
+func DefaultPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("finding user cache directory: %w", err)
+	}
+
+	return filepath.Join(dir, "seaglass", "index.db"), nil
+}
+
+// Close closes the database
+func (i *Index) Close() error {
+	return i.db.Close()
+}
+
+// Query runs an SQL query against the index and returns the names of the
+// columns and the rows. Values are returned as the types that SQLite stores
+// them as.
+func (i *Index) Query(ctx context.Context, query string, args ...any) ([]string, [][]any, error) {
+	rows, err := i.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("running query: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading columns: %w", err)
+	}
+
+	var results [][]any
+	for rows.Next() {
+		values := make([]any, len(columns))
+		ptrs := make([]any, len(columns))
+		for n := range values {
+			ptrs[n] = &values[n]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, nil, fmt.Errorf("reading row: %w", err)
+		}
+		results = append(results, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("reading rows: %w", err)
+	}
+
+	return columns, results, nil
+}
+
+func dsn(path string, readOnly bool) string {
+	q := url.Values{}
+	q.Add("_pragma", "foreign_keys(1)")
+	q.Add("_pragma", "busy_timeout(5000)")
+	if readOnly {
+		q.Set("mode", "ro")
+	} else {
+		q.Add("_pragma", "journal_mode(WAL)")
+	}
+
+	return "file:" + path + "?" + q.Encode()
+}
+
+func formatTime(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+
+	return t.UTC().Format(timeFormat)
+}
+
+func parseTime(s sql.NullString) (*time.Time, error) {
+	if !s.Valid {
+		return nil, nil
+	}
+	t, err := time.Parse(timeFormat, s.String)
+	if err != nil {
+		return nil, fmt.Errorf("parsing time: %w", err)
+	}
+
+	return &t, nil
+}
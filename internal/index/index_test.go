@@ -0,0 +1,274 @@
+package index
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+)
+
+func TestIndexCrawl(t *testing.T) {
+	ctx := context.Background()
+
+	idx, err := Open(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatalf("unexpected error opening index: %s", err)
+	}
+	t.Cleanup(func() { idx.Close() })
+
+	created := time.Date(2023, 1, 31, 12, 0, 0, 0, time.UTC)
+	uploaded := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+
+	c := &fakeClient{
+		repositories: map[string][]seaglass.Manifest{
+			"foo/bar": {
+				{
+					Digest:    "sha256:aaaaaaa",
+					MediaType: "application/vnd.oci.image.index.v1+json",
+					Tags:      []string{"latest", "v1.0.0"},
+					Created:   &created,
+					Uploaded:  &uploaded,
+					Manifests: []seaglass.Descriptor{
+						{Platform: &seaglass.Platform{OS: "linux", Architecture: "amd64"}},
+						{Platform: &seaglass.Platform{OS: "linux", Architecture: "arm64"}},
+					},
+				},
+				{
+					Digest: "sha256:bbbbbbb",
+					Tags:   []string{"v0.1.0"},
+				},
+			},
+			"foo/baz": {
+				{
+					Digest:    "sha256:ccccccc",
+					MediaType: "application/vnd.oci.image.manifest.v1+json",
+					Tags:      []string{"latest"},
+					Uploaded:  &uploaded,
+					Platform:  &seaglass.Platform{OS: "linux", Architecture: "amd64"},
+				},
+			},
+		},
+	}
+
+	t.Run("first crawl", func(t *testing.T) {
+		got, err := idx.Crawl(ctx, c, "example.com", "foo", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		want := &CrawlResult{
+			Repositories: 2,
+			Manifests:    3,
+			AddedTags:    4,
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("query manifests", func(t *testing.T) {
+		testCases := map[string]struct {
+			conds []Condition
+			want  []string
+		}{
+			"all manifests": {
+				want: []string{"foo/bar@sha256:aaaaaaa", "foo/bar@sha256:bbbbbbb", "foo/baz@sha256:ccccccc"},
+			},
+			"by tag": {
+				conds: []Condition{{Field: FieldTag, Operator: OpMatch, Value: "v*"}},
+				want:  []string{"foo/bar@sha256:aaaaaaa", "foo/bar@sha256:bbbbbbb"},
+			},
+			"by repository and not tag": {
+				conds: []Condition{
+					{Field: FieldRepository, Operator: OpMatch, Value: "foo/*"},
+					{Field: FieldTag, Operator: OpNotMatch, Value: "latest"},
+				},
+				want: []string{"foo/bar@sha256:bbbbbbb"},
+			},
+			"by time": {
+				conds: []Condition{{Field: FieldCreated, Operator: OpBefore, Value: uploaded}},
+				want:  []string{"foo/bar@sha256:aaaaaaa"},
+			},
+			"by platform": {
+				conds: []Condition{{Field: FieldPlatform, Operator: OpMatch, Value: "*/arm64"}},
+				want:  []string{"foo/bar@sha256:aaaaaaa"},
+			},
+		}
+		for name, tc := range testCases {
+			t.Run(name, func(t *testing.T) {
+				manifests, err := idx.Manifests(ctx, tc.conds)
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+
+				var got []string
+				for _, m := range manifests {
+					got = append(got, m.Repository+"@"+m.Digest)
+				}
+				if diff := cmp.Diff(tc.want, got); diff != "" {
+					t.Errorf("unexpected result:\n%s", diff)
+				}
+			})
+		}
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		manifests, err := idx.Manifests(ctx, []Condition{{Field: FieldDigest, Operator: OpMatch, Value: "sha256:aaaaaaa"}})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		want := []Manifest{
+			{
+				Host:       "example.com",
+				Repository: "foo/bar",
+				Manifest:   c.repositories["foo/bar"][0],
+			},
+		}
+		if diff := cmp.Diff(want, manifests); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("incremental crawl", func(t *testing.T) {
+		// v1.0.0 moves to a new manifest, the old manifest loses its
+		// tags and foo/baz is deleted
+		c.repositories["foo/bar"] = []seaglass.Manifest{
+			{
+				Digest: "sha256:aaaaaaa",
+				Tags:   []string{"latest"},
+			},
+			{
+				Digest: "sha256:ddddddd",
+				Tags:   []string{"v1.0.0", "v1.0.1"},
+			},
+		}
+		delete(c.repositories, "foo/baz")
+
+		got, err := idx.Crawl(ctx, c, "example.com", "foo", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		want := &CrawlResult{
+			Repositories:        1,
+			RemovedRepositories: 1,
+			Manifests:           2,
+			AddedTags:           1,
+			MovedTags:           1,
+			RemovedTags:         1,
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+
+		columns, rows, err := idx.Query(ctx, `SELECT repository, tag, digest FROM images ORDER BY repository, tag`)
+		if err != nil {
+			t.Fatalf("unexpected error querying index: %s", err)
+		}
+		if diff := cmp.Diff([]string{"repository", "tag", "digest"}, columns); diff != "" {
+			t.Errorf("unexpected columns:\n%s", diff)
+		}
+		wantRows := [][]any{
+			{"foo/bar", "latest", "sha256:aaaaaaa"},
+			{"foo/bar", "v1.0.0", "sha256:ddddddd"},
+			{"foo/bar", "v1.0.1", "sha256:ddddddd"},
+		}
+		if diff := cmp.Diff(wantRows, rows); diff != "" {
+			t.Errorf("unexpected rows:\n%s", diff)
+		}
+	})
+
+	t.Run("crawl that fails part way through a repository", func(t *testing.T) {
+		// Only the first page of foo/bar is listed before the error,
+		// which mustn't remove what's indexed from the other pages
+		c.repositories["foo/bar"] = []seaglass.Manifest{
+			{
+				Digest: "sha256:aaaaaaa",
+				Tags:   []string{"latest"},
+			},
+			{
+				Digest: "sha256:eeeeeee",
+				Tags:   []string{"v2.0.0"},
+			},
+		}
+		c.errors = map[string]error{"foo/bar": errors.New("page 2 failed")}
+		t.Cleanup(func() { c.errors = nil })
+
+		got, err := idx.Crawl(ctx, c, "example.com", "foo", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if len(got.Errors) != 1 {
+			t.Errorf("unexpected errors: %v", got.Errors)
+		}
+		got.Errors = nil
+
+		if diff := cmp.Diff(&CrawlResult{}, got); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+
+		_, rows, err := idx.Query(ctx, `SELECT repository, tag, digest FROM images ORDER BY repository, tag`)
+		if err != nil {
+			t.Fatalf("unexpected error querying index: %s", err)
+		}
+		wantRows := [][]any{
+			{"foo/bar", "latest", "sha256:aaaaaaa"},
+			{"foo/bar", "v1.0.0", "sha256:ddddddd"},
+			{"foo/bar", "v1.0.1", "sha256:ddddddd"},
+		}
+		if diff := cmp.Diff(wantRows, rows); diff != "" {
+			t.Errorf("unexpected rows:\n%s", diff)
+		}
+	})
+}
+
+// fakeClient lists the manifests in a map of repositories, which are all
+// under a single root. Each manifest is listed in its own page.
+type fakeClient struct {
+	seaglass.Client
+
+	repositories map[string][]seaglass.Manifest
+
+	// errors are returned for the repositories after their first page
+	errors map[string]error
+}
+
+func (c *fakeClient) ListRepositoryPages(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) iter.Seq2[*seaglass.RepositoryList, error] {
+	return func(yield func(*seaglass.RepositoryList, error) bool) {
+		var repos []string
+		for r := range c.repositories {
+			if rel, ok := strings.CutPrefix(r, repo+"/"); ok {
+				repos = append(repos, rel)
+			}
+		}
+		slices.Sort(repos)
+		yield(&seaglass.RepositoryList{Name: repo, Repositories: repos}, nil)
+	}
+}
+
+func (c *fakeClient) ListManifestPages(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) iter.Seq2[*seaglass.ManifestList, error] {
+	return func(yield func(*seaglass.ManifestList, error) bool) {
+		manifests, ok := c.repositories[repo]
+		if !ok {
+			yield(nil, seaglass.ErrNotFound)
+			return
+		}
+		for i, m := range manifests {
+			if err, ok := c.errors[repo]; ok && i > 0 {
+				yield(nil, err)
+				return
+			}
+			if !yield(&seaglass.ManifestList{Manifests: []seaglass.Manifest{m}}, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package index
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jetstack/seaglass/pkg/seaglass"
+)
+
+// Field is a field of a manifest that conditions can match
+type Field string
+
+const (
+	FieldHost       Field = "host"
+	FieldRepository Field = "repository"
+	FieldDigest     Field = "digest"
+	FieldTag        Field = "tag"
+	FieldMediaType  Field = "mediaType"
+	FieldPlatform   Field = "platform"
+	FieldCreated    Field = "created"
+	FieldUploaded   Field = "uploaded"
+	FieldUpdated    Field = "updated"
+	FieldPulled     Field = "pulled"
+)
+
+// columns are the SQL expressions for each field
+var columns = map[Field]string{
+	FieldHost:       "r.host",
+	FieldRepository: "r.name",
+	FieldDigest:     "m.digest",
+	FieldMediaType:  "m.media_type",
+	FieldCreated:    "m.created_at",
+	FieldUploaded:   "m.uploaded_at",
+	FieldUpdated:    "m.updated_at",
+	FieldPulled:     "m.pulled_at",
+}
+
+// IsTime returns true if the field is a time
+func (f Field) IsTime() bool {
+	switch f {
+	case FieldCreated, FieldUploaded, FieldUpdated, FieldPulled:
+		return true
+	}
+
+	return false
+}
+
+// Fields are the fields that conditions can match
+var Fields = []Field{
+	FieldHost,
+	FieldRepository,
+	FieldDigest,
+	FieldTag,
+	FieldMediaType,
+	FieldPlatform,
+	FieldCreated,
+	FieldUploaded,
+	FieldUpdated,
+	FieldPulled,
+}
+
+// Operator compares a field with a value
+type Operator string
+
+const (
+	// OpMatch matches a string against a glob pattern, or a time
+	// exactly
+	OpMatch Operator = "="
+
+	// OpNotMatch is the opposite of OpMatch
+	OpNotMatch Operator = "!="
+
+	OpBefore     Operator = "<"
+	OpBeforeOrAt Operator = "<="
+	OpAfter      Operator = ">"
+	OpAfterOrAt  Operator = ">="
+)
+
+// Operators are the operators that conditions can use, longest first so that
+// they can be parsed in order
+var Operators = []Operator{OpNotMatch, OpBeforeOrAt, OpAfterOrAt, OpMatch, OpBefore, OpAfter}
+
+// Condition is a condition that manifests must match. String fields are
+// matched against glob patterns, as in the SQLite GLOB operator, and times are
+// compared.
+type Condition struct {
+	Field    Field
+	Operator Operator
+
+	// Value is a string for string fields and a time.Time for times
+	Value any
+}
+
+// Manifest is a manifest in the index
+type Manifest struct {
+	Host       string
+	Repository string
+
+	seaglass.Manifest
+}
+
+// Manifests returns the manifests in the index that match every condition,
+// with all of their tags
+func (i *Index) Manifests(ctx context.Context, conds []Condition) ([]Manifest, error) {
+	var (
+		where []string
+		args  []any
+	)
+	for _, cond := range conds {
+		w, a, err := cond.sql()
+		if err != nil {
+			return nil, err
+		}
+		where = append(where, w)
+		args = append(args, a...)
+	}
+
+	query := `SELECT r.host, r.name, m.digest, m.media_type, m.size, m.platforms, m.created_at, m.uploaded_at, m.updated_at, m.pulled_at,
+		(SELECT json_group_array(t.name) FROM (SELECT name FROM tags t WHERE t.repository_id = m.repository_id AND t.digest = m.digest ORDER BY name) t)
+	FROM manifests m
+	JOIN repositories r ON r.id = m.repository_id`
+	if len(where) > 0 {
+		query += "\nWHERE " + strings.Join(where, " AND ")
+	}
+	query += "\nORDER BY r.host, r.name, m.digest"
+
+	rows, err := i.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying manifests: %w", err)
+	}
+	defer rows.Close()
+
+	var manifests []Manifest
+	for rows.Next() {
+		var (
+			m                                  Manifest
+			mediaType, platforms, tags         sql.NullString
+			created, uploaded, updated, pulled sql.NullString
+			size                               sql.NullInt64
+		)
+		if err := rows.Scan(&m.Host, &m.Repository, &m.Digest, &mediaType, &size, &platforms, &created, &uploaded, &updated, &pulled, &tags); err != nil {
+			return nil, fmt.Errorf("reading manifest: %w", err)
+		}
+		m.MediaType = mediaType.String
+		m.Size = size.Int64
+		if err := scanTags(tags, &m.Tags); err != nil {
+			return nil, err
+		}
+		for _, t := range []struct {
+			s sql.NullString
+			t **time.Time
+		}{{created, &m.Created}, {uploaded, &m.Uploaded}, {updated, &m.Updated}, {pulled, &m.Pulled}} {
+			if *t.t, err = parseTime(t.s); err != nil {
+				return nil, err
+			}
+		}
+		if err := setPlatforms(&m.Manifest, platforms.String); err != nil {
+			return nil, err
+		}
+
+		manifests = append(manifests, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading manifests: %w", err)
+	}
+
+	return manifests, nil
+}
+
+// sql returns the SQL expression for the condition and its arguments
+func (c Condition) sql() (string, []any, error) {
+	if c.Field.IsTime() {
+		t, ok := c.Value.(time.Time)
+		if !ok {
+			return "", nil, fmt.Errorf("%s must be compared with a time", c.Field)
+		}
+		op := string(c.Operator)
+		if c.Operator == OpNotMatch {
+			op = "<>"
+		}
+
+		return fmt.Sprintf("%s %s ?", columns[c.Field], op), []any{formatTime(&t)}, nil
+	}
+
+	s, ok := c.Value.(string)
+	if !ok {
+		return "", nil, fmt.Errorf("%s must be compared with a string", c.Field)
+	}
+	var expr string
+	switch c.Field {
+	case FieldTag:
+		expr = "EXISTS (SELECT 1 FROM tags t WHERE t.repository_id = m.repository_id AND t.digest = m.digest AND t.name GLOB ?)"
+	case FieldPlatform:
+		// Platforms are stored separated by commas
+		expr = "EXISTS (SELECT 1 FROM json_each('[\"' || replace(COALESCE(m.platforms, ''), ',', '\",\"') || '\"]') p WHERE p.value GLOB ?)"
+	default:
+		column, ok := columns[c.Field]
+		if !ok {
+			return "", nil, fmt.Errorf("unknown field: %s", c.Field)
+		}
+		expr = fmt.Sprintf("COALESCE(%s, '') GLOB ?", column)
+	}
+
+	switch c.Operator {
+	case OpMatch:
+		return expr, []any{s}, nil
+	case OpNotMatch:
+		return "NOT " + expr, []any{s}, nil
+	default:
+		return "", nil, fmt.Errorf("%s can only be compared with %s or %s", c.Field, OpMatch, OpNotMatch)
+	}
+}
+
+func scanTags(s sql.NullString, tags *[]string) error {
+	if !s.Valid {
+		return nil
+	}
+	var t []string
+	if err := json.Unmarshal([]byte(s.String), &t); err != nil {
+		return fmt.Errorf("reading tags: %w", err)
+	}
+	if len(t) > 0 {
+		*tags = t
+	}
+
+	return nil
+}
+
+// setPlatforms sets the platform of an image, or the platforms of the
+// manifests in an index, from the platforms stored in the index
+func setPlatforms(m *seaglass.Manifest, s string) error {
+	if s == "" {
+		return nil
+	}
+
+	var platforms []seaglass.Platform
+	for _, p := range strings.Split(s, ",") {
+		platform, err := seaglass.ParsePlatform(p)
+		if err != nil {
+			return err
+		}
+		platforms = append(platforms, platform)
+	}
+
+	if len(platforms) == 1 && !isIndex(m.MediaType) {
+		m.Platform = &platforms[0]
+		return nil
+	}
+	for _, p := range platforms {
+		m.Manifests = append(m.Manifests, seaglass.Descriptor{Platform: &p})
+	}
+
+	return nil
+}
+
+func isIndex(mediaType string) bool {
+	return mediaType == "application/vnd.oci.image.index.v1+json" ||
+		mediaType == "application/vnd.docker.distribution.manifest.list.v2+json"
+}
@@ -0,0 +1,435 @@
+// Package inspect fetches the details of manifests with the OCI distribution
+// API, which every registry supports, so that clients don't have to implement
+// it themselves.
+package inspect
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+	"golang.org/x/sync/errgroup"
+)
+
+// defaultConcurrency is the number of manifests to resolve at once when the
+// concurrency isn't set in the options
+const defaultConcurrency = 10
+
+// Inspector fetches the details of manifests from a registry. Clients embed it
+// to implement seaglass.Client.GetManifest and seaglass.Client.ListReferrers.
+type Inspector struct {
+	registry name.Registry
+	puller   *remote.Puller
+}
+
+// New returns an inspector for the registry. Credentials are taken from the
+// default keychain, unless the options say otherwise.
+func New(registry name.Registry, opts ...remote.Option) (*Inspector, error) {
+	opts = append([]remote.Option{remote.WithAuthFromKeychain(authn.DefaultKeychain)}, opts...)
+
+	puller, err := remote.NewPuller(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("creating puller: %w", err)
+	}
+
+	return &Inspector{
+		registry: registry,
+		puller:   puller,
+	}, nil
+}
+
+// GetManifest fetches the manifest by tag or digest, along with the config of
+// an image or the platforms of an index
+func (i *Inspector) GetManifest(ctx context.Context, repo, ref string) (*seaglass.ManifestDetail, error) {
+	r, err := reference(i.registry.Repo(repo), ref)
+	if err != nil {
+		return nil, err
+	}
+
+	desc, err := i.puller.Get(ctx, r)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, seaglass.ErrNotFound
+		}
+		return nil, fmt.Errorf("fetching manifest: %w", err)
+	}
+
+	detail := &seaglass.ManifestDetail{
+		Digest:    desc.Digest.String(),
+		MediaType: string(desc.MediaType),
+		Size:      desc.Size,
+		TotalSize: desc.Size,
+	}
+
+	if desc.MediaType.IsIndex() {
+		index, err := ggcrv1.ParseIndexManifest(bytes.NewReader(desc.Manifest))
+		if err != nil {
+			return nil, fmt.Errorf("parsing index: %w", err)
+		}
+		detail.Annotations = index.Annotations
+		detail.Subject = descriptor(index.Subject)
+		for _, m := range index.Manifests {
+			detail.Manifests = append(detail.Manifests, *descriptor(&m))
+			detail.TotalSize += m.Size
+		}
+
+		return detail, nil
+	}
+
+	manifest, err := ggcrv1.ParseManifest(bytes.NewReader(desc.Manifest))
+	if err != nil {
+		return nil, fmt.Errorf("parsing manifest: %w", err)
+	}
+	detail.Annotations = manifest.Annotations
+	detail.Subject = descriptor(manifest.Subject)
+	detail.ArtifactType = artifactType(desc.Manifest)
+	detail.TotalSize += manifest.Config.Size
+	for _, l := range manifest.Layers {
+		detail.Layers = append(detail.Layers, *descriptor(&l))
+		detail.TotalSize += l.Size
+	}
+
+	// Artifacts may have a config that isn't an image config, which
+	// can't be parsed
+	if !isImageConfig(manifest.Config.MediaType) {
+		return detail, nil
+	}
+
+	config, err := i.config(ctx, r.Context(), manifest.Config)
+	if err != nil {
+		return nil, err
+	}
+	detail.Config = config
+
+	return detail, nil
+}
+
+// ResolveTag returns the manifest that the tag points to, with a HEAD request
+// for its descriptor
+func (i *Inspector) ResolveTag(ctx context.Context, repo, tag string) (*seaglass.Manifest, error) {
+	t, err := name.NewTag(fmt.Sprintf("%s:%s", i.registry.Repo(repo), tag))
+	if err != nil {
+		return nil, fmt.Errorf("parsing tag: %w", err)
+	}
+
+	desc, err := i.puller.Head(ctx, t)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, seaglass.ErrNotFound
+		}
+		return nil, fmt.Errorf("fetching descriptor: %w", err)
+	}
+
+	return &seaglass.Manifest{
+		Digest:    desc.Digest.String(),
+		MediaType: string(desc.MediaType),
+		Tags:      []string{tag},
+	}, nil
+}
+
+// Resolve fills in the details of each manifest in the pages that the options
+// ask for and that the client didn't already get from the listing: the
+// platform of each image, the manifests in each index and the referrers of
+// each manifest. Manifests that won't match the rest of the options aren't
+// resolved.
+func (i *Inspector) Resolve(ctx context.Context, repo string, pages iter.Seq2[*seaglass.ManifestList, error], opts *seaglass.ManifestListOptions) iter.Seq2[*seaglass.ManifestList, error] {
+	if opts == nil {
+		return pages
+	}
+	platforms := opts.ResolvePlatforms || len(opts.Platforms) > 0
+	if !platforms && !opts.Referrers {
+		return pages
+	}
+
+	return func(yield func(*seaglass.ManifestList, error) bool) {
+		filterOpts := *opts
+		filterOpts.Platforms = nil
+		f, err := seaglass.NewManifestFilter(&filterOpts)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		concurrency := defaultConcurrency
+		if opts.Concurrency > 0 {
+			concurrency = opts.Concurrency
+		}
+
+		for page, err := range pages {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			manifests := slices.Clone(page.Manifests)
+
+			g, gctx := errgroup.WithContext(ctx)
+			g.SetLimit(concurrency)
+			for n := range manifests {
+				m := &manifests[n]
+				if _, ok := f.Filter(*m); !ok {
+					continue
+				}
+				g.Go(func() error {
+					if platforms && m.Platform == nil && m.Manifests == nil {
+						if err := i.resolve(gctx, repo, m); err != nil {
+							return err
+						}
+					}
+					if opts.Referrers {
+						referrers, err := i.ListReferrers(gctx, repo, m.Digest, "")
+						if err != nil {
+							return fmt.Errorf("listing referrers of %s: %w", m.Digest, err)
+						}
+						if m.Referrers == nil {
+							m.Referrers = []seaglass.Referrer{}
+						}
+						m.AddReferrers(referrers...)
+					}
+
+					return nil
+				})
+			}
+			if err := g.Wait(); err != nil {
+				yield(nil, err)
+				return
+			}
+
+			if !yield(&seaglass.ManifestList{Manifests: manifests}, nil) {
+				return
+			}
+		}
+	}
+}
+
+// ListReferrers lists the referrers of the manifest with the referrers API.
+// Registries that don't support the API fall back to the tag that the OCI
+// distribution spec defines for it. Referrers stored in cosign's tags, like
+// sha256-<hex>.sig, are listed too.
+func (i *Inspector) ListReferrers(ctx context.Context, repo, digest, artifactType string) ([]seaglass.Referrer, error) {
+	d, err := name.NewDigest(fmt.Sprintf("%s@%s", i.registry.Repo(repo), digest))
+	if err != nil {
+		return nil, fmt.Errorf("parsing digest: %w", err)
+	}
+
+	opts := []remote.Option{
+		remote.Reuse(i.puller),
+		remote.WithContext(ctx),
+	}
+	if artifactType != "" {
+		opts = append(opts, remote.WithFilter("artifactType", artifactType))
+	}
+	index, err := remote.Referrers(d, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("fetching referrers: %w", err)
+	}
+	im, err := index.IndexManifest()
+	if err != nil {
+		return nil, fmt.Errorf("parsing referrers: %w", err)
+	}
+
+	referrers := []seaglass.Referrer{}
+	for _, m := range im.Manifests {
+		// Registries don't have to apply the filter
+		if artifactType != "" && m.ArtifactType != artifactType {
+			continue
+		}
+		referrers = append(referrers, seaglass.Referrer{Descriptor: *descriptor(&m)})
+	}
+
+	for _, rt := range seaglass.ReferrerTags {
+		if artifactType != "" && rt.ArtifactType != artifactType {
+			continue
+		}
+
+		tag := seaglass.ReferrerTag(digest, rt.Suffix)
+		desc, err := i.puller.Head(ctx, d.Context().Tag(tag))
+		if err != nil {
+			if isNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("fetching %s: %w", tag, err)
+		}
+		if slices.ContainsFunc(referrers, func(r seaglass.Referrer) bool {
+			return r.Digest == desc.Digest.String()
+		}) {
+			continue
+		}
+
+		referrers = append(referrers, seaglass.Referrer{
+			Descriptor: seaglass.Descriptor{
+				Digest:       desc.Digest.String(),
+				MediaType:    string(desc.MediaType),
+				ArtifactType: rt.ArtifactType,
+				Size:         desc.Size,
+			},
+			Tag: tag,
+		})
+	}
+
+	return referrers, nil
+}
+
+// resolve fetches the manifest to fill in the platform of an image or the
+// manifests in an index
+func (i *Inspector) resolve(ctx context.Context, repo string, m *seaglass.Manifest) error {
+	r := i.registry.Repo(repo).Digest(m.Digest)
+	desc, err := i.puller.Get(ctx, r)
+	if err != nil {
+		return fmt.Errorf("fetching manifest %s: %w", m.Digest, err)
+	}
+	if m.MediaType == "" {
+		m.MediaType = string(desc.MediaType)
+	}
+
+	if desc.MediaType.IsIndex() {
+		index, err := ggcrv1.ParseIndexManifest(bytes.NewReader(desc.Manifest))
+		if err != nil {
+			return fmt.Errorf("parsing index %s: %w", m.Digest, err)
+		}
+		m.Manifests = []seaglass.Descriptor{}
+		for _, d := range index.Manifests {
+			m.Manifests = append(m.Manifests, *descriptor(&d))
+		}
+
+		return nil
+	}
+
+	manifest, err := ggcrv1.ParseManifest(bytes.NewReader(desc.Manifest))
+	if err != nil {
+		return fmt.Errorf("parsing manifest %s: %w", m.Digest, err)
+	}
+	if !isImageConfig(manifest.Config.MediaType) {
+		return nil
+	}
+
+	config, err := i.config(ctx, r.Context(), manifest.Config)
+	if err != nil {
+		return err
+	}
+	m.Platform = config.Platform
+	if m.Created == nil {
+		m.Created = config.Created
+	}
+
+	return nil
+}
+
+// config fetches and parses the image config blob
+func (i *Inspector) config(ctx context.Context, repo name.Repository, desc ggcrv1.Descriptor) (*seaglass.ImageConfig, error) {
+	layer, err := i.puller.Layer(ctx, repo.Digest(desc.Digest.String()))
+	if err != nil {
+		return nil, fmt.Errorf("fetching config: %w", err)
+	}
+	rc, err := layer.Compressed()
+	if err != nil {
+		return nil, fmt.Errorf("fetching config: %w", err)
+	}
+	defer rc.Close()
+
+	cf, err := ggcrv1.ParseConfigFile(rc)
+	if err != nil {
+		return nil, fmt.Errorf("parsing config: %w", err)
+	}
+
+	config := &seaglass.ImageConfig{
+		Digest:    desc.Digest.String(),
+		MediaType: string(desc.MediaType),
+		Size:      desc.Size,
+		Author:    cf.Author,
+		Labels:    cf.Config.Labels,
+	}
+	if cf.OS != "" || cf.Architecture != "" {
+		config.Platform = &seaglass.Platform{
+			OS:           cf.OS,
+			Architecture: cf.Architecture,
+			Variant:      cf.Variant,
+			OSVersion:    cf.OSVersion,
+			OSFeatures:   cf.OSFeatures,
+		}
+	}
+	if !cf.Created.IsZero() {
+		created := cf.Created.UTC()
+		config.Created = &created
+	}
+
+	return config, nil
+}
+
+// reference returns the reference to a tag or digest in the repository
+func reference(repo name.Repository, ref string) (name.Reference, error) {
+	if strings.Contains(ref, ":") {
+		d, err := name.NewDigest(fmt.Sprintf("%s@%s", repo, ref))
+		if err != nil {
+			return nil, fmt.Errorf("parsing digest: %w", err)
+		}
+		return d, nil
+	}
+
+	t, err := name.NewTag(fmt.Sprintf("%s:%s", repo, ref))
+	if err != nil {
+		return nil, fmt.Errorf("parsing tag: %w", err)
+	}
+
+	return t, nil
+}
+
+func descriptor(d *ggcrv1.Descriptor) *seaglass.Descriptor {
+	if d == nil {
+		return nil
+	}
+
+	desc := &seaglass.Descriptor{
+		Digest:       d.Digest.String(),
+		MediaType:    string(d.MediaType),
+		ArtifactType: d.ArtifactType,
+		Size:         d.Size,
+		Annotations:  d.Annotations,
+	}
+	if d.Platform != nil {
+		desc.Platform = &seaglass.Platform{
+			OS:           d.Platform.OS,
+			Architecture: d.Platform.Architecture,
+			Variant:      d.Platform.Variant,
+			OSVersion:    d.Platform.OSVersion,
+			OSFeatures:   d.Platform.OSFeatures,
+		}
+	}
+
+	return desc
+}
+
+// artifactType returns the artifactType field of a manifest, which
+// ggcrv1.Manifest doesn't include
+func artifactType(manifest []byte) string {
+	var m struct {
+		ArtifactType string `json:"artifactType"`
+	}
+	if err := json.Unmarshal(manifest, &m); err != nil {
+		return ""
+	}
+
+	return m.ArtifactType
+}
+
+func isImageConfig(mt types.MediaType) bool {
+	return mt == types.OCIConfigJSON || mt == types.DockerConfigJSON
+}
+
+func isNotFound(err error) bool {
+	var terr *transport.Error
+	return errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,365 @@
+package inspect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	ggcrv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+)
+
+func TestInspectorGetManifest(t *testing.T) {
+	reg := setupRegistry(t)
+
+	i, err := New(reg)
+	if err != nil {
+		t.Fatalf("unexpected error creating inspector: %s", err)
+	}
+
+	created := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+
+	img, err := random.Image(1024, 2)
+	if err != nil {
+		t.Fatalf("unexpected error creating test image: %s", err)
+	}
+	img, err = mutate.ConfigFile(img, &ggcrv1.ConfigFile{
+		Created:      ggcrv1.Time{Time: created},
+		OS:           "linux",
+		Architecture: "arm64",
+		Variant:      "v8",
+		Config: ggcrv1.Config{
+			Labels: map[string]string{"foo": "bar"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error setting config: %s", err)
+	}
+	img = mutate.Annotations(img, map[string]string{
+		"org.opencontainers.image.source": "https://github.com/jetstack/seaglass",
+	}).(ggcrv1.Image)
+
+	if err := remote.Write(reg.Repo("foo/bar").Tag("latest"), img); err != nil {
+		t.Fatalf("unexpected error pushing image: %s", err)
+	}
+
+	idx := mutate.AppendManifests(empty.Index, mutate.IndexAddendum{
+		Add: img,
+		Descriptor: ggcrv1.Descriptor{
+			Platform: &ggcrv1.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"},
+		},
+	})
+	if err := remote.WriteIndex(reg.Repo("foo/bar").Tag("index"), idx); err != nil {
+		t.Fatalf("unexpected error pushing index: %s", err)
+	}
+
+	t.Run("image by tag", func(t *testing.T) {
+		ctx := context.Background()
+
+		got, err := i.GetManifest(ctx, "foo/bar", "latest")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		manifest, err := img.Manifest()
+		if err != nil {
+			t.Fatalf("unexpected error getting manifest: %s", err)
+		}
+		digest, err := img.Digest()
+		if err != nil {
+			t.Fatalf("unexpected error getting digest: %s", err)
+		}
+		size, err := img.Size()
+		if err != nil {
+			t.Fatalf("unexpected error getting size: %s", err)
+		}
+
+		want := &seaglass.ManifestDetail{
+			Digest:    digest.String(),
+			MediaType: string(types.DockerManifestSchema2),
+			Size:      size,
+			TotalSize: size + manifest.Config.Size + manifest.Layers[0].Size + manifest.Layers[1].Size,
+			Annotations: map[string]string{
+				"org.opencontainers.image.source": "https://github.com/jetstack/seaglass",
+			},
+			Config: &seaglass.ImageConfig{
+				Digest:    manifest.Config.Digest.String(),
+				MediaType: string(manifest.Config.MediaType),
+				Size:      manifest.Config.Size,
+				Created:   &created,
+				Platform: &seaglass.Platform{
+					OS:           "linux",
+					Architecture: "arm64",
+					Variant:      "v8",
+				},
+				Labels: map[string]string{"foo": "bar"},
+			},
+		}
+		for _, l := range manifest.Layers {
+			want.Layers = append(want.Layers, seaglass.Descriptor{
+				Digest:    l.Digest.String(),
+				MediaType: string(l.MediaType),
+				Size:      l.Size,
+			})
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("index by digest", func(t *testing.T) {
+		ctx := context.Background()
+
+		digest, err := idx.Digest()
+		if err != nil {
+			t.Fatalf("unexpected error getting digest: %s", err)
+		}
+
+		got, err := i.GetManifest(ctx, "foo/bar", digest.String())
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		imgDigest, err := img.Digest()
+		if err != nil {
+			t.Fatalf("unexpected error getting digest: %s", err)
+		}
+		imgSize, err := img.Size()
+		if err != nil {
+			t.Fatalf("unexpected error getting size: %s", err)
+		}
+		size, err := idx.Size()
+		if err != nil {
+			t.Fatalf("unexpected error getting size: %s", err)
+		}
+
+		want := &seaglass.ManifestDetail{
+			Digest:    digest.String(),
+			MediaType: string(types.OCIImageIndex),
+			Size:      size,
+			TotalSize: size + imgSize,
+			Manifests: []seaglass.Descriptor{
+				{
+					Digest:    imgDigest.String(),
+					MediaType: string(types.DockerManifestSchema2),
+					Size:      imgSize,
+					Platform: &seaglass.Platform{
+						OS:           "linux",
+						Architecture: "arm64",
+						Variant:      "v8",
+					},
+				},
+			},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		ctx := context.Background()
+
+		got, err := i.GetManifest(ctx, "foo/bar", "missing")
+		if !errors.Is(err, seaglass.ErrNotFound) {
+			t.Errorf("unexpected error: %s", err)
+		}
+		if got != nil {
+			t.Errorf("unexpected response: %v", got)
+		}
+	})
+}
+
+func TestInspectorListReferrers(t *testing.T) {
+	for _, referrersSupport := range []bool{true, false} {
+		t.Run(fmt.Sprintf("referrers support %t", referrersSupport), func(t *testing.T) {
+			reg := setupRegistry(t, registry.WithReferrersSupport(referrersSupport))
+
+			i, err := New(reg)
+			if err != nil {
+				t.Fatalf("unexpected error creating inspector: %s", err)
+			}
+
+			repo := reg.Repo("foo/bar")
+
+			img, err := random.Image(1024, 1)
+			if err != nil {
+				t.Fatalf("unexpected error creating test image: %s", err)
+			}
+			img = mutate.MediaType(img, types.OCIManifestSchema1)
+			if err := remote.Write(repo.Tag("latest"), img); err != nil {
+				t.Fatalf("unexpected error pushing image: %s", err)
+			}
+			desc, err := partial.Descriptor(img)
+			if err != nil {
+				t.Fatalf("unexpected error getting descriptor: %s", err)
+			}
+
+			// An SBOM that refers to the image with the subject field
+			sbom, err := random.Image(256, 1)
+			if err != nil {
+				t.Fatalf("unexpected error creating test sbom: %s", err)
+			}
+			sbom = mutate.MediaType(sbom, types.OCIManifestSchema1)
+			sbom = mutate.ConfigMediaType(sbom, "application/spdx+json")
+			sbom = mutate.Subject(sbom, *desc).(ggcrv1.Image)
+			sbomDigest, err := sbom.Digest()
+			if err != nil {
+				t.Fatalf("unexpected error getting digest: %s", err)
+			}
+			if err := remote.Write(repo.Digest(sbomDigest.String()), sbom); err != nil {
+				t.Fatalf("unexpected error pushing sbom: %s", err)
+			}
+
+			// A signature in cosign's tag scheme
+			sig, err := random.Image(256, 1)
+			if err != nil {
+				t.Fatalf("unexpected error creating test signature: %s", err)
+			}
+			sigDigest, err := sig.Digest()
+			if err != nil {
+				t.Fatalf("unexpected error getting digest: %s", err)
+			}
+			sigTag := seaglass.ReferrerTag(desc.Digest.String(), ".sig")
+			if err := remote.Write(repo.Tag(sigTag), sig); err != nil {
+				t.Fatalf("unexpected error pushing signature: %s", err)
+			}
+
+			sbomReferrer := seaglass.Referrer{
+				Descriptor: seaglass.Descriptor{
+					Digest:       sbomDigest.String(),
+					ArtifactType: "application/spdx+json",
+				},
+			}
+			sigReferrer := seaglass.Referrer{
+				Descriptor: seaglass.Descriptor{
+					Digest:       sigDigest.String(),
+					ArtifactType: seaglass.ArtifactTypeCosignSignature,
+				},
+				Tag: sigTag,
+			}
+
+			testCases := map[string]struct {
+				artifactType string
+				want         []seaglass.Referrer
+			}{
+				"all referrers": {
+					want: []seaglass.Referrer{sbomReferrer, sigReferrer},
+				},
+				"by artifact type": {
+					artifactType: "application/spdx+json",
+					want:         []seaglass.Referrer{sbomReferrer},
+				},
+				"by cosign artifact type": {
+					artifactType: seaglass.ArtifactTypeCosignSignature,
+					want:         []seaglass.Referrer{sigReferrer},
+				},
+				"no matching referrers": {
+					artifactType: seaglass.ArtifactTypeCosignAttestation,
+					want:         []seaglass.Referrer{},
+				},
+			}
+			for name, tc := range testCases {
+				t.Run(name, func(t *testing.T) {
+					got, err := i.ListReferrers(context.Background(), "foo/bar", desc.Digest.String(), tc.artifactType)
+					if err != nil {
+						t.Fatalf("unexpected error: %s", err)
+					}
+
+					if diff := cmp.Diff(tc.want, got, cmpopts.IgnoreFields(seaglass.Descriptor{}, "MediaType", "Size", "Annotations")); diff != "" {
+						t.Errorf("unexpected result:\n%s", diff)
+					}
+				})
+			}
+
+			t.Run("attached to manifests", func(t *testing.T) {
+				pages := func(yield func(*seaglass.ManifestList, error) bool) {
+					yield(&seaglass.ManifestList{Manifests: []seaglass.Manifest{{Digest: desc.Digest.String()}}}, nil)
+				}
+				got, err := seaglass.CollectManifests(i.Resolve(context.Background(), "foo/bar", pages, &seaglass.ManifestListOptions{Referrers: true}))
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+
+				want := &seaglass.ManifestList{
+					Manifests: []seaglass.Manifest{
+						{
+							Digest:    desc.Digest.String(),
+							Referrers: []seaglass.Referrer{sbomReferrer, sigReferrer},
+						},
+					},
+				}
+				if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(seaglass.Descriptor{}, "MediaType", "Size", "Annotations")); diff != "" {
+					t.Errorf("unexpected result:\n%s", diff)
+				}
+			})
+		})
+	}
+}
+
+func TestInspectorResolveTag(t *testing.T) {
+	reg := setupRegistry(t)
+
+	i, err := New(reg)
+	if err != nil {
+		t.Fatalf("unexpected error creating inspector: %s", err)
+	}
+
+	img, err := random.Image(1024, 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating test image: %s", err)
+	}
+	if err := remote.Write(reg.Repo("foo/bar").Tag("latest"), img); err != nil {
+		t.Fatalf("unexpected error pushing image: %s", err)
+	}
+	desc, err := partial.Descriptor(img)
+	if err != nil {
+		t.Fatalf("unexpected error getting descriptor: %s", err)
+	}
+
+	ctx := context.Background()
+
+	got, err := i.ResolveTag(ctx, "foo/bar", "latest")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want := &seaglass.Manifest{
+		Digest:    desc.Digest.String(),
+		MediaType: string(desc.MediaType),
+		Tags:      []string{"latest"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected manifest:\n%s", diff)
+	}
+
+	if _, err := i.ResolveTag(ctx, "foo/bar", "missing"); !errors.Is(err, seaglass.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing tag, got: %v", err)
+	}
+}
+
+func setupRegistry(t *testing.T, opts ...registry.Option) name.Registry {
+	r := httptest.NewServer(registry.New(opts...))
+	t.Cleanup(r.Close)
+	u, err := url.Parse(r.URL)
+	if err != nil {
+		t.Fatalf("unexpected error parsing registry url: %s", err)
+	}
+	reg, err := name.NewRegistry(u.Host)
+	if err != nil {
+		t.Fatalf("unexpected error parsing registry: %s", err)
+	}
+	return reg
+}
@@ -0,0 +1,241 @@
+// Package mirror copies the manifests and tags in repositories from one
+// registry to another.
+package mirror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+)
+
+// Action is what's done to mirror a manifest
+type Action string
+
+const (
+	// ActionCopy copies the manifest, and the content it refers to, to the
+	// destination and sets its tags there
+	ActionCopy Action = "copy"
+
+	// ActionTag sets tags on a manifest that's already at the
+	// destination
+	ActionTag Action = "tag"
+
+	// ActionSkip does nothing, because the manifest and its tags are
+	// already at the destination
+	ActionSkip Action = "skip"
+)
+
+// Result is what was done, or would be done in a dry run, to mirror a manifest
+type Result struct {
+	// Source is the repository the manifest is copied from
+	Source string `json:"source"`
+
+	// Destination is the repository the manifest is copied to
+	Destination string `json:"destination"`
+
+	// Digest is the digest of the manifest
+	Digest string `json:"digest"`
+
+	// Action is what was done
+	Action Action `json:"action"`
+
+	// Tags are the tags that were set at the destination, because they
+	// were missing or pointed to a different manifest
+	Tags []string `json:"tags,omitempty"`
+}
+
+// Options are options for mirroring
+type Options struct {
+	// DryRun works out what would be done, without writing anything to
+	// the destination
+	DryRun bool
+
+	// ProgressFile is the path of a file that records the manifests that
+	// have been mirrored. If it's set, manifests that are already
+	// recorded in it are skipped without checking the destination, so
+	// that an interrupted mirror can resume where it left off.
+	ProgressFile string
+
+	// RemoteOptions are the options for reading from the source and
+	// writing to the destination, like the credentials and transport
+	RemoteOptions []remote.Option
+}
+
+// Mirror copies manifests between repositories
+type Mirror struct {
+	opts     Options
+	puller   *remote.Puller
+	progress *progress
+}
+
+// New returns a new Mirror. It must be closed once it's finished with, to
+// close the progress file.
+func New(opts *Options) (*Mirror, error) {
+	m := &Mirror{}
+	if opts != nil {
+		m.opts = *opts
+	}
+
+	puller, err := remote.NewPuller(m.opts.RemoteOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("creating puller: %w", err)
+	}
+	m.puller = puller
+
+	if m.opts.ProgressFile != "" && !m.opts.DryRun {
+		p, err := openProgress(m.opts.ProgressFile)
+		if err != nil {
+			return nil, err
+		}
+		m.progress = p
+	}
+
+	return m, nil
+}
+
+// Close closes the progress file
+func (m *Mirror) Close() error {
+	if m.progress == nil {
+		return nil
+	}
+
+	return m.progress.close()
+}
+
+// Repository mirrors the manifests, listed from the src repository, to the dst
+// repository and calls fn with the result for each one. The manifests should
+// be merged, so that each appears once with all of its tags.
+//
+// Manifests that are already at the destination aren't copied again, but any
+// of their tags that are missing or point to another manifest are set.
+func (m *Mirror) Repository(ctx context.Context, src, dst name.Repository, manifests []seaglass.Manifest, fn func(*Result) error) error {
+	// Only the tags that exist at the destination have to be checked,
+	// so they're listed once up front
+	dstTags := map[string]bool{}
+	tags, err := m.puller.List(ctx, dst)
+	if err != nil && !isNotFound(err) {
+		return fmt.Errorf("listing tags in %s: %w", dst, err)
+	}
+	for _, tag := range tags {
+		dstTags[tag] = true
+	}
+
+	for _, manifest := range manifests {
+		result, err := m.manifest(ctx, src, dst, manifest, dstTags)
+		if err != nil {
+			return fmt.Errorf("mirroring %s@%s: %w", src, manifest.Digest, err)
+		}
+		if err := fn(result); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Mirror) manifest(ctx context.Context, src, dst name.Repository, manifest seaglass.Manifest, dstTags map[string]bool) (*Result, error) {
+	result := &Result{
+		Source:      src.String(),
+		Destination: dst.String(),
+		Digest:      manifest.Digest,
+		Action:      ActionSkip,
+	}
+
+	if m.progress.done(dst.String(), manifest.Digest, manifest.Tags) {
+		return result, nil
+	}
+
+	srcRef := src.Digest(manifest.Digest)
+	dstRef := dst.Digest(manifest.Digest)
+
+	if _, err := m.puller.Head(ctx, dstRef); err != nil {
+		if !isNotFound(err) {
+			return nil, fmt.Errorf("checking destination: %w", err)
+		}
+		result.Action = ActionCopy
+	}
+
+	for _, tag := range manifest.Tags {
+		if dstTags[tag] {
+			desc, err := m.puller.Head(ctx, dst.Tag(tag))
+			if err != nil && !isNotFound(err) {
+				return nil, fmt.Errorf("checking destination tag %s: %w", tag, err)
+			}
+			if desc != nil && desc.Digest.String() == manifest.Digest {
+				continue
+			}
+		}
+		result.Tags = append(result.Tags, tag)
+	}
+	if result.Action == ActionSkip && len(result.Tags) > 0 {
+		result.Action = ActionTag
+	}
+
+	if m.opts.DryRun || result.Action == ActionSkip {
+		if err := m.progress.record(dst.String(), manifest.Digest, manifest.Tags); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	desc, err := m.puller.Get(ctx, srcRef)
+	if err != nil {
+		return nil, fmt.Errorf("fetching manifest: %w", err)
+	}
+
+	opts := append([]remote.Option{remote.WithContext(ctx)}, m.opts.RemoteOptions...)
+	if result.Action == ActionCopy {
+		if err := write(dstRef, desc, opts); err != nil {
+			return nil, err
+		}
+	}
+	for _, tag := range result.Tags {
+		// The content is at the destination by now, so only the
+		// manifest has to be written for each tag
+		if err := remote.Tag(dst.Tag(tag), desc, opts...); err != nil {
+			return nil, fmt.Errorf("tagging %s: %w", tag, err)
+		}
+	}
+
+	if err := m.progress.record(dst.String(), manifest.Digest, manifest.Tags); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// write copies the manifest, and the content it refers to, to the reference
+func write(ref name.Reference, desc *remote.Descriptor, opts []remote.Option) error {
+	if desc.MediaType.IsIndex() {
+		idx, err := desc.ImageIndex()
+		if err != nil {
+			return fmt.Errorf("reading index: %w", err)
+		}
+		if err := remote.WriteIndex(ref, idx, opts...); err != nil {
+			return fmt.Errorf("writing index: %w", err)
+		}
+
+		return nil
+	}
+
+	img, err := desc.Image()
+	if err != nil {
+		return fmt.Errorf("reading image: %w", err)
+	}
+	if err := remote.Write(ref, img, opts...); err != nil {
+		return fmt.Errorf("writing image: %w", err)
+	}
+
+	return nil
+}
+
+func isNotFound(err error) bool {
+	var terr *transport.Error
+	return errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound
+}
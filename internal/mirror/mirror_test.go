@@ -0,0 +1,192 @@
+package mirror
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	ggcrv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+	registryclient "github.com/jetstack/seaglass/pkg/seaglass/clients/registry"
+)
+
+func TestMirrorRepository(t *testing.T) {
+	ctx := context.Background()
+
+	src := setupRepository(t)
+	c, err := registryclient.NewClient(src.RegistryStr())
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %s", err)
+	}
+
+	img1, err := random.Image(1024, 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating test image: %s", err)
+	}
+	img2, err := random.Image(1024, 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating test image: %s", err)
+	}
+	idx, err := random.Index(1024, 1, 2)
+	if err != nil {
+		t.Fatalf("unexpected error creating test index: %s", err)
+	}
+	digest1, _ := img1.Digest()
+	digest2, _ := img2.Digest()
+	idxDigest, _ := idx.Digest()
+
+	for tag, img := range map[string]ggcrv1.Image{"v1": img1, "v2": img1, "other": img2, "moved": img2} {
+		if err := remote.Write(src.Tag(tag), img); err != nil {
+			t.Fatalf("unexpected error pushing image: %s", err)
+		}
+	}
+	if err := remote.WriteIndex(src.Tag("multi"), idx); err != nil {
+		t.Fatalf("unexpected error pushing index: %s", err)
+	}
+
+	// listManifests lists the manifests in the source repository
+	listManifests := func(t *testing.T) []seaglass.Manifest {
+		list, err := c.ListManifests(ctx, src.RepositoryStr(), nil)
+		if err != nil {
+			t.Fatalf("unexpected error listing manifests: %s", err)
+		}
+		return list.Manifests
+	}
+
+	// mirror mirrors the source repository and returns the results by
+	// digest
+	mirror := func(t *testing.T, dst name.Repository, opts *Options) map[string]Result {
+		m, err := New(opts)
+		if err != nil {
+			t.Fatalf("unexpected error creating mirror: %s", err)
+		}
+		defer m.Close()
+
+		got := map[string]Result{}
+		err = m.Repository(ctx, src, dst, listManifests(t), func(r *Result) error {
+			got[r.Digest] = *r
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error mirroring: %s", err)
+		}
+		return got
+	}
+
+	result := func(dst name.Repository, digest string, action Action, tags ...string) Result {
+		return Result{
+			Source:      src.String(),
+			Destination: dst.String(),
+			Digest:      digest,
+			Action:      action,
+			Tags:        tags,
+		}
+	}
+
+	t.Run("mirror to a destination with some of the content", func(t *testing.T) {
+		dst := setupRepository(t)
+		if err := remote.Write(dst.Tag("v1"), img1); err != nil {
+			t.Fatalf("unexpected error pushing image: %s", err)
+		}
+		if err := remote.Write(dst.Tag("moved"), img1); err != nil {
+			t.Fatalf("unexpected error pushing image: %s", err)
+		}
+
+		want := map[string]Result{
+			digest1.String():   result(dst, digest1.String(), ActionTag, "v2"),
+			digest2.String():   result(dst, digest2.String(), ActionCopy, "moved", "other"),
+			idxDigest.String(): result(dst, idxDigest.String(), ActionCopy, "multi"),
+		}
+		if diff := cmp.Diff(want, mirror(t, dst, nil)); diff != "" {
+			t.Errorf("unexpected results:\n%s", diff)
+		}
+
+		// Mirroring again has nothing left to do
+		want = map[string]Result{
+			digest1.String():   result(dst, digest1.String(), ActionSkip),
+			digest2.String():   result(dst, digest2.String(), ActionSkip),
+			idxDigest.String(): result(dst, idxDigest.String(), ActionSkip),
+		}
+		if diff := cmp.Diff(want, mirror(t, dst, nil)); diff != "" {
+			t.Errorf("unexpected results mirroring again:\n%s", diff)
+		}
+
+		for tag, digest := range map[string]string{"v1": digest1.String(), "v2": digest1.String(), "other": digest2.String(), "moved": digest2.String(), "multi": idxDigest.String()} {
+			desc, err := remote.Head(dst.Tag(tag))
+			if err != nil {
+				t.Fatalf("unexpected error fetching %s from destination: %s", tag, err)
+			}
+			if desc.Digest.String() != digest {
+				t.Errorf("unexpected digest for %s: %s", tag, desc.Digest)
+			}
+		}
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		dst := setupRepository(t)
+
+		want := map[string]Result{
+			digest1.String():   result(dst, digest1.String(), ActionCopy, "v1", "v2"),
+			digest2.String():   result(dst, digest2.String(), ActionCopy, "moved", "other"),
+			idxDigest.String(): result(dst, idxDigest.String(), ActionCopy, "multi"),
+		}
+		if diff := cmp.Diff(want, mirror(t, dst, &Options{DryRun: true})); diff != "" {
+			t.Errorf("unexpected results:\n%s", diff)
+		}
+
+		if _, err := remote.List(dst); err == nil {
+			t.Errorf("expected nothing to be written to the destination")
+		}
+	})
+
+	t.Run("resume from progress file", func(t *testing.T) {
+		progressFile := filepath.Join(t.TempDir(), "progress.json")
+
+		dst := setupRepository(t)
+		mirror(t, dst, &Options{ProgressFile: progressFile})
+
+		// The content that was recorded isn't checked again, so
+		// nothing is copied even though it's been removed from the
+		// destination
+		if err := remote.Delete(dst.Digest(digest2.String())); err != nil {
+			t.Fatalf("unexpected error deleting from destination: %s", err)
+		}
+
+		want := map[string]Result{
+			digest1.String():   result(dst, digest1.String(), ActionSkip),
+			digest2.String():   result(dst, digest2.String(), ActionSkip),
+			idxDigest.String(): result(dst, idxDigest.String(), ActionSkip),
+		}
+		if diff := cmp.Diff(want, mirror(t, dst, &Options{ProgressFile: progressFile})); diff != "" {
+			t.Errorf("unexpected results:\n%s", diff)
+		}
+
+		// Without it, the missing content is copied again
+		want[digest2.String()] = result(dst, digest2.String(), ActionCopy)
+		if diff := cmp.Diff(want, mirror(t, dst, nil)); diff != "" {
+			t.Errorf("unexpected results without progress file:\n%s", diff)
+		}
+	})
+}
+
+// setupRepository starts a registry and returns a repository in it
+func setupRepository(t *testing.T) name.Repository {
+	r := httptest.NewServer(registry.New())
+	t.Cleanup(r.Close)
+	u, err := url.Parse(r.URL)
+	if err != nil {
+		t.Fatalf("unexpected error parsing registry url: %s", err)
+	}
+	repo, err := name.NewRepository(u.Host + "/foo/bar")
+	if err != nil {
+		t.Fatalf("unexpected error parsing repository: %s", err)
+	}
+	return repo
+}
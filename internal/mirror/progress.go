@@ -0,0 +1,100 @@
+package mirror
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+)
+
+// progress records the manifests that have been mirrored in a file, with a
+// JSON object on each line. Lines are only ever appended, so a mirror that's
+// interrupted loses at most the line it was writing.
+type progress struct {
+	f    *os.File
+	enc  *json.Encoder
+	tags map[string][]string
+}
+
+// progressEntry is a line in the progress file
+type progressEntry struct {
+	Repository string   `json:"repository"`
+	Digest     string   `json:"digest"`
+	Tags       []string `json:"tags,omitempty"`
+}
+
+// openProgress reads the progress recorded in the file, if it exists, and
+// opens it to record more
+func openProgress(path string) (*progress, error) {
+	p := &progress{tags: map[string][]string{}}
+
+	f, err := os.Open(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("opening progress file: %w", err)
+	}
+	if err == nil {
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var entry progressEntry
+			// A line that can't be decoded was cut short, so the
+			// manifest is mirrored again
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				continue
+			}
+			key := entry.Repository + "@" + entry.Digest
+			p.tags[key] = append(p.tags[key], entry.Tags...)
+		}
+		f.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("reading progress file: %w", err)
+		}
+	}
+
+	p.f, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("opening progress file: %w", err)
+	}
+	p.enc = json.NewEncoder(p.f)
+
+	return p, nil
+}
+
+// done reports whether the manifest has been mirrored to the repository
+// with all of the tags
+func (p *progress) done(repo, digest string, tags []string) bool {
+	if p == nil {
+		return false
+	}
+	recorded, ok := p.tags[repo+"@"+digest]
+	if !ok {
+		return false
+	}
+	for _, tag := range tags {
+		if !slices.Contains(recorded, tag) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// record records that the manifest has been mirrored to the repository with
+// the tags
+func (p *progress) record(repo, digest string, tags []string) error {
+	if p == nil {
+		return nil
+	}
+	if err := p.enc.Encode(progressEntry{Repository: repo, Digest: digest, Tags: tags}); err != nil {
+		return fmt.Errorf("recording progress: %w", err)
+	}
+	key := repo + "@" + digest
+	p.tags[key] = append(p.tags[key], tags...)
+
+	return nil
+}
+
+func (p *progress) close() error {
+	return p.f.Close()
+}
@@ -0,0 +1,224 @@
+// Package output prints the results of commands in the format chosen by the
+// user.
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Formats are the supported output formats, not including the template format
+var Formats = []string{"json", "yaml", "ndjson", "table"}
+
+// Column is a column in a table
+type Column[T any] struct {
+	Header string
+	Value  func(T) string
+}
+
+// Format describes how to print items of a given type as text and as a table.
+// Every other format is derived from the JSON encoding of the item.
+type Format[T any] struct {
+	// Text returns the line that's printed for the item when no output
+	// format is chosen
+	Text func(T) string
+
+	// Columns are the columns of the table format
+	Columns []Column[T]
+}
+
+// Printer prints items. Depending on the format, items may be printed as
+// soon as they're added or buffered until Flush is called.
+type Printer[T any] interface {
+	// Print prints the item
+	Print(item T) error
+
+	// Flush prints any buffered items
+	Flush() error
+}
+
+// NewPrinter returns a printer for the output format, which is one of
+// Formats, "template=<go template>" or empty for the default text format.
+func NewPrinter[T any](w io.Writer, output string, f Format[T]) (Printer[T], error) {
+	if text, ok := strings.CutPrefix(output, "template="); ok {
+		tmpl, err := template.New("output").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("parsing template: %w", err)
+		}
+
+		return &templatePrinter[T]{w: w, tmpl: tmpl}, nil
+	}
+
+	switch output {
+	case "":
+		return &textPrinter[T]{w: w, text: f.Text}, nil
+	case "json":
+		return &bufferedPrinter[T]{w: w, encode: encodeJSON}, nil
+	case "yaml":
+		return &bufferedPrinter[T]{w: w, encode: encodeYAML}, nil
+	case "ndjson":
+		return &ndjsonPrinter[T]{enc: json.NewEncoder(w)}, nil
+	case "table":
+		return newTablePrinter(w, f.Columns), nil
+	default:
+		return nil, fmt.Errorf("unsupported output format %q, must be one of %s or template=<template>", output, strings.Join(Formats, ", "))
+	}
+}
+
+// Time formats an optional timestamp for a table
+func Time(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+
+	return t.Format(time.RFC3339)
+}
+
+type textPrinter[T any] struct {
+	w    io.Writer
+	text func(T) string
+}
+
+func (p *textPrinter[T]) Print(item T) error {
+	_, err := fmt.Fprintln(p.w, p.text(item))
+
+	return err
+}
+
+func (p *textPrinter[T]) Flush() error {
+	return nil
+}
+
+type templatePrinter[T any] struct {
+	w    io.Writer
+	tmpl *template.Template
+}
+
+func (p *templatePrinter[T]) Print(item T) error {
+	if err := p.tmpl.Execute(p.w, item); err != nil {
+		return fmt.Errorf("executing template: %w", err)
+	}
+	_, err := fmt.Fprintln(p.w)
+
+	return err
+}
+
+func (p *templatePrinter[T]) Flush() error {
+	return nil
+}
+
+type ndjsonPrinter[T any] struct {
+	enc *json.Encoder
+}
+
+func (p *ndjsonPrinter[T]) Print(item T) error {
+	return p.enc.Encode(item)
+}
+
+func (p *ndjsonPrinter[T]) Flush() error {
+	return nil
+}
+
+// bufferedPrinter collects every item and then encodes them as a list
+type bufferedPrinter[T any] struct {
+	w      io.Writer
+	items  []T
+	encode func(io.Writer, any) error
+}
+
+func (p *bufferedPrinter[T]) Print(item T) error {
+	p.items = append(p.items, item)
+
+	return nil
+}
+
+func (p *bufferedPrinter[T]) Flush() error {
+	items := p.items
+	if items == nil {
+		items = []T{}
+	}
+
+	return p.encode(p.w, items)
+}
+
+func encodeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(v)
+}
+
+// encodeYAML encodes v as YAML. The value is encoded as JSON first, so that the
+// YAML uses the same field names and ordering as the JSON output.
+func encodeYAML(w io.Writer, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encoding json: %w", err)
+	}
+
+	// JSON is valid YAML, so decoding it into a node preserves the order
+	// of the fields
+	var node yaml.Node
+	if err := yaml.Unmarshal(b, &node); err != nil {
+		return fmt.Errorf("decoding json: %w", err)
+	}
+	blockStyle(&node)
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return fmt.Errorf("encoding yaml: %w", err)
+	}
+
+	return enc.Close()
+}
+
+// blockStyle clears the flow style that nodes decoded from JSON have, so that
+// they're encoded in the block style
+func blockStyle(node *yaml.Node) {
+	node.Style &^= yaml.FlowStyle | yaml.DoubleQuotedStyle
+	for _, n := range node.Content {
+		blockStyle(n)
+	}
+}
+
+type tablePrinter[T any] struct {
+	tw      *tabwriter.Writer
+	columns []Column[T]
+}
+
+func newTablePrinter[T any](w io.Writer, columns []Column[T]) *tablePrinter[T] {
+	p := &tablePrinter[T]{
+		tw:      tabwriter.NewWriter(w, 0, 4, 2, ' ', 0),
+		columns: columns,
+	}
+
+	var headers []string
+	for _, c := range columns {
+		headers = append(headers, c.Header)
+	}
+	fmt.Fprintln(p.tw, strings.Join(headers, "\t"))
+
+	return p
+}
+
+func (p *tablePrinter[T]) Print(item T) error {
+	var values []string
+	for _, c := range p.columns {
+		values = append(values, c.Value(item))
+	}
+	_, err := fmt.Fprintln(p.tw, strings.Join(values, "\t"))
+
+	return err
+}
+
+func (p *tablePrinter[T]) Flush() error {
+	return p.tw.Flush()
+}
@@ -0,0 +1,136 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type item struct {
+	Name    string     `json:"name"`
+	Tags    []string   `json:"tags,omitempty"`
+	Version string     `json:"version,omitempty"`
+	Created *time.Time `json:"created,omitempty"`
+}
+
+var format = Format[item]{
+	Text: func(i item) string {
+		return i.Name
+	},
+	Columns: []Column[item]{
+		{Header: "NAME", Value: func(i item) string { return i.Name }},
+		{Header: "CREATED", Value: func(i item) string { return Time(i.Created) }},
+	},
+}
+
+func TestPrinter(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	items := []item{
+		{Name: "foo", Tags: []string{"v1", "latest"}, Created: &created},
+		{Name: "bar-baz", Version: "1.0"},
+	}
+
+	testCases := map[string]struct {
+		output string
+		want   string
+	}{
+		"text": {
+			want: "foo\nbar-baz\n",
+		},
+		"json": {
+			output: "json",
+			want: `[
+  {
+    "name": "foo",
+    "tags": [
+      "v1",
+      "latest"
+    ],
+    "created": "2024-03-01T12:00:00Z"
+  },
+  {
+    "name": "bar-baz",
+    "version": "1.0"
+  }
+]
+`,
+		},
+		"yaml": {
+			output: "yaml",
+			want: `- name: foo
+  tags:
+    - v1
+    - latest
+  created: "2024-03-01T12:00:00Z"
+- name: bar-baz
+  version: "1.0"
+`,
+		},
+		"ndjson": {
+			output: "ndjson",
+			want: `{"name":"foo","tags":["v1","latest"],"created":"2024-03-01T12:00:00Z"}
+{"name":"bar-baz","version":"1.0"}
+`,
+		},
+		"table": {
+			output: "table",
+			want: `NAME     CREATED
+foo      2024-03-01T12:00:00Z
+bar-baz  -
+`,
+		},
+		"template": {
+			output: "template={{.Name}}={{len .Tags}}",
+			want:   "foo=2\nbar-baz=0\n",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			p, err := NewPrinter(&buf, tc.output, format)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			for _, i := range items {
+				if err := p.Print(i); err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+			}
+			if err := p.Flush(); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if diff := cmp.Diff(tc.want, buf.String()); diff != "" {
+				t.Errorf("unexpected output:\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("empty json", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		p, err := NewPrinter(&buf, "json", format)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if err := p.Flush(); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if diff := cmp.Diff("[]\n", buf.String()); diff != "" {
+			t.Errorf("unexpected output:\n%s", diff)
+		}
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		if _, err := NewPrinter(&bytes.Buffer{}, "xml", format); err == nil {
+			t.Errorf("expected error")
+		}
+	})
+}
@@ -0,0 +1,202 @@
+package prune
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jetstack/seaglass/pkg/seaglass"
+)
+
+// semverRegexp matches semantic versions, with an optional v prefix
+var semverRegexp = regexp.MustCompile(`^v?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+
+// Action is what a plan does with a manifest
+type Action string
+
+const (
+	// ActionKeep keeps the manifest
+	ActionKeep Action = "keep"
+
+	// ActionDelete deletes the manifest, along with its tags and
+	// referrers
+	ActionDelete Action = "delete"
+)
+
+// Decision is what a plan does with a manifest, and why
+type Decision struct {
+	seaglass.Manifest
+
+	// Action is what's done with the manifest
+	Action Action `json:"action"`
+
+	// Reason describes the rule that decided the action
+	Reason string `json:"reason"`
+}
+
+// Plan decides which of the manifests in a repository to keep and which to
+// delete, at the time now. The manifests should have been listed with the
+// policy's ListOptions and merged, so that each manifest appears once with all
+// of its tags.
+//
+// On top of the policy's rules, the manifests in an index that's kept and the
+// referrers of a manifest that's kept are always kept.
+func Plan(p *Policy, manifests []seaglass.Manifest, now time.Time) []Decision {
+	recent := p.lastTagged(manifests)
+
+	decisions := make([]Decision, len(manifests))
+	for i, m := range manifests {
+		d := Decision{Manifest: m, Action: ActionKeep}
+		if reason := p.keep(&m, recent[i], now); reason != "" {
+			d.Reason = reason
+		} else if reason := p.delete(&m, now); reason != "" {
+			d.Action = ActionDelete
+			d.Reason = reason
+		} else {
+			d.Reason = "no rule matched"
+		}
+		decisions[i] = d
+	}
+
+	// Deleting a manifest that a kept manifest depends on would break it,
+	// so they're kept too. An index can contain other indexes, so this
+	// repeats until nothing changes.
+	index := map[string]int{}
+	for i, d := range decisions {
+		index[d.Digest] = i
+	}
+	keep := func(digest, reason string) bool {
+		i, ok := index[digest]
+		if !ok || decisions[i].Action == ActionKeep {
+			return false
+		}
+		decisions[i].Action = ActionKeep
+		decisions[i].Reason = reason
+
+		return true
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, d := range decisions {
+			if d.Action != ActionKeep {
+				continue
+			}
+			for _, desc := range d.Manifests {
+				changed = keep(desc.Digest, "in kept index "+d.Digest) || changed
+			}
+			for _, r := range d.Referrers {
+				changed = keep(r.Digest, "referrer of kept manifest "+d.Digest) || changed
+			}
+		}
+	}
+
+	return decisions
+}
+
+// lastTagged returns the indexes of the Keep.LastTagged most recent tagged
+// manifests. Manifests without a timestamp count as the most recent, because
+// there's no telling how old they are.
+func (p *Policy) lastTagged(manifests []seaglass.Manifest) map[int]bool {
+	var tagged []int
+	for i, m := range manifests {
+		if len(m.Tags) > 0 {
+			tagged = append(tagged, i)
+		}
+	}
+	slices.SortStableFunc(tagged, func(a, b int) int {
+		ta, tb := p.time(&manifests[a]), p.time(&manifests[b])
+		switch {
+		case ta == nil && tb == nil:
+			return 0
+		case ta == nil:
+			return -1
+		case tb == nil:
+			return 1
+		}
+		return tb.Compare(*ta)
+	})
+
+	recent := map[int]bool{}
+	for _, i := range tagged[:min(len(tagged), p.Keep.LastTagged)] {
+		recent[i] = true
+	}
+
+	return recent
+}
+
+// keep returns the reason for keeping the manifest, or an empty string if no
+// keep rule matches it
+func (p *Policy) keep(m *seaglass.Manifest, recent bool, now time.Time) string {
+	if recent {
+		return fmt.Sprintf("one of the last %d tagged", p.Keep.LastTagged)
+	}
+	for _, tag := range m.Tags {
+		if pattern, ok := match(p.Keep.Tags, tag); ok {
+			return fmt.Sprintf("tag %s matches %s", tag, pattern)
+		}
+	}
+	if p.Keep.Semver {
+		for _, tag := range m.Tags {
+			if semverRegexp.MatchString(tag) {
+				return fmt.Sprintf("tag %s is a semantic version", tag)
+			}
+		}
+	}
+	if p.Keep.Referrers && len(m.Referrers) > 0 {
+		return fmt.Sprintf("has %d referrers", len(m.Referrers))
+	}
+	if p.Keep.NewerThan > 0 && !p.olderThan(m, p.Keep.NewerThan, now) && p.time(m) != nil {
+		return fmt.Sprintf("newer than %s", p.Keep.NewerThan)
+	}
+
+	return ""
+}
+
+// delete returns the reason for deleting the manifest, or an empty string if
+// no delete rule matches it
+func (p *Policy) delete(m *seaglass.Manifest, now time.Time) string {
+	if len(m.Tags) == 0 && p.Delete.UntaggedOlderThan > 0 && p.olderThan(m, p.Delete.UntaggedOlderThan, now) {
+		return fmt.Sprintf("untagged and older than %s", p.Delete.UntaggedOlderThan)
+	}
+	if len(m.Tags) > 0 && p.Delete.TaggedOlderThan > 0 && p.olderThan(m, p.Delete.TaggedOlderThan, now) {
+		return fmt.Sprintf("tagged and older than %s", p.Delete.TaggedOlderThan)
+	}
+	if len(m.Tags) > 0 && len(p.Delete.Tags) > 0 {
+		var patterns []string
+		for _, tag := range m.Tags {
+			pattern, ok := match(p.Delete.Tags, tag)
+			if !ok {
+				return ""
+			}
+			if !slices.Contains(patterns, pattern) {
+				patterns = append(patterns, pattern)
+			}
+		}
+		return fmt.Sprintf("tags match %s", strings.Join(patterns, ", "))
+	}
+
+	return ""
+}
+
+// olderThan reports whether the manifest is older than the duration. It's
+// false for manifests without a timestamp.
+func (p *Policy) olderThan(m *seaglass.Manifest, d Duration, now time.Time) bool {
+	t := p.time(m)
+
+	return t != nil && t.Before(now.Add(-time.Duration(d)))
+}
+
+// match returns the first of the glob patterns that matches the tag
+func match(patterns []string, tag string) (string, bool) {
+	for _, pattern := range patterns {
+		// The patterns are checked by Validate
+		if ok, _ := path.Match(pattern, tag); ok {
+			return pattern, true
+		}
+	}
+
+	return "", false
+}
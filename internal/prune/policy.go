@@ -0,0 +1,202 @@
+// Package prune plans which manifests in a repository can be deleted, based on
+// a retention policy.
+package prune
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jetstack/seaglass/pkg/seaglass"
+	"gopkg.in/yaml.v3"
+)
+
+// Policy is a retention policy for the manifests in a repository.
+//
+// A manifest is deleted if one of the Delete rules matches it and none of the
+// Keep rules do. Manifests that no rule matches are kept.
+type Policy struct {
+	// Time is the timestamp that the age of a manifest is measured from:
+	// uploaded, created or updated. It defaults to uploaded.
+	//
+	// Manifests without the timestamp are never deleted because of their
+	// age and count as the most recent for Keep.LastTagged.
+	Time string `yaml:"time,omitempty" json:"time,omitempty"`
+
+	// Keep are the rules for manifests that must be kept
+	Keep KeepRules `yaml:"keep,omitempty" json:"keep,omitempty"`
+
+	// Delete are the rules for manifests that can be deleted
+	Delete DeleteRules `yaml:"delete,omitempty" json:"delete,omitempty"`
+}
+
+// KeepRules are the rules for manifests that must be kept
+type KeepRules struct {
+	// LastTagged keeps the most recent tagged manifests
+	LastTagged int `yaml:"lastTagged,omitempty" json:"lastTagged,omitempty"`
+
+	// Tags keeps manifests with a tag that matches one of the glob
+	// patterns, as in path.Match
+	Tags []string `yaml:"tags,omitempty" json:"tags,omitempty"`
+
+	// Semver keeps manifests with a tag that's a semantic version, like
+	// 1.2.3 or v1.2.3-rc.1
+	Semver bool `yaml:"semver,omitempty" json:"semver,omitempty"`
+
+	// Referrers keeps manifests that have referrers, like signatures,
+	// SBOMs and attestations
+	Referrers bool `yaml:"referrers,omitempty" json:"referrers,omitempty"`
+
+	// NewerThan keeps manifests that are newer than the duration
+	NewerThan Duration `yaml:"newerThan,omitempty" json:"newerThan,omitempty"`
+}
+
+// DeleteRules are the rules for manifests that can be deleted
+type DeleteRules struct {
+	// UntaggedOlderThan deletes manifests without tags that are older
+	// than the duration
+	UntaggedOlderThan Duration `yaml:"untaggedOlderThan,omitempty" json:"untaggedOlderThan,omitempty"`
+
+	// TaggedOlderThan deletes manifests with tags that are older than the
+	// duration
+	TaggedOlderThan Duration `yaml:"taggedOlderThan,omitempty" json:"taggedOlderThan,omitempty"`
+
+	// Tags deletes manifests whose tags all match one of the glob
+	// patterns, as in path.Match. A manifest with another tag as well
+	// isn't deleted, because that would remove the other tag too.
+	Tags []string `yaml:"tags,omitempty" json:"tags,omitempty"`
+}
+
+// LoadPolicy reads a policy from a YAML file
+func LoadPolicy(name string) (*Policy, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("reading policy: %w", err)
+	}
+
+	return ParsePolicy(b)
+}
+
+// ParsePolicy parses a policy from YAML. Unknown fields are an error, so that
+// a misspelt rule doesn't silently delete more than it should.
+func ParsePolicy(b []byte) (*Policy, error) {
+	var p Policy
+
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&p); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parsing policy: %w", err)
+	}
+
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy: %w", err)
+	}
+
+	return &p, nil
+}
+
+// Validate checks that the policy is valid
+func (p *Policy) Validate() error {
+	switch p.Time {
+	case "", "uploaded", "created", "updated":
+	default:
+		return fmt.Errorf("invalid time %q: must be uploaded, created or updated", p.Time)
+	}
+	if p.Keep.LastTagged < 0 {
+		return fmt.Errorf("keep.lastTagged can't be negative")
+	}
+	for _, pattern := range append(slices.Clone(p.Keep.Tags), p.Delete.Tags...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid tag pattern %q: %w", pattern, err)
+		}
+	}
+	if p.Keep.NewerThan < 0 || p.Delete.UntaggedOlderThan < 0 || p.Delete.TaggedOlderThan < 0 {
+		return fmt.Errorf("durations can't be negative")
+	}
+	if p.Delete.UntaggedOlderThan == 0 && p.Delete.TaggedOlderThan == 0 && len(p.Delete.Tags) == 0 {
+		return fmt.Errorf("there are no delete rules")
+	}
+
+	return nil
+}
+
+// ListOptions returns the options to list manifests with so that the policy
+// can be applied to them. Platforms and referrers are always resolved, so
+// that the images in an index that's kept and the referrers of a manifest
+// that's kept are kept too, and the referrers of a manifest that's deleted are
+// deleted with it.
+func (p *Policy) ListOptions() *seaglass.ManifestListOptions {
+	return &seaglass.ManifestListOptions{
+		ResolvePlatforms: true,
+		HideArtifacts:    true,
+		Referrers:        true,
+	}
+}
+
+// time returns the timestamp of the manifest that its age is measured from
+func (p *Policy) time(m *seaglass.Manifest) *time.Time {
+	switch p.Time {
+	case "created":
+		return m.Created
+	case "updated":
+		return m.Updated
+	default:
+		return m.Uploaded
+	}
+}
+
+// Duration is a duration that can also be given in days, like 30d
+type Duration time.Duration
+
+// ParseDuration parses a duration in days, like 30d, or in the format of
+// time.ParseDuration
+func ParseDuration(s string) (Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil {
+			return Duration(time.Duration(n) * 24 * time.Hour), nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q: must be a number of days, like 30d, or a duration, like 12h", s)
+	}
+
+	return Duration(d), nil
+}
+
+// String formats the duration in days if it's a whole number of them
+func (d Duration) String() string {
+	day := 24 * time.Hour
+	if d != 0 && time.Duration(d)%day == 0 {
+		return fmt.Sprintf("%dd", time.Duration(d)/day)
+	}
+
+	return time.Duration(d).String()
+}
+
+// UnmarshalYAML parses the duration with ParseDuration
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	var s string
+	if err := node.Decode(&s); err != nil {
+		return err
+	}
+	v, err := ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = v
+
+	return nil
+}
+
+// MarshalText formats the duration with String
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
@@ -0,0 +1,305 @@
+package prune
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	ggcrv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+	registryclient "github.com/jetstack/seaglass/pkg/seaglass/clients/registry"
+)
+
+func TestParsePolicy(t *testing.T) {
+	testCases := map[string]struct {
+		policy  string
+		want    *Policy
+		wantErr bool
+	}{
+		"full policy": {
+			policy: `
+time: created
+keep:
+  lastTagged: 10
+  tags: ["latest", "release-*"]
+  semver: true
+  referrers: true
+  newerThan: 12h
+delete:
+  untaggedOlderThan: 30d
+  taggedOlderThan: 365d
+  tags: ["pr-*"]
+`,
+			want: &Policy{
+				Time: "created",
+				Keep: KeepRules{
+					LastTagged: 10,
+					Tags:       []string{"latest", "release-*"},
+					Semver:     true,
+					Referrers:  true,
+					NewerThan:  Duration(12 * time.Hour),
+				},
+				Delete: DeleteRules{
+					UntaggedOlderThan: Duration(30 * 24 * time.Hour),
+					TaggedOlderThan:   Duration(365 * 24 * time.Hour),
+					Tags:              []string{"pr-*"},
+				},
+			},
+		},
+		"unknown field": {
+			policy:  "delete:\n  untaggedOlderThen: 30d\n",
+			wantErr: true,
+		},
+		"invalid duration": {
+			policy:  "delete:\n  untaggedOlderThan: 30 days\n",
+			wantErr: true,
+		},
+		"invalid time": {
+			policy:  "time: pulled\ndelete:\n  untaggedOlderThan: 30d\n",
+			wantErr: true,
+		},
+		"invalid pattern": {
+			policy:  "delete:\n  tags: ['[pr']\n",
+			wantErr: true,
+		},
+		"no delete rules": {
+			policy:  "keep:\n  semver: true\n",
+			wantErr: true,
+		},
+		"empty": {
+			policy:  "",
+			wantErr: true,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParsePolicy([]byte(tc.policy))
+			if err != nil {
+				if !tc.wantErr {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			if tc.wantErr {
+				t.Fatalf("expected error")
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("unexpected policy:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPlan(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	daysAgo := func(n int) *time.Time {
+		t := now.AddDate(0, 0, -n)
+		return &t
+	}
+
+	manifests := []seaglass.Manifest{
+		{Digest: "sha256:latest", Tags: []string{"latest"}, Uploaded: daysAgo(1)},
+		{Digest: "sha256:release", Tags: []string{"v1.2.0"}, Uploaded: daysAgo(400)},
+		{Digest: "sha256:pr", Tags: []string{"pr-1"}, Uploaded: daysAgo(2)},
+		{Digest: "sha256:pr-and-main", Tags: []string{"pr-2", "main"}, Uploaded: daysAgo(3)},
+		{Digest: "sha256:old", Tags: []string{"old"}, Uploaded: daysAgo(400)},
+		{Digest: "sha256:untagged", Uploaded: daysAgo(60)},
+		{Digest: "sha256:untagged-new", Uploaded: daysAgo(10)},
+		{Digest: "sha256:no-time"},
+		{
+			Digest:    "sha256:signed",
+			Uploaded:  daysAgo(60),
+			Referrers: []seaglass.Referrer{{Descriptor: seaglass.Descriptor{Digest: "sha256:sbom"}}},
+		},
+		{Digest: "sha256:sbom", Uploaded: daysAgo(60)},
+		{
+			Digest:    "sha256:index",
+			Tags:      []string{"multi"},
+			Uploaded:  daysAgo(5),
+			Manifests: []seaglass.Descriptor{{Digest: "sha256:amd64"}, {Digest: "sha256:arm64"}},
+		},
+		{Digest: "sha256:amd64", Uploaded: daysAgo(90)},
+		{Digest: "sha256:arm64", Uploaded: daysAgo(90)},
+	}
+
+	policy := &Policy{
+		Keep: KeepRules{
+			LastTagged: 1,
+			Semver:     true,
+			Referrers:  true,
+		},
+		Delete: DeleteRules{
+			UntaggedOlderThan: Duration(30 * 24 * time.Hour),
+			TaggedOlderThan:   Duration(365 * 24 * time.Hour),
+			Tags:              []string{"pr-*"},
+		},
+	}
+
+	want := map[string]string{
+		"sha256:latest":       "keep: one of the last 1 tagged",
+		"sha256:release":      "keep: tag v1.2.0 is a semantic version",
+		"sha256:pr":           "delete: tags match pr-*",
+		"sha256:pr-and-main":  "keep: no rule matched",
+		"sha256:old":          "delete: tagged and older than 365d",
+		"sha256:untagged":     "delete: untagged and older than 30d",
+		"sha256:untagged-new": "keep: no rule matched",
+		"sha256:no-time":      "keep: no rule matched",
+		"sha256:signed":       "keep: has 1 referrers",
+		"sha256:sbom":         "keep: referrer of kept manifest sha256:signed",
+		"sha256:index":        "keep: no rule matched",
+		"sha256:amd64":        "keep: in kept index sha256:index",
+		"sha256:arm64":        "keep: in kept index sha256:index",
+	}
+
+	got := map[string]string{}
+	for _, d := range Plan(policy, manifests, now) {
+		got[d.Digest] = string(d.Action) + ": " + d.Reason
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected plan:\n%s", diff)
+	}
+}
+
+func TestPlanReferrersOfKeptManifests(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	daysAgo := func(n int) *time.Time {
+		t := now.AddDate(0, 0, -n)
+		return &t
+	}
+
+	// Without keep.referrers, the referrers of a manifest that's kept
+	// for another reason must still be listed and kept
+	policy := &Policy{
+		Keep: KeepRules{
+			Tags: []string{"latest"},
+		},
+		Delete: DeleteRules{
+			UntaggedOlderThan: Duration(30 * 24 * time.Hour),
+		},
+	}
+	if !policy.ListOptions().Referrers {
+		t.Errorf("expected referrers to be listed")
+	}
+
+	manifests := []seaglass.Manifest{
+		{
+			Digest:    "sha256:latest",
+			Tags:      []string{"latest"},
+			Uploaded:  daysAgo(60),
+			Referrers: []seaglass.Referrer{{Descriptor: seaglass.Descriptor{Digest: "sha256:sbom"}}},
+		},
+		{Digest: "sha256:sbom", Uploaded: daysAgo(60)},
+		{Digest: "sha256:untagged", Uploaded: daysAgo(60)},
+	}
+
+	want := map[string]string{
+		"sha256:latest":   "keep: tag latest matches latest",
+		"sha256:sbom":     "keep: referrer of kept manifest sha256:latest",
+		"sha256:untagged": "delete: untagged and older than 30d",
+	}
+
+	got := map[string]string{}
+	for _, d := range Plan(policy, manifests, now) {
+		got[d.Digest] = string(d.Action) + ": " + d.Reason
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected plan:\n%s", diff)
+	}
+}
+
+func TestPlanRegistry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	host := setupRegistry(t)
+	c, err := registryclient.NewClient(host)
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %s", err)
+	}
+	repo, err := name.NewRepository(host + "/foo/bar")
+	if err != nil {
+		t.Fatalf("unexpected error parsing repository: %s", err)
+	}
+
+	push := func(tag string, age time.Duration) ggcrv1.Hash {
+		img, err := random.Image(1024, 1)
+		if err != nil {
+			t.Fatalf("unexpected error creating test image: %s", err)
+		}
+		img, err = mutate.CreatedAt(img, ggcrv1.Time{Time: now.Add(-age)})
+		if err != nil {
+			t.Fatalf("unexpected error setting created time: %s", err)
+		}
+		if err := remote.Write(repo.Tag(tag), img); err != nil {
+			t.Fatalf("unexpected error pushing to registry: %s", err)
+		}
+		d, err := img.Digest()
+		if err != nil {
+			t.Fatalf("unexpected error getting digest: %s", err)
+		}
+		return d
+	}
+
+	day := 24 * time.Hour
+	latest := push("latest", day)
+	release := push("v1.0.0", 100*day)
+	feature := push("feature", 100*day)
+	signed := push("signed", 100*day)
+	push(strings.Replace(signed.String(), ":", "-", 1)+".sig", 100*day)
+
+	policy, err := ParsePolicy([]byte(`
+time: created
+keep:
+  lastTagged: 1
+  semver: true
+  referrers: true
+delete:
+  taggedOlderThan: 30d
+`))
+	if err != nil {
+		t.Fatalf("unexpected error parsing policy: %s", err)
+	}
+
+	list, err := c.ListManifests(ctx, "foo/bar", policy.ListOptions())
+	if err != nil {
+		t.Fatalf("unexpected error listing manifests: %s", err)
+	}
+
+	want := map[string]string{
+		latest.String():  "keep",
+		release.String(): "keep",
+		feature.String(): "delete",
+		signed.String():  "keep",
+	}
+
+	got := map[string]string{}
+	for _, d := range Plan(policy, list.Manifests, now) {
+		got[d.Digest] = string(d.Action)
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected plan:\n%s", diff)
+	}
+}
+
+func setupRegistry(t *testing.T) string {
+	r := httptest.NewServer(registry.New())
+	t.Cleanup(r.Close)
+	u, err := url.Parse(r.URL)
+	if err != nil {
+		t.Fatalf("unexpected error parsing registry url: %s", err)
+	}
+	return u.Host
+}
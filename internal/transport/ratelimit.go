@@ -0,0 +1,35 @@
+package transport
+
+import (
+	"net/http"
+
+	"golang.org/x/time/rate"
+)
+
+// NewRateLimitTransport returns a http.RoundTripper that waits for the limiter
+// before making each request. Waiting is cancelled with the context of the
+// request.
+func NewRateLimitTransport(rt http.RoundTripper, rl *rate.Limiter) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+
+	return &rateLimitTransport{
+		rt: rt,
+		rl: rl,
+	}
+}
+
+type rateLimitTransport struct {
+	rt http.RoundTripper
+	rl *rate.Limiter
+}
+
+// RoundTrip waits for the limiter and then makes the request
+func (t *rateLimitTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if err := t.rl.Wait(r.Context()); err != nil {
+		return nil, err
+	}
+
+	return t.rt.RoundTrip(r)
+}
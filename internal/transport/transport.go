@@ -8,21 +8,6 @@ import (
 	"github.com/google/go-containerregistry/pkg/name"
 )
 
-// NewClient returns a http.Client that is configured to authenticate with
-// credentials fetched from the provided keychain.
-//
-// If resource is provided, then the client will use the credentials for that
-// resource. Otherwise it will infer the resource from the request.
-func NewClient(httpClient *http.Client, kc authn.Keychain, resource authn.Resource) *http.Client {
-	if httpClient == nil {
-		httpClient = http.DefaultClient
-	}
-
-	httpClient.Transport = NewTransport(httpClient.Transport, kc, resource)
-
-	return httpClient
-}
-
 // NewTransport returns a http.RoundTripper that mutates requests to authenticate
 // with credentials fetched from the provided keychain.
 //
@@ -0,0 +1,31 @@
+package transport
+
+import (
+	"net/http"
+)
+
+// NewUserAgentTransport returns a http.RoundTripper that sets the User-Agent
+// header of each request
+func NewUserAgentTransport(rt http.RoundTripper, userAgent string) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+
+	return &userAgentTransport{
+		rt:        rt,
+		userAgent: userAgent,
+	}
+}
+
+type userAgentTransport struct {
+	rt        http.RoundTripper
+	userAgent string
+}
+
+// RoundTrip sets the user agent on a copy of the request and then makes it
+func (t *userAgentTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.Header.Set("User-Agent", t.userAgent)
+
+	return t.rt.RoundTrip(r)
+}
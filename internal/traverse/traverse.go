@@ -0,0 +1,234 @@
+// Package traverse lists the manifests in a tree of repositories
+// concurrently.
+package traverse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+
+	"github.com/jetstack/seaglass/pkg/seaglass"
+)
+
+const (
+	// defaultConcurrency is the number of repositories to list at once
+	// when the concurrency isn't set in the options
+	defaultConcurrency = 10
+
+	// pageBuffer is the number of pages that a worker can list ahead of
+	// the consumer before it waits
+	pageBuffer = 16
+)
+
+// Options are options for traversing repositories
+type Options struct {
+	// Recursive lists the manifests in every repository under the
+	// repository, as well as the repository itself
+	Recursive bool
+
+	// Concurrency is the maximum number of repositories to list at once
+	Concurrency int
+
+	// ContinueOnError carries on listing the other repositories after an
+	// error, rather than stopping at the first one
+	ContinueOnError bool
+
+	// RepositoryListOptions filter the repositories under the repository
+	// when listing recursively
+	RepositoryListOptions *seaglass.RepositoryListOptions
+
+	// ManifestListOptions are passed to the client when listing manifests
+	ManifestListOptions *seaglass.ManifestListOptions
+}
+
+// Page is a page of manifests from a repository
+type Page struct {
+	// Repository is the full name of the repository
+	Repository string
+
+	// Manifests are the manifests in the page. The same manifest may
+	// appear in more than one page of a repository.
+	Manifests []seaglass.Manifest
+}
+
+// RepositoryError is an error listing a repository
+type RepositoryError struct {
+	Repository string
+	Err        error
+}
+
+// Error returns the error message
+func (e *RepositoryError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Repository, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *RepositoryError) Unwrap() error {
+	return e.Err
+}
+
+type result struct {
+	page *Page
+	err  error
+}
+
+type job struct {
+	results chan result
+}
+
+// Manifests lists the manifests in the repository and, if recursive, every
+// repository under it.
+//
+// Repositories are listed concurrently, but the pages are yielded in the
+// order that the repositories were discovered in, with all the pages for one
+// repository before any of the next, so the output is deterministic.
+//
+// Errors are yielded as a *RepositoryError with a nil page. Unless
+// ContinueOnError is set, iteration stops after the first error.
+func Manifests(ctx context.Context, c seaglass.Client, repo string, opts *Options) iter.Seq2[*Page, error] {
+	if opts == nil {
+		opts = &Options{}
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+
+	return func(yield func(*Page, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		jobs := make(chan *job, concurrency)
+		go dispatch(ctx, c, repo, opts, concurrency, jobs)
+
+		for j := range jobs {
+			for r := range j.results {
+				if r.err != nil {
+					if !yield(nil, r.err) || !opts.ContinueOnError {
+						return
+					}
+					continue
+				}
+				if !yield(r.page, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// dispatch discovers the repositories and starts a worker for each of them,
+// sending the jobs in order. No more than concurrency workers run at once.
+func dispatch(ctx context.Context, c seaglass.Client, repo string, opts *Options, concurrency int, jobs chan<- *job) {
+	defer close(jobs)
+
+	repoOpts := &seaglass.RepositoryListOptions{}
+	if opts.RepositoryListOptions != nil {
+		repoOpts = ptr(*opts.RepositoryListOptions)
+	}
+	repoOpts.Recursive = opts.Recursive
+
+	sem := make(chan struct{}, concurrency)
+	for r, err := range Repositories(ctx, c, repo, repoOpts) {
+		if err != nil {
+			j := &job{results: make(chan result, 1)}
+			j.results <- result{err: &RepositoryError{Repository: repo, Err: err}}
+			close(j.results)
+
+			select {
+			case jobs <- j:
+			case <-ctx.Done():
+			}
+			return
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+
+		j := &job{results: make(chan result, pageBuffer)}
+		go func() {
+			defer func() { <-sem }()
+			defer close(j.results)
+
+			// When listing recursively, the repository at the top
+			// of the tree doesn't have to contain any manifests
+			// itself
+			ignoreNotFound := opts.Recursive && r == repo
+
+			list(ctx, c, r, opts.ManifestListOptions, ignoreNotFound, j.results)
+		}()
+
+		select {
+		case jobs <- j:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// list sends each page of manifests in the repository to results
+func list(ctx context.Context, c seaglass.Client, repo string, opts *seaglass.ManifestListOptions, ignoreNotFound bool, results chan<- result) {
+	for manifests, err := range c.ListManifestPages(ctx, repo, opts) {
+		r := result{}
+		if err != nil {
+			if ignoreNotFound && errors.Is(err, seaglass.ErrNotFound) {
+				return
+			}
+			r.err = &RepositoryError{Repository: repo, Err: err}
+		} else {
+			r.page = &Page{Repository: repo, Manifests: manifests.Manifests}
+		}
+
+		select {
+		case results <- r:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Repositories yields the repository and, if the options are recursive, all
+// of the repositories under it that match the options, as they're listed.
+//
+// The repository itself is only yielded if the options don't select
+// repositories by name.
+func Repositories(ctx context.Context, c seaglass.Client, repo string, opts *seaglass.RepositoryListOptions) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		f, err := seaglass.NewRepositoryFilter(opts)
+		if err != nil {
+			yield("", err)
+			return
+		}
+		if !f.Filters() && !yield(repo, nil) {
+			return
+		}
+		if opts == nil || !opts.Recursive {
+			return
+		}
+
+		for repoList, err := range c.ListRepositoryPages(ctx, repo, opts) {
+			if err != nil {
+				yield("", fmt.Errorf("listing repositories: %w", err))
+				return
+			}
+
+			for _, r := range repoList.Repositories {
+				name := r
+				if repoList.Name != "" {
+					name = fmt.Sprintf("%s/%s", repoList.Name, r)
+				}
+				if !yield(name, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,282 @@
+package traverse
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+)
+
+func TestManifests(t *testing.T) {
+	errBroken := errors.New("broken")
+
+	c := &fakeClient{
+		manifests: map[string][][]seaglass.Manifest{
+			"foo": nil,
+			"foo/bar": {
+				{{Digest: "sha256:aaaa", Tags: []string{"v1"}}},
+				{{Digest: "sha256:bbbb", Tags: []string{"v2"}}},
+			},
+			"foo/bar/baz": {
+				{{Digest: "sha256:cccc", Tags: []string{"latest"}}},
+			},
+			"foo/qux": {
+				{{Digest: "sha256:dddd", Tags: []string{"latest"}}},
+			},
+		},
+		errs: map[string]error{
+			"foo/broken": errBroken,
+		},
+		// Make the repositories that are listed first the slowest, so
+		// that they finish last
+		delays: map[string]time.Duration{
+			"foo/bar": 50 * time.Millisecond,
+		},
+	}
+
+	t.Run("recursive", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := c.withRepositories("bar", "bar/baz", "qux")
+
+		got, errs := collect(Manifests(ctx, c, "foo", &Options{Recursive: true, Concurrency: 4}))
+		if len(errs) > 0 {
+			t.Errorf("unexpected errors: %v", errs)
+		}
+
+		want := []string{
+			"foo/bar@sha256:aaaa",
+			"foo/bar@sha256:bbbb",
+			"foo/bar/baz@sha256:cccc",
+			"foo/qux@sha256:dddd",
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("recursive with filters", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := c.withRepositories("bar", "bar/baz", "qux")
+
+		got, errs := collect(Manifests(ctx, c, "foo", &Options{
+			Recursive: true,
+			RepositoryListOptions: &seaglass.RepositoryListOptions{
+				MaxDepth: 1,
+				Include:  []string{"*"},
+				Exclude:  []string{"qux"},
+			},
+		}))
+		if len(errs) > 0 {
+			t.Errorf("unexpected errors: %v", errs)
+		}
+
+		want := []string{
+			"foo/bar@sha256:aaaa",
+			"foo/bar@sha256:bbbb",
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("not recursive", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := c.withRepositories("bar", "bar/baz", "qux")
+
+		got, errs := collect(Manifests(ctx, c, "foo/bar", nil))
+		if len(errs) > 0 {
+			t.Errorf("unexpected errors: %v", errs)
+		}
+
+		want := []string{
+			"foo/bar@sha256:aaaa",
+			"foo/bar@sha256:bbbb",
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("stop on error", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := c.withRepositories("bar", "broken", "qux")
+
+		got, errs := collect(Manifests(ctx, c, "foo", &Options{Recursive: true, Concurrency: 4}))
+
+		want := []string{
+			"foo/bar@sha256:aaaa",
+			"foo/bar@sha256:bbbb",
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+
+		if len(errs) != 1 {
+			t.Fatalf("unexpected number of errors: %v", errs)
+		}
+		var rerr *RepositoryError
+		if !errors.As(errs[0], &rerr) || rerr.Repository != "foo/broken" || !errors.Is(rerr, errBroken) {
+			t.Errorf("unexpected error: %s", errs[0])
+		}
+	})
+
+	t.Run("continue on error", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := c.withRepositories("bar", "broken", "qux")
+
+		got, errs := collect(Manifests(ctx, c, "foo", &Options{Recursive: true, Concurrency: 4, ContinueOnError: true}))
+
+		want := []string{
+			"foo/bar@sha256:aaaa",
+			"foo/bar@sha256:bbbb",
+			"foo/qux@sha256:dddd",
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+
+		if len(errs) != 1 || !errors.Is(errs[0], errBroken) {
+			t.Errorf("unexpected errors: %v", errs)
+		}
+	})
+
+	t.Run("repository not found", func(t *testing.T) {
+		ctx := context.Background()
+
+		_, errs := collect(Manifests(ctx, c, "foo/missing", nil))
+		if len(errs) != 1 || !errors.Is(errs[0], seaglass.ErrNotFound) {
+			t.Errorf("unexpected errors: %v", errs)
+		}
+	})
+
+	t.Run("stop iterating early", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := c.withRepositories("bar", "bar/baz", "qux")
+
+		var got []string
+		for page, err := range Manifests(ctx, c, "foo", &Options{Recursive: true, Concurrency: 1}) {
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			got = append(got, page.Repository)
+			break
+		}
+
+		if diff := cmp.Diff([]string{"foo/bar"}, got); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+}
+
+// collect returns a repository@digest string for every manifest, in order,
+// and the errors
+func collect(pages iter.Seq2[*Page, error]) ([]string, []error) {
+	var (
+		refs []string
+		errs []error
+	)
+	for page, err := range pages {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, m := range page.Manifests {
+			refs = append(refs, page.Repository+"@"+m.Digest)
+		}
+	}
+
+	return refs, errs
+}
+
+// fakeClient is an in-memory implementation of seaglass.Client
+type fakeClient struct {
+	// repositories are the child repositories of "foo"
+	repositories []string
+
+	// manifests maps repositories to their pages of manifests
+	manifests map[string][][]seaglass.Manifest
+
+	// errs are returned when listing the manifests in a repository
+	errs map[string]error
+
+	// delays are applied before listing the manifests in a repository
+	delays map[string]time.Duration
+}
+
+func (c *fakeClient) withRepositories(repos ...string) *fakeClient {
+	cc := *c
+	cc.repositories = repos
+	return &cc
+}
+
+func (c *fakeClient) ListRepositories(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) (*seaglass.RepositoryList, error) {
+	return seaglass.CollectRepositories(repo, c.ListRepositoryPages(ctx, repo, opts))
+}
+
+func (c *fakeClient) ListRepositoryPages(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) iter.Seq2[*seaglass.RepositoryList, error] {
+	return seaglass.FilterRepositoryPages(c.listRepositoryPages(ctx, repo, opts), opts)
+}
+
+func (c *fakeClient) listRepositoryPages(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) iter.Seq2[*seaglass.RepositoryList, error] {
+	return func(yield func(*seaglass.RepositoryList, error) bool) {
+		// Yield one repository per page
+		for _, r := range c.repositories {
+			if !strings.HasPrefix("foo/"+r, repo+"/") {
+				continue
+			}
+			if !yield(&seaglass.RepositoryList{Name: repo, Repositories: []string{strings.TrimPrefix("foo/"+r, repo+"/")}}, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (c *fakeClient) ListManifests(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) (*seaglass.ManifestList, error) {
+	return seaglass.CollectManifests(c.ListManifestPages(ctx, repo, opts))
+}
+
+func (c *fakeClient) ListManifestPages(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) iter.Seq2[*seaglass.ManifestList, error] {
+	return func(yield func(*seaglass.ManifestList, error) bool) {
+		select {
+		case <-time.After(c.delays[repo]):
+		case <-ctx.Done():
+			yield(nil, ctx.Err())
+			return
+		}
+
+		if err, ok := c.errs[repo]; ok {
+			yield(nil, err)
+			return
+		}
+
+		pages, ok := c.manifests[repo]
+		if !ok {
+			yield(nil, seaglass.ErrNotFound)
+			return
+		}
+		for _, p := range pages {
+			if !yield(&seaglass.ManifestList{Manifests: p}, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (c *fakeClient) GetManifest(ctx context.Context, repo, ref string) (*seaglass.ManifestDetail, error) {
+	return nil, seaglass.ErrNotFound
+}
+
+func (c *fakeClient) ListReferrers(ctx context.Context, repo, digest, artifactType string) ([]seaglass.Referrer, error) {
+	return nil, nil
+}
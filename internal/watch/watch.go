@@ -0,0 +1,266 @@
+// Package watch polls repositories and emits events when their tags or
+// manifests change.
+package watch
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jetstack/seaglass/internal/diff"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+)
+
+// EventType is the type of change that an event describes
+type EventType string
+
+const (
+	// EventTagCreated is a tag that didn't exist in the previous poll
+	EventTagCreated EventType = "tag.created"
+
+	// EventTagMoved is a tag that points to a different manifest than it
+	// did in the previous poll
+	EventTagMoved EventType = "tag.moved"
+
+	// EventTagDeleted is a tag that existed in the previous poll, but
+	// doesn't anymore
+	EventTagDeleted EventType = "tag.deleted"
+
+	// EventManifestPushed is a manifest that didn't exist in the previous
+	// poll
+	EventManifestPushed EventType = "manifest.pushed"
+)
+
+// Event is a change to a repository
+type Event struct {
+	// Type is the type of change
+	Type EventType `json:"type"`
+
+	// Time is when the change was observed
+	Time time.Time `json:"time"`
+
+	// Repository is the full reference to the repository, including the
+	// registry host
+	Repository string `json:"repository"`
+
+	// Tag is the tag that changed, for tag events
+	Tag string `json:"tag,omitempty"`
+
+	// Digest is the digest of the manifest that was pushed or that the tag
+	// points to. It's the digest the tag pointed to for tag.deleted.
+	Digest string `json:"digest"`
+
+	// PreviousDigest is the digest the tag pointed to before, for
+	// tag.moved
+	PreviousDigest string `json:"previousDigest,omitempty"`
+
+	// MediaType is the media type of the manifest, for manifest.pushed
+	MediaType string `json:"mediaType,omitempty"`
+
+	// Tags are the tags of the manifest, for manifest.pushed
+	Tags []string `json:"tags,omitempty"`
+}
+
+// State is the manifests in each repository at a point in time, by the full
+// reference to the repository and then by digest
+type State map[string]map[string]seaglass.Manifest
+
+// Add adds the manifest in the repository, merging it with the manifest that
+// has the same digest if there is one
+func (s State) Add(repo string, manifest seaglass.Manifest) {
+	if s[repo] == nil {
+		s[repo] = map[string]seaglass.Manifest{}
+	}
+	if m, ok := s[repo][manifest.Digest]; ok {
+		m.Merge(manifest)
+		manifest = m
+	}
+	s[repo][manifest.Digest] = manifest
+}
+
+// tags returns the digest that each tag points to
+func (s State) tags() diff.Tags {
+	tags := diff.Tags{}
+	for repo, manifests := range s {
+		for _, manifest := range manifests {
+			tags.Add(repo, manifest)
+		}
+	}
+	return tags
+}
+
+// Events returns the events for the changes from prev to next. Pushed
+// manifests come first, sorted by repository and digest, and then the tag
+// events, sorted by repository and tag.
+func Events(prev, next State, now time.Time) []Event {
+	var events []Event
+	for repo, manifests := range next {
+		for digest, manifest := range manifests {
+			if _, ok := prev[repo][digest]; ok {
+				continue
+			}
+			tags := append([]string(nil), manifest.Tags...)
+			sort.Strings(tags)
+			events = append(events, Event{
+				Type:       EventManifestPushed,
+				Time:       now,
+				Repository: repo,
+				Digest:     digest,
+				MediaType:  manifest.MediaType,
+				Tags:       tags,
+			})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].Repository != events[j].Repository {
+			return events[i].Repository < events[j].Repository
+		}
+		return events[i].Digest < events[j].Digest
+	})
+
+	for _, change := range diff.Compare(prev.tags(), next.tags()) {
+		event := Event{
+			Time:       now,
+			Repository: change.Repository,
+			Tag:        change.Tag,
+		}
+		switch change.Change {
+		case diff.ChangeAdded:
+			event.Type = EventTagCreated
+			event.Digest = change.To
+		case diff.ChangeRemoved:
+			event.Type = EventTagDeleted
+			event.Digest = change.From
+		case diff.ChangeMoved:
+			event.Type = EventTagMoved
+			event.Digest = change.To
+			event.PreviousDigest = change.From
+		}
+		events = append(events, event)
+	}
+
+	return events
+}
+
+// Sink is where events are sent
+type Sink interface {
+	// Send sends the event
+	Send(ctx context.Context, event Event) error
+}
+
+// SinkFunc is a function that's a Sink
+type SinkFunc func(ctx context.Context, event Event) error
+
+// Send calls the function
+func (f SinkFunc) Send(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+// Options are options for watching
+type Options struct {
+	// Interval is how long to wait between polls
+	Interval time.Duration
+
+	// MaxBackoff is the longest to wait between polls after errors. The
+	// wait doubles from the interval with each consecutive error, up to
+	// this.
+	MaxBackoff time.Duration
+
+	// OnError is called with each error and how long it'll be until the
+	// next poll. The watcher keeps going after errors.
+	OnError func(err error, wait time.Duration)
+}
+
+// Watcher polls repositories and sends events for the changes between polls
+type Watcher struct {
+	poll func(ctx context.Context) (State, error)
+	sink Sink
+	opts Options
+
+	now   func() time.Time
+	after func(time.Duration) <-chan time.Time
+}
+
+// New returns a watcher that calls poll for the current state of the
+// repositories and sends the events to sink
+func New(poll func(ctx context.Context) (State, error), sink Sink, opts Options) *Watcher {
+	if opts.MaxBackoff < opts.Interval {
+		opts.MaxBackoff = opts.Interval
+	}
+
+	return &Watcher{
+		poll:  poll,
+		sink:  sink,
+		opts:  opts,
+		now:   time.Now,
+		after: time.After,
+	}
+}
+
+// Run polls until the context is cancelled. The first poll records the
+// state that later polls are compared to, so it doesn't send any events.
+//
+// The state only moves forward once every event for a poll has been sent, so
+// events that fail to send are sent again after the next poll, along with
+// the events sent before them in the same poll.
+func (w *Watcher) Run(ctx context.Context) error {
+	var (
+		state    State
+		failures int
+	)
+	for {
+		err := w.step(ctx, &state)
+		wait := w.opts.Interval
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			failures++
+			wait = w.backoff(failures)
+			if w.opts.OnError != nil {
+				w.opts.OnError(err, wait)
+			}
+		} else {
+			failures = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-w.after(wait):
+		}
+	}
+}
+
+// step polls once and sends the events for the changes since the state,
+// updating it if they're all sent
+func (w *Watcher) step(ctx context.Context, state *State) error {
+	next, err := w.poll(ctx)
+	if err != nil {
+		return fmt.Errorf("polling: %w", err)
+	}
+
+	if *state != nil {
+		for _, event := range Events(*state, next, w.now().UTC()) {
+			if err := w.sink.Send(ctx, event); err != nil {
+				return fmt.Errorf("sending %s event for %s: %w", event.Type, event.Repository, err)
+			}
+		}
+	}
+	*state = next
+
+	return nil
+}
+
+// backoff returns how long to wait after the number of consecutive failures
+func (w *Watcher) backoff(failures int) time.Duration {
+	wait := w.opts.Interval
+	for range failures {
+		wait *= 2
+		if wait >= w.opts.MaxBackoff || wait <= 0 {
+			return w.opts.MaxBackoff
+		}
+	}
+	return wait
+}
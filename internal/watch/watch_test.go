@@ -0,0 +1,181 @@
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+)
+
+func TestEvents(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	prev := State{}
+	prev.Add("ghcr.io/org/app", seaglass.Manifest{Digest: "sha256:aaaaaaa", Tags: []string{"latest", "v1.0.0"}})
+	prev.Add("ghcr.io/org/app", seaglass.Manifest{Digest: "sha256:bbbbbbb", Tags: []string{"v0.9.0"}})
+
+	next := State{}
+	next.Add("ghcr.io/org/app", seaglass.Manifest{Digest: "sha256:aaaaaaa", Tags: []string{"v1.0.0"}})
+	next.Add("ghcr.io/org/app", seaglass.Manifest{Digest: "sha256:ccccccc", MediaType: "application/vnd.oci.image.manifest.v1+json", Tags: []string{"v1.1.0"}})
+	next.Add("ghcr.io/org/app", seaglass.Manifest{Digest: "sha256:ccccccc", Tags: []string{"latest"}})
+	next.Add("ghcr.io/org/app", seaglass.Manifest{Digest: "sha256:ddddddd"})
+
+	want := []Event{
+		{Type: EventManifestPushed, Time: now, Repository: "ghcr.io/org/app", Digest: "sha256:ccccccc", MediaType: "application/vnd.oci.image.manifest.v1+json", Tags: []string{"latest", "v1.1.0"}},
+		{Type: EventManifestPushed, Time: now, Repository: "ghcr.io/org/app", Digest: "sha256:ddddddd"},
+		{Type: EventTagMoved, Time: now, Repository: "ghcr.io/org/app", Tag: "latest", Digest: "sha256:ccccccc", PreviousDigest: "sha256:aaaaaaa"},
+		{Type: EventTagDeleted, Time: now, Repository: "ghcr.io/org/app", Tag: "v0.9.0", Digest: "sha256:bbbbbbb"},
+		{Type: EventTagCreated, Time: now, Repository: "ghcr.io/org/app", Tag: "v1.1.0", Digest: "sha256:ccccccc"},
+	}
+	if diff := cmp.Diff(want, Events(prev, next, now)); diff != "" {
+		t.Errorf("unexpected events:\n%s", diff)
+	}
+
+	if events := Events(next, next, now); len(events) != 0 {
+		t.Errorf("unexpected events for an unchanged state: %v", events)
+	}
+}
+
+func TestWatcherRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	v1State := State{}
+	v1State.Add("ghcr.io/org/app", seaglass.Manifest{Digest: "sha256:aaaaaaa", Tags: []string{"latest"}})
+	v2State := State{}
+	v2State.Add("ghcr.io/org/app", seaglass.Manifest{Digest: "sha256:bbbbbbb", Tags: []string{"latest"}})
+
+	// Each poll returns the next result, and the watcher is stopped once
+	// they've all been returned
+	polls := []struct {
+		state State
+		err   error
+	}{
+		{err: errors.New("unavailable")},
+		{state: v1State},
+		{state: v1State},
+		{err: errors.New("unavailable")},
+		{err: errors.New("unavailable")},
+		{state: v2State},
+		// The events for v2 failed to send, so they're sent again
+		{state: v2State},
+		{state: v2State},
+	}
+	poll := func(ctx context.Context) (State, error) {
+		if len(polls) == 0 {
+			return nil, ctx.Err()
+		}
+		p := polls[0]
+		polls = polls[1:]
+		if len(polls) == 0 {
+			cancel()
+		}
+		return p.state, p.err
+	}
+
+	var (
+		sent    []EventType
+		sends   int
+		waits   []time.Duration
+		backoff []time.Duration
+	)
+	sink := SinkFunc(func(ctx context.Context, event Event) error {
+		sends++
+		if sends == 2 {
+			return errors.New("webhook unavailable")
+		}
+		sent = append(sent, event.Type)
+		return nil
+	})
+
+	w := New(poll, sink, Options{
+		Interval:   time.Minute,
+		MaxBackoff: 3 * time.Minute,
+		OnError: func(err error, wait time.Duration) {
+			backoff = append(backoff, wait)
+		},
+	})
+	w.after = func(d time.Duration) <-chan time.Time {
+		waits = append(waits, d)
+		ch := make(chan time.Time, 1)
+		ch <- time.Time{}
+		return ch
+	}
+
+	if err := w.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	wantSent := []EventType{
+		EventManifestPushed,
+		EventManifestPushed,
+		EventTagMoved,
+	}
+	if diff := cmp.Diff(wantSent, sent); diff != "" {
+		t.Errorf("unexpected events sent:\n%s", diff)
+	}
+
+	wantWaits := []time.Duration{
+		2 * time.Minute,
+		time.Minute,
+		time.Minute,
+		2 * time.Minute,
+		3 * time.Minute,
+		3 * time.Minute,
+		time.Minute,
+		time.Minute,
+	}
+	if diff := cmp.Diff(wantWaits, waits); diff != "" {
+		t.Errorf("unexpected waits:\n%s", diff)
+	}
+
+	wantBackoff := []time.Duration{2 * time.Minute, 2 * time.Minute, 3 * time.Minute, 3 * time.Minute}
+	if diff := cmp.Diff(wantBackoff, backoff); diff != "" {
+		t.Errorf("unexpected backoff:\n%s", diff)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	event := Event{
+		Type:       EventTagCreated,
+		Time:       time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Repository: "ghcr.io/org/app",
+		Tag:        "v1.0.0",
+		Digest:     "sha256:aaaaaaa",
+	}
+
+	var got Event
+	status := http.StatusNoContent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("unexpected method: %s", r.Method)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("unexpected content type: %s", ct)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decoding event: %s", err)
+		}
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	wh := NewWebhook(srv.URL, nil)
+	if err := wh.Send(context.Background(), event); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if diff := cmp.Diff(event, got); diff != "" {
+		t.Errorf("unexpected event posted:\n%s", diff)
+	}
+
+	status = http.StatusInternalServerError
+	if err := wh.Send(context.Background(), event); err == nil {
+		t.Errorf("expected error for %d response", status)
+	}
+}
@@ -0,0 +1,54 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Webhook is a sink that posts each event to a URL as JSON
+type Webhook struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhook returns a sink that posts events to the URL with the client, or
+// http.DefaultClient if it's nil
+func NewWebhook(url string, client *http.Client) *Webhook {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &Webhook{url: url, client: client}
+}
+
+// Send posts the event to the webhook. Any response other than a 2xx is an
+// error.
+func (w *Webhook) Send(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encoding event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("posting to webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response code: %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package seaglass
+
+import (
+	"context"
+	"errors"
+	"iter"
+)
+
+var (
+	// ErrNotFound is returned when a client can't find the requested resource
+	ErrNotFound = errors.New("not found")
+
+	// ErrNotSupported is returned when a client implementation doesn't
+	// support a given registry
+	ErrNotSupported = errors.New("not supported")
+)
+
+// Client is a client for an upstream registry
+type Client interface {
+	// ListRepositories lists the repositories relative to the
+	// specified repository.
+	ListRepositories(ctx context.Context, repo string, opts *RepositoryListOptions) (*RepositoryList, error)
+
+	// ListRepositoryPages lists the repositories relative to the
+	// specified repository, yielding each page of results as it's
+	// fetched from the registry.
+	//
+	// If there's an error, it's yielded with a nil page and iteration
+	// stops.
+	ListRepositoryPages(ctx context.Context, repo string, opts *RepositoryListOptions) iter.Seq2[*RepositoryList, error]
+
+	// ListManifests lists the manifests in the specified repository.
+	ListManifests(ctx context.Context, repo string, opts *ManifestListOptions) (*ManifestList, error)
+
+	// ListManifestPages lists the manifests in the specified repository,
+	// yielding each page of results as it's fetched from the registry.
+	//
+	// Registries that list content by tag may return the same manifest
+	// in more than one page, with a different subset of its tags in each.
+	// Use CollectManifests to merge them.
+	//
+	// If there's an error, it's yielded with a nil page and iteration
+	// stops.
+	ListManifestPages(ctx context.Context, repo string, opts *ManifestListOptions) iter.Seq2[*ManifestList, error]
+
+	// GetManifest fetches the manifest in the specified repository, by
+	// tag or digest, along with its config and the platforms of an index.
+	//
+	// Returns ErrNotFound if the manifest doesn't exist.
+	GetManifest(ctx context.Context, repo, ref string) (*ManifestDetail, error)
+
+	// ListReferrers lists the manifests that refer to the manifest with
+	// the digest, like signatures, SBOMs and attestations. If
+	// artifactType isn't empty, only referrers of that type are listed.
+	ListReferrers(ctx context.Context, repo, digest, artifactType string) ([]Referrer, error)
+}
+
+// Deleter is implemented by clients that can delete manifests and tags. Either
+// method returns ErrNotSupported if the registry doesn't support the operation.
+type Deleter interface {
+	// DeleteManifest deletes the manifest with the digest from the
+	// specified repository, along with the tags that point to it.
+	//
+	// Returns ErrNotFound if the manifest doesn't exist.
+	DeleteManifest(ctx context.Context, repo, digest string) error
+
+	// DeleteTag deletes the tag from the specified repository, leaving the
+	// manifest that it points to.
+	//
+	// Returns ErrNotFound if the tag doesn't exist.
+	DeleteTag(ctx context.Context, repo, tag string) error
+}
+
+// TagResolver is implemented by clients that can find the manifest that a tag
+// points to without listing the repository, like with a HEAD request to the v2
+// API. Returns ErrNotSupported if the registry doesn't support it.
+type TagResolver interface {
+	// ResolveTag returns the manifest that the tag points to in the
+	// specified repository. The tags of the manifest include the tag,
+	// but may not include its other tags.
+	//
+	// Returns ErrNotFound if the tag doesn't exist.
+	ResolveTag(ctx context.Context, repo, tag string) (*Manifest, error)
+}
+
+// ClientFactory constructs a client for the given host, configured by the
+// options. Returns ErrNotSupported if the client implementation doesn't support
+// the host.
+type ClientFactory func(host string, opts ...Option) (Client, error)
@@ -0,0 +1,419 @@
+package artifactory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/jetstack/seaglass/internal/inspect"
+	"github.com/jetstack/seaglass/internal/transport"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+)
+
+// pageSize is the number of repositories to request per page from the
+// catalog API
+const pageSize = 100
+
+// Client is a client for JFrog Artifactory
+type Client struct {
+	*inspect.Inspector
+
+	apiURL     *url.URL
+	httpClient *http.Client
+}
+
+// NewClient returns a new client for JFrog Cloud
+func NewClient(host string, opts ...seaglass.Option) (seaglass.Client, error) {
+	if !strings.HasSuffix(host, ".jfrog.io") {
+		return nil, seaglass.ErrNotSupported
+	}
+
+	return NewSelfHostedClient(host, opts...)
+}
+
+// NewSelfHostedClient returns a new client for an Artifactory instance running
+// on any host.
+//
+// Artifactory must be configured to use the repository path method for Docker
+// access, so that the first component of the repository is the Artifactory
+// repository key.
+func NewSelfHostedClient(host string, opts ...seaglass.Option) (seaglass.Client, error) {
+	registry, err := name.NewRegistry(host)
+	if err != nil {
+		return nil, fmt.Errorf("parsing host: %w", err)
+	}
+
+	// Artifactory accepts the same credentials for the API as it does for
+	// the registry
+	o := seaglass.NewClientOptions(opts...)
+	rt := o.Transport(0, 0)
+	httpClient := o.HTTPClient(transport.NewTransport(rt, o.Keychain(), registry))
+
+	inspector, err := inspect.New(registry, o.RemoteOptions(rt)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		Inspector: inspector,
+		apiURL: &url.URL{
+			Scheme: registry.Scheme(),
+			Host:   registry.RegistryStr(),
+			Path:   "/artifactory/api",
+		},
+		httpClient: httpClient,
+	}, nil
+}
+
+// ListRepositories lists the child repositories of the specified repository.
+//
+// At the root of the registry, this lists the Docker repositories in
+// Artifactory. Otherwise, it uses the catalog API of the Artifactory
+// repository.
+func (c *Client) ListRepositories(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) (*seaglass.RepositoryList, error) {
+	return seaglass.CollectRepositories(repo, c.ListRepositoryPages(ctx, repo, opts))
+}
+
+// ListRepositoryPages lists the child repositories of the specified
+// repository, yielding the matching repositories from each page of the
+// catalog.
+func (c *Client) ListRepositoryPages(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) iter.Seq2[*seaglass.RepositoryList, error] {
+	return seaglass.FilterRepositoryPages(c.listRepositoryPages(ctx, repo, opts), opts)
+}
+
+func (c *Client) listRepositoryPages(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) iter.Seq2[*seaglass.RepositoryList, error] {
+	return func(yield func(*seaglass.RepositoryList, error) bool) {
+		if repo == "" {
+			c.listRepositoryKeys(ctx, opts, yield)
+			return
+		}
+
+		repoKey, image := parseRepo(repo)
+
+		found := false
+		childMap := map[string]struct{}{}
+
+		query := url.Values{}
+		query.Set("n", fmt.Sprint(pageSize))
+		for {
+			var body struct {
+				Repositories []string `json:"repositories"`
+			}
+			if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/docker/%s/v2/_catalog", url.PathEscape(repoKey)), query, nil, &body); err != nil {
+				yield(nil, fmt.Errorf("listing repositories: %w", err))
+				return
+			}
+
+			var children []string
+			for _, r := range body.Repositories {
+				if r == image {
+					found = true
+					continue
+				}
+
+				prefix := fmt.Sprintf("%s/", image)
+				if image != "" && !strings.HasPrefix(r, prefix) {
+					continue
+				}
+				found = true
+
+				relativePath := strings.TrimPrefix(r, prefix)
+				if opts != nil && opts.Recursive {
+					children = append(children, relativePath)
+				} else {
+					child := strings.Split(relativePath, "/")[0]
+					if _, ok := childMap[child]; !ok {
+						children = append(children, child)
+					}
+					childMap[child] = struct{}{}
+				}
+			}
+
+			if len(children) > 0 && !yield(&seaglass.RepositoryList{Name: repo, Repositories: children}, nil) {
+				return
+			}
+
+			if len(body.Repositories) < pageSize {
+				break
+			}
+
+			query.Set("last", body.Repositories[len(body.Repositories)-1])
+		}
+
+		// The repository key is a valid repository to list from, even if
+		// it's empty
+		if !found && image != "" {
+			yield(nil, seaglass.ErrNotFound)
+		}
+	}
+}
+
+// listRepositoryKeys yields the keys of the Docker repositories in
+// Artifactory and, if recursive, the images in each of them
+func (c *Client) listRepositoryKeys(ctx context.Context, opts *seaglass.RepositoryListOptions, yield func(*seaglass.RepositoryList, error) bool) {
+	query := url.Values{}
+	query.Set("packageType", "docker")
+
+	var body []struct {
+		Key string `json:"key"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/repositories", query, nil, &body); err != nil {
+		yield(nil, fmt.Errorf("listing repositories: %w", err))
+		return
+	}
+
+	for _, r := range body {
+		if !yield(&seaglass.RepositoryList{Repositories: []string{r.Key}}, nil) {
+			return
+		}
+
+		if opts == nil || !opts.Recursive || opts.MaxDepth == 1 {
+			continue
+		}
+
+		for children, err := range c.listRepositoryPages(ctx, r.Key, opts) {
+			if err != nil {
+				yield(nil, fmt.Errorf("listing repositories for %s: %w", r.Key, err))
+				return
+			}
+
+			var repos []string
+			for _, child := range children.Repositories {
+				repos = append(repos, fmt.Sprintf("%s/%s", r.Key, child))
+			}
+			if !yield(&seaglass.RepositoryList{Repositories: repos}, nil) {
+				return
+			}
+		}
+	}
+}
+
+// ListManifests lists the manifests in the repository.
+//
+// Artifactory stores each tag as a folder that contains the manifest and the
+// layers of the image. This uses a single AQL query to find every file in the
+// tag folders of the image, which gives the digest and timestamps of the
+// manifest, when it was last downloaded and the total size of the image. The
+// media type of an image manifest comes from its docker.manifest.type
+// property, so manifests without that property don't match media type filters.
+func (c *Client) ListManifests(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) (*seaglass.ManifestList, error) {
+	return seaglass.CollectManifests(c.ListManifestPages(ctx, repo, opts))
+}
+
+// ListManifestPages lists the manifests in the repository. The AQL query
+// returns every result at once, so there is only ever one page.
+func (c *Client) ListManifestPages(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) iter.Seq2[*seaglass.ManifestList, error] {
+	return seaglass.FilterManifestPages(c.Resolve(ctx, repo, seaglass.GroupArtifactPages(c.listManifestPages(ctx, repo, opts), opts), opts), opts)
+}
+
+func (c *Client) listManifestPages(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) iter.Seq2[*seaglass.ManifestList, error] {
+	return func(yield func(*seaglass.ManifestList, error) bool) {
+		manifests, err := c.listManifests(ctx, repo)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		yield(&seaglass.ManifestList{Manifests: manifests}, nil)
+	}
+}
+
+func (c *Client) listManifests(ctx context.Context, repo string) ([]seaglass.Manifest, error) {
+	repoKey, image := parseRepo(repo)
+
+	// The repository key itself doesn't host any manifests
+	if image == "" {
+		return nil, nil
+	}
+
+	find, err := json.Marshal(map[string]any{
+		"repo": repoKey,
+		"path": map[string]string{"$match": fmt.Sprintf("%s/*", image)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("encoding query: %w", err)
+	}
+	aql := fmt.Sprintf(`items.find(%s).include("path","name","size","sha256","created","updated","stat.downloaded","property.key","property.value")`, find)
+
+	var body struct {
+		Results []struct {
+			Path    string    `json:"path"`
+			Name    string    `json:"name"`
+			Size    int64     `json:"size"`
+			SHA256  string    `json:"sha256"`
+			Created time.Time `json:"created"`
+			Updated time.Time `json:"updated"`
+			Stats   []struct {
+				Downloaded time.Time `json:"downloaded"`
+			} `json:"stats"`
+			Properties []struct {
+				Key   string `json:"key"`
+				Value string `json:"value"`
+			} `json:"properties"`
+		} `json:"results"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/search/aql", nil, strings.NewReader(aql), &body); err != nil {
+		return nil, fmt.Errorf("searching for manifests: %w", err)
+	}
+
+	// Index the manifests by tag folder first, so that the size of the
+	// layers can be added up
+	folders := map[string]*seaglass.Manifest{}
+	sizes := map[string]int64{}
+	for _, r := range body.Results {
+		tag := strings.TrimPrefix(r.Path, fmt.Sprintf("%s/", image))
+		if tag == r.Path || strings.Contains(tag, "/") {
+			continue
+		}
+		sizes[tag] += r.Size
+
+		var mediaType string
+		switch r.Name {
+		case "manifest.json":
+			for _, p := range r.Properties {
+				if p.Key == "docker.manifest.type" {
+					mediaType = manifestMediaType(p.Value)
+				}
+			}
+		case "list.manifest.json":
+			mediaType = "application/vnd.docker.distribution.manifest.list.v2+json"
+		default:
+			continue
+		}
+
+		manifest := &seaglass.Manifest{
+			Digest:    fmt.Sprintf("sha256:%s", r.SHA256),
+			MediaType: mediaType,
+		}
+		// Images that are pushed by digest are stored in folders
+		// named after the digest, rather than a tag
+		if !strings.HasPrefix(tag, "sha256__") {
+			manifest.Tags = []string{tag}
+		}
+		if !r.Created.IsZero() {
+			manifest.Uploaded = &r.Created
+		}
+		if !r.Updated.IsZero() {
+			manifest.Updated = &r.Updated
+		}
+		if len(r.Stats) > 0 && !r.Stats[0].Downloaded.IsZero() {
+			manifest.Pulled = &r.Stats[0].Downloaded
+		}
+		folders[tag] = manifest
+	}
+
+	if len(folders) == 0 {
+		if err := c.checkImage(ctx, repoKey, image); err != nil {
+			return nil, err
+		}
+	}
+
+	// Then merge the tags that point to the same manifest
+	manifestMap := map[string]*seaglass.Manifest{}
+	for tag, m := range folders {
+		m.Size = sizes[tag]
+
+		manifest, ok := manifestMap[m.Digest]
+		if !ok {
+			manifestMap[m.Digest] = m
+			continue
+		}
+		manifest.Tags = append(manifest.Tags, m.Tags...)
+		if manifest.Uploaded == nil || (m.Uploaded != nil && m.Uploaded.Before(*manifest.Uploaded)) {
+			manifest.Uploaded = m.Uploaded
+		}
+		if manifest.Updated == nil || (m.Updated != nil && m.Updated.After(*manifest.Updated)) {
+			manifest.Updated = m.Updated
+		}
+		if manifest.Pulled == nil || (m.Pulled != nil && m.Pulled.After(*manifest.Pulled)) {
+			manifest.Pulled = m.Pulled
+		}
+	}
+
+	var manifests []seaglass.Manifest
+	for _, manifest := range manifestMap {
+		sort.Strings(manifest.Tags)
+		manifests = append(manifests, *manifest)
+	}
+
+	sort.Slice(manifests, func(i, j int) bool {
+		return manifests[i].Digest < manifests[j].Digest
+	})
+
+	return manifests, nil
+}
+
+// checkImage returns ErrNotFound if the image doesn't exist in the repository
+func (c *Client) checkImage(ctx context.Context, repoKey, image string) error {
+	var body struct {
+		Tags []string `json:"tags"`
+	}
+
+	return c.do(ctx, http.MethodGet, fmt.Sprintf("/docker/%s/v2/%s/tags/list", url.PathEscape(repoKey), image), nil, nil, &body)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body io.Reader, v any) error {
+	u := c.apiURL.JoinPath(path)
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "text/plain")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return seaglass.ErrNotFound
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response code: %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decoding body: %w", err)
+	}
+
+	return nil
+}
+
+// manifestMediaType returns the media type of a manifest from the value of its
+// docker.manifest.type property. Artifactory records either the media type
+// itself or its own name for the Docker image manifest. Other values give an
+// empty media type.
+func manifestMediaType(value string) string {
+	switch {
+	case strings.Contains(value, "/"):
+		return value
+	case value == "ManifestV2":
+		return "application/vnd.docker.distribution.manifest.v2+json"
+	default:
+		return ""
+	}
+}
+
+func parseRepo(repo string) (repoKey, image string) {
+	parts := strings.SplitN(repo, "/", 2)
+	repoKey = parts[0]
+	if len(parts) > 1 {
+		image = parts[1]
+	}
+
+	return repoKey, image
+}
@@ -0,0 +1,307 @@
+package artifactory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+)
+
+func sortStrings(a, b string) bool {
+	return a < b
+}
+
+func TestClientListRepositories(t *testing.T) {
+	a := &fakeArtifactory{
+		repositories: map[string][]string{
+			"docker-local": {"foo/bar", "foo/bar/baz", "foo/baz", "qux"},
+		},
+	}
+
+	t.Run("listing a repository key", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, a)
+
+		gotList, err := c.ListRepositories(ctx, "docker-local", nil)
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		wantList := &seaglass.RepositoryList{
+			Name: "docker-local",
+			Repositories: []string{
+				"foo",
+				"qux",
+			},
+		}
+		if diff := cmp.Diff(wantList, gotList, cmpopts.SortSlices(sortStrings)); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("listing an image recursive", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, a)
+
+		gotList, err := c.ListRepositories(ctx, "docker-local/foo", &seaglass.RepositoryListOptions{Recursive: true})
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		wantList := &seaglass.RepositoryList{
+			Name: "docker-local/foo",
+			Repositories: []string{
+				"bar",
+				"bar/baz",
+				"baz",
+			},
+		}
+		if diff := cmp.Diff(wantList, gotList, cmpopts.SortSlices(sortStrings)); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("listing the root recursive", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, a)
+
+		gotList, err := c.ListRepositories(ctx, "", &seaglass.RepositoryListOptions{Recursive: true})
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		wantList := &seaglass.RepositoryList{
+			Repositories: []string{
+				"docker-local",
+				"docker-local/foo/bar",
+				"docker-local/foo/bar/baz",
+				"docker-local/foo/baz",
+				"docker-local/qux",
+			},
+		}
+		if diff := cmp.Diff(wantList, gotList, cmpopts.SortSlices(sortStrings)); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("listing an image that doesn't exist", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, a)
+
+		gotList, err := c.ListRepositories(ctx, "docker-local/bar", nil)
+		if !errors.Is(err, seaglass.ErrNotFound) {
+			t.Errorf("unexpected error: %s", err)
+		}
+		if gotList != nil {
+			t.Errorf("unexpected response: %v", gotList)
+		}
+	})
+}
+
+func TestClientListManifests(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	updated := time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC)
+	downloaded := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	a := &fakeArtifactory{
+		repositories: map[string][]string{
+			"docker-local": {"foo/bar", "foo/bar/baz"},
+		},
+		items: []item{
+			{Path: "foo/bar/v1", Name: "manifest.json", Size: 100, SHA256: "aaaa", Created: created, Updated: created, Downloaded: downloaded, ManifestType: "ManifestV2"},
+			{Path: "foo/bar/v1", Name: "sha256__1111", Size: 1000},
+			{Path: "foo/bar/latest", Name: "manifest.json", Size: 100, SHA256: "aaaa", Created: updated, Updated: updated, ManifestType: "ManifestV2"},
+			{Path: "foo/bar/v2", Name: "manifest.json", Size: 100, SHA256: "dddd", Created: created, Updated: created, ManifestType: "application/vnd.oci.image.manifest.v1+json"},
+			{Path: "foo/bar/v2", Name: "sha256__2222", Size: 500},
+			{Path: "foo/bar/latest", Name: "sha256__1111", Size: 1000},
+			{Path: "foo/bar/sha256__bbbb", Name: "list.manifest.json", Size: 200, SHA256: "bbbb", Created: created, Updated: created},
+			{Path: "foo/bar/baz/v1", Name: "manifest.json", Size: 100, SHA256: "cccc", Created: created, Updated: created},
+		},
+	}
+
+	t.Run("list manifests", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, a)
+
+		gotList, err := c.ListManifests(ctx, "docker-local/foo/bar", nil)
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		wantList := &seaglass.ManifestList{
+			Manifests: []seaglass.Manifest{
+				{
+					Digest:    "sha256:aaaa",
+					MediaType: "application/vnd.docker.distribution.manifest.v2+json",
+					Tags:      []string{"latest", "v1"},
+					Size:      1100,
+					Uploaded:  &created,
+					Updated:   &updated,
+					Pulled:    &downloaded,
+				},
+				{
+					Digest:    "sha256:bbbb",
+					MediaType: "application/vnd.docker.distribution.manifest.list.v2+json",
+					Size:      200,
+					Uploaded:  &created,
+					Updated:   &created,
+				},
+				{
+					Digest:    "sha256:dddd",
+					MediaType: "application/vnd.oci.image.manifest.v1+json",
+					Tags:      []string{"v2"},
+					Size:      600,
+					Uploaded:  &created,
+					Updated:   &created,
+				},
+			},
+		}
+		if diff := cmp.Diff(wantList, gotList); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("repository not found", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, a)
+
+		gotList, err := c.ListManifests(ctx, "docker-local/foo/qux", nil)
+		if !errors.Is(err, seaglass.ErrNotFound) {
+			t.Errorf("unexpected error: %s", err)
+		}
+		if gotList != nil {
+			t.Errorf("unexpected response: %v", gotList)
+		}
+	})
+}
+
+type item struct {
+	Path       string
+	Name       string
+	Size       int64
+	SHA256     string
+	Created    time.Time
+	Updated    time.Time
+	Downloaded time.Time
+
+	// ManifestType is the docker.manifest.type property of the item
+	ManifestType string
+}
+
+// fakeArtifactory is a fake implementation of the Artifactory API
+type fakeArtifactory struct {
+	// repositories maps repository keys to the images in them
+	repositories map[string][]string
+
+	// items are the files in the docker-local repository
+	items []item
+}
+
+func (a *fakeArtifactory) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/artifactory/api")
+
+	switch {
+	case path == "/repositories":
+		if r.URL.Query().Get("packageType") != "docker" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var repos []map[string]string
+		for key := range a.repositories {
+			repos = append(repos, map[string]string{"key": key})
+		}
+		json.NewEncoder(w).Encode(repos)
+	case strings.HasPrefix(path, "/docker/") && strings.HasSuffix(path, "/v2/_catalog"):
+		key := strings.TrimSuffix(strings.TrimPrefix(path, "/docker/"), "/v2/_catalog")
+		repos, ok := a.repositories[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string][]string{"repositories": repos})
+	case strings.HasPrefix(path, "/docker/docker-local/v2/") && strings.HasSuffix(path, "/tags/list"):
+		image := strings.TrimSuffix(strings.TrimPrefix(path, "/docker/docker-local/v2/"), "/tags/list")
+		for _, repo := range a.repositories["docker-local"] {
+			if repo == image {
+				json.NewEncoder(w).Encode(map[string][]string{"tags": {}})
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	case path == "/search/aql" && r.Method == http.MethodPost:
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		query := strings.TrimSuffix(strings.TrimPrefix(string(b), "items.find("), `).include("path","name","size","sha256","created","updated","stat.downloaded","property.key","property.value")`)
+		var find struct {
+			Repo string `json:"repo"`
+			Path struct {
+				Match string `json:"$match"`
+			} `json:"path"`
+		}
+		if err := json.Unmarshal([]byte(query), &find); err != nil || find.Repo != "docker-local" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var results []map[string]any
+		for _, i := range a.items {
+			if !strings.HasPrefix(i.Path, strings.TrimSuffix(find.Path.Match, "*")) {
+				continue
+			}
+			result := map[string]any{
+				"path":    i.Path,
+				"name":    i.Name,
+				"size":    i.Size,
+				"sha256":  i.SHA256,
+				"created": i.Created,
+				"updated": i.Updated,
+			}
+			if !i.Downloaded.IsZero() {
+				result["stats"] = []map[string]any{{"downloaded": i.Downloaded}}
+			}
+			if i.ManifestType != "" {
+				result["properties"] = []map[string]any{{"key": "docker.manifest.type", "value": i.ManifestType}}
+			}
+			results = append(results, result)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"results": results})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func setupClient(t *testing.T, a *fakeArtifactory) seaglass.Client {
+	srv := httptest.NewServer(a)
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error parsing server url: %s", err)
+	}
+
+	c, err := NewSelfHostedClient(u.Host)
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %s", err)
+	}
+
+	return c
+}
@@ -0,0 +1,305 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/jetstack/seaglass/internal/inspect"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+)
+
+// Client is a client for Azure Container Registry
+type Client struct {
+	*inspect.Inspector
+
+	registry name.Registry
+	kc       authn.Keychain
+	rt       http.RoundTripper
+}
+
+// NewClient returns a new client for an Azure Container Registry
+func NewClient(host string, opts ...seaglass.Option) (seaglass.Client, error) {
+	if !isAzureHost(host) {
+		return nil, seaglass.ErrNotSupported
+	}
+
+	registry, err := name.NewRegistry(host)
+	if err != nil {
+		return nil, fmt.Errorf("parsing host: %w", err)
+	}
+
+	o := seaglass.NewClientOptions(opts...)
+	rt := o.Transport(0, 0)
+
+	inspector, err := inspect.New(registry, o.RemoteOptions(rt)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		Inspector: inspector,
+		registry:  registry,
+		kc:        o.Keychain(),
+		rt:        rt,
+	}, nil
+}
+
+// ListRepositories lists the child repositories of the specified repository.
+// This uses the ACR catalog API, which lists every repository in the registry.
+func (c *Client) ListRepositories(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) (*seaglass.RepositoryList, error) {
+	return seaglass.CollectRepositories(repo, c.ListRepositoryPages(ctx, repo, opts))
+}
+
+// ListRepositoryPages lists the child repositories of the specified
+// repository, yielding the matching repositories from each page of the
+// catalog.
+func (c *Client) ListRepositoryPages(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) iter.Seq2[*seaglass.RepositoryList, error] {
+	return seaglass.FilterRepositoryPages(c.listRepositoryPages(ctx, repo, opts), opts)
+}
+
+func (c *Client) listRepositoryPages(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) iter.Seq2[*seaglass.RepositoryList, error] {
+	return func(yield func(*seaglass.RepositoryList, error) bool) {
+		httpClient, err := c.httpClient(ctx, "registry:catalog:*")
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		found := false
+		childMap := map[string]struct{}{}
+
+		next := c.url("/acr/v1/_catalog").String()
+		for next != "" {
+			var body struct {
+				Repositories []string `json:"repositories"`
+			}
+			next, err = c.get(ctx, httpClient, next, &body)
+			if err != nil {
+				yield(nil, fmt.Errorf("listing repositories: %w", err))
+				return
+			}
+
+			var children []string
+			for _, r := range body.Repositories {
+				if r == repo {
+					found = true
+				}
+				prefix := fmt.Sprintf("%s/", repo)
+				if strings.HasPrefix(r, prefix) {
+					found = true
+					relativePath := strings.TrimPrefix(r, prefix)
+					if opts != nil && opts.Recursive {
+						children = append(children, relativePath)
+					} else {
+						child := strings.Split(relativePath, "/")[0]
+						if _, ok := childMap[child]; !ok {
+							children = append(children, child)
+						}
+						childMap[child] = struct{}{}
+					}
+				}
+			}
+
+			if len(children) == 0 {
+				continue
+			}
+
+			if !yield(&seaglass.RepositoryList{Name: repo, Repositories: children}, nil) {
+				return
+			}
+		}
+
+		if !found {
+			yield(nil, seaglass.ErrNotFound)
+		}
+	}
+}
+
+// ListManifests lists the manifests in the repository. The ACR manifests API
+// returns the tags, timestamps and platform of every manifest in bulk.
+//
+// ACR doesn't report the created time from the image config, so Created is
+// never set.
+func (c *Client) ListManifests(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) (*seaglass.ManifestList, error) {
+	return seaglass.CollectManifests(c.ListManifestPages(ctx, repo, opts))
+}
+
+// ListManifestPages lists the manifests in the repository, yielding each page
+// of the ACR manifests API.
+func (c *Client) ListManifestPages(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) iter.Seq2[*seaglass.ManifestList, error] {
+	return seaglass.FilterManifestPages(c.Resolve(ctx, repo, seaglass.GroupArtifactPages(c.listManifestPages(ctx, repo, opts), opts), opts), opts)
+}
+
+func (c *Client) listManifestPages(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) iter.Seq2[*seaglass.ManifestList, error] {
+	return func(yield func(*seaglass.ManifestList, error) bool) {
+		httpClient, err := c.httpClient(ctx, fmt.Sprintf("repository:%s:metadata_read", repo))
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		next := c.url(fmt.Sprintf("/acr/v1/%s/_manifests", repo)).String()
+		for next != "" {
+			var body struct {
+				Manifests []struct {
+					Digest         string    `json:"digest"`
+					MediaType      string    `json:"mediaType"`
+					ImageSize      int64     `json:"imageSize"`
+					CreatedTime    time.Time `json:"createdTime"`
+					LastUpdateTime time.Time `json:"lastUpdateTime"`
+					Architecture   string    `json:"architecture"`
+					OS             string    `json:"os"`
+					Tags           []string  `json:"tags"`
+				} `json:"manifests"`
+			}
+			next, err = c.get(ctx, httpClient, next, &body)
+			if err != nil {
+				yield(nil, fmt.Errorf("listing manifests: %w", err))
+				return
+			}
+
+			var manifests []seaglass.Manifest
+			for _, m := range body.Manifests {
+				if m.Digest == "" {
+					continue
+				}
+
+				// The createdTime reported by ACR is when the
+				// manifest was created in the registry, rather
+				// than the created time from the image config, so
+				// it's the upload time. The created time from the
+				// image config isn't listed at all.
+				manifest := seaglass.Manifest{
+					Digest:    m.Digest,
+					MediaType: m.MediaType,
+					Tags:      m.Tags,
+					Size:      m.ImageSize,
+				}
+				// ACR reports the platform of images, so they
+				// don't have to be resolved separately
+				if m.OS != "" {
+					manifest.Platform = &seaglass.Platform{
+						OS:           m.OS,
+						Architecture: m.Architecture,
+					}
+				}
+				if !m.CreatedTime.IsZero() {
+					manifest.Uploaded = &m.CreatedTime
+				}
+				if !m.LastUpdateTime.IsZero() {
+					manifest.Updated = &m.LastUpdateTime
+				}
+
+				manifests = append(manifests, manifest)
+			}
+
+			if !yield(&seaglass.ManifestList{Manifests: manifests}, nil) {
+				return
+			}
+		}
+	}
+}
+
+// httpClient returns a http.Client that authenticates with an ACR access
+// token for the given scope.
+//
+// Credentials for the registry are fetched from the keychain. The transport
+// handles exchanging them with the registry's token service, including
+// exchanging an ACR refresh token (as returned by `az acr login` or the ACR
+// credential helper) for an access token.
+func (c *Client) httpClient(ctx context.Context, scope string) (*http.Client, error) {
+	auth, err := c.kc.Resolve(c.registry)
+	if err != nil {
+		return nil, fmt.Errorf("resolving keychain: %w", err)
+	}
+
+	rt, err := transport.NewWithContext(ctx, c.registry, auth, c.rt, []string{scope})
+	if err != nil {
+		return nil, fmt.Errorf("creating transport: %w", err)
+	}
+
+	return &http.Client{Transport: rt}, nil
+}
+
+// get fetches the url and decodes the response into v. Returns the url of the
+// next page of results, if there is one.
+func (c *Client) get(ctx context.Context, httpClient *http.Client, u string, v any) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", seaglass.ErrNotFound
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected response code: %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return "", fmt.Errorf("decoding body: %w", err)
+	}
+
+	return c.next(resp)
+}
+
+// next parses the url of the next page from the Link header, which takes the
+// form `</acr/v1/_catalog?last=foo&n=100>; rel="next"`
+func (c *Client) next(resp *http.Response) (string, error) {
+	link := resp.Header.Get("Link")
+	if link == "" {
+		return "", nil
+	}
+
+	start, end := strings.Index(link, "<"), strings.Index(link, ">")
+	if start < 0 || end < start {
+		return "", fmt.Errorf("parsing link header: %s", link)
+	}
+
+	u, err := url.Parse(link[start+1 : end])
+	if err != nil {
+		return "", fmt.Errorf("parsing link header: %w", err)
+	}
+
+	return resp.Request.URL.ResolveReference(u).String(), nil
+}
+
+func (c *Client) url(path string) *url.URL {
+	return &url.URL{
+		Scheme: c.registry.Scheme(),
+		Host:   c.registry.RegistryStr(),
+		Path:   path,
+	}
+}
+
+func isAzureHost(host string) bool {
+	if strings.HasSuffix(host, ".azurecr.io") {
+		return true
+	}
+
+	if strings.HasSuffix(host, ".azurecr.cn") {
+		return true
+	}
+
+	if strings.HasSuffix(host, ".azurecr.us") {
+		return true
+	}
+
+	return false
+}
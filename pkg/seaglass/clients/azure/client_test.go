@@ -0,0 +1,267 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+)
+
+func sortStrings(a, b string) bool {
+	return a < b
+}
+
+func sortManifests(a, b seaglass.Manifest) bool {
+	return a.Digest < b.Digest
+}
+
+func TestClientListRepositories(t *testing.T) {
+	repositories := []string{"foo/bar", "foo/bar/baz", "foo/baz", "foo/baz/bar/foo", "qux"}
+
+	t.Run("listing a top-level repository", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, repositories, nil)
+
+		gotList, err := c.ListRepositories(ctx, "foo", nil)
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		wantList := &seaglass.RepositoryList{
+			Name: "foo",
+			Repositories: []string{
+				"bar",
+				"baz",
+			},
+		}
+		if diff := cmp.Diff(wantList, gotList, cmpopts.SortSlices(sortStrings)); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("listing a top-level repository recursive", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, repositories, nil)
+
+		gotList, err := c.ListRepositories(ctx, "foo", &seaglass.RepositoryListOptions{Recursive: true})
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		wantList := &seaglass.RepositoryList{
+			Name: "foo",
+			Repositories: []string{
+				"bar",
+				"bar/baz",
+				"baz",
+				"baz/bar/foo",
+			},
+		}
+		if diff := cmp.Diff(wantList, gotList, cmpopts.SortSlices(sortStrings)); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("listing a sub-repository that doesn't exist", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, repositories, nil)
+
+		gotList, err := c.ListRepositories(ctx, "foo/bar/baz/qux", nil)
+		if !errors.Is(err, seaglass.ErrNotFound) {
+			t.Errorf("unexpected error: %s", err)
+		}
+		if gotList != nil {
+			t.Errorf("unexpected response: %v", gotList)
+		}
+	})
+}
+
+func TestClientListManifests(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	updated := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	manifests := map[string][]manifestAttributes{
+		"foo/bar": {
+			{
+				Digest:         "sha256:aaaa",
+				MediaType:      "application/vnd.oci.image.manifest.v1+json",
+				ImageSize:      1024,
+				CreatedTime:    created,
+				LastUpdateTime: updated,
+				Architecture:   "arm64",
+				OS:             "linux",
+				Tags:           []string{"latest", "v1"},
+			},
+			{
+				Digest:         "sha256:bbbb",
+				MediaType:      "application/vnd.oci.image.index.v1+json",
+				ImageSize:      512,
+				CreatedTime:    created,
+				LastUpdateTime: created,
+			},
+		},
+	}
+
+	t.Run("list manifests", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, nil, manifests)
+
+		gotList, err := c.ListManifests(ctx, "foo/bar", nil)
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		wantList := &seaglass.ManifestList{
+			Manifests: []seaglass.Manifest{
+				{
+					Digest:    "sha256:aaaa",
+					MediaType: "application/vnd.oci.image.manifest.v1+json",
+					Tags:      []string{"latest", "v1"},
+					Size:      1024,
+					Uploaded:  &created,
+					Updated:   &updated,
+					Platform: &seaglass.Platform{
+						OS:           "linux",
+						Architecture: "arm64",
+					},
+				},
+				{
+					Digest:    "sha256:bbbb",
+					MediaType: "application/vnd.oci.image.index.v1+json",
+					Size:      512,
+					Uploaded:  &created,
+					Updated:   &created,
+				},
+			},
+		}
+		if diff := cmp.Diff(wantList, gotList, cmpopts.SortSlices(sortManifests)); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("repository not found", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, nil, manifests)
+
+		gotList, err := c.ListManifests(ctx, "foo/baz", nil)
+		if !errors.Is(err, seaglass.ErrNotFound) {
+			t.Errorf("unexpected error: %s", err)
+		}
+		if gotList != nil {
+			t.Errorf("unexpected response: %v", gotList)
+		}
+	})
+}
+
+type manifestAttributes struct {
+	Digest         string    `json:"digest"`
+	MediaType      string    `json:"mediaType"`
+	ImageSize      int64     `json:"imageSize"`
+	CreatedTime    time.Time `json:"createdTime"`
+	LastUpdateTime time.Time `json:"lastUpdateTime"`
+	Architecture   string    `json:"architecture,omitempty"`
+	OS             string    `json:"os,omitempty"`
+	Tags           []string  `json:"tags,omitempty"`
+}
+
+type refreshTokenKeychain string
+
+func (k refreshTokenKeychain) Resolve(authn.Resource) (authn.Authenticator, error) {
+	return authn.FromConfig(authn.AuthConfig{
+		IdentityToken: string(k),
+	}), nil
+}
+
+// setupClient returns a client that talks to a fake implementation of the ACR
+// data-plane API. The server only accepts access tokens that have been
+// exchanged for the refresh token returned by the client's keychain. Results
+// are served one item per page to exercise pagination.
+func setupClient(t *testing.T, repositories []string, manifests map[string][]manifestAttributes) *Client {
+	const (
+		refreshToken = "refresh-token"
+		accessToken  = "access-token"
+	)
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/oauth2/token" {
+			if err := r.ParseForm(); err != nil {
+				t.Errorf("unexpected error parsing form: %s", err)
+			}
+			if r.Form.Get("grant_type") != "refresh_token" || r.Form.Get("refresh_token") != refreshToken {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"access_token": accessToken})
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Bearer "+accessToken {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/oauth2/token",service="%s"`, srv.URL, r.Host))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		page := func(items int) int {
+			last := 0
+			fmt.Sscan(r.URL.Query().Get("last"), &last)
+			if last+1 < items {
+				w.Header().Set("Link", fmt.Sprintf(`<%s?last=%d&n=1>; rel="next"`, r.URL.Path, last+1))
+			}
+			return last
+		}
+
+		switch {
+		case r.URL.Path == "/v2/":
+			w.WriteHeader(http.StatusOK)
+		case r.URL.Path == "/acr/v1/_catalog":
+			i := page(len(repositories))
+			json.NewEncoder(w).Encode(map[string][]string{"repositories": repositories[i : i+1]})
+		case strings.HasPrefix(r.URL.Path, "/acr/v1/") && strings.HasSuffix(r.URL.Path, "/_manifests"):
+			repo := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/acr/v1/"), "/_manifests")
+			ms, ok := manifests[repo]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			i := page(len(ms))
+			json.NewEncoder(w).Encode(map[string][]manifestAttributes{"manifests": ms[i : i+1]})
+		default:
+			t.Errorf("unexpected request: %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error parsing server url: %s", err)
+	}
+	registry, err := name.NewRegistry(u.Host)
+	if err != nil {
+		t.Fatalf("unexpected error parsing registry: %s", err)
+	}
+
+	return &Client{
+		registry: registry,
+		kc:       refreshTokenKeychain(refreshToken),
+		rt:       http.DefaultTransport,
+	}
+}
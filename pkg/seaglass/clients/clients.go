@@ -0,0 +1,103 @@
+// Package clients constructs the client for a registry host, from the
+// implementations in its subpackages.
+package clients
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+	"github.com/jetstack/seaglass/pkg/seaglass/clients/artifactory"
+	"github.com/jetstack/seaglass/pkg/seaglass/clients/azure"
+	"github.com/jetstack/seaglass/pkg/seaglass/clients/dockerhub"
+	"github.com/jetstack/seaglass/pkg/seaglass/clients/ecr"
+	"github.com/jetstack/seaglass/pkg/seaglass/clients/github"
+	"github.com/jetstack/seaglass/pkg/seaglass/clients/gitlab"
+	"github.com/jetstack/seaglass/pkg/seaglass/clients/google"
+	"github.com/jetstack/seaglass/pkg/seaglass/clients/harbor"
+	"github.com/jetstack/seaglass/pkg/seaglass/clients/nexus"
+	"github.com/jetstack/seaglass/pkg/seaglass/clients/quay"
+	"github.com/jetstack/seaglass/pkg/seaglass/clients/registry"
+)
+
+var clientFactories = []seaglass.ClientFactory{
+	google.NewClient,
+	github.NewClient,
+	gitlab.NewClient,
+	dockerhub.NewClient,
+	ecr.NewClient,
+	azure.NewClient,
+	quay.NewClient,
+	artifactory.NewClient,
+
+	// Factories that probe the host must come after the factories that
+	// only match on hostname, so that we don't make requests for hosts
+	// we already know about
+	harbor.NewClient,
+}
+
+// clientTypes are the clients that can be explicitly selected for a host with
+// NewClientOfType. This allows clients for registries that can run on any
+// host to be used when they can't be detected from the hostname.
+var clientTypes = map[string]seaglass.ClientFactory{
+	"artifactory": artifactory.NewSelfHostedClient,
+	"gitlab":      gitlab.NewSelfHostedClient,
+	"harbor":      harbor.NewSelfHostedClient,
+	"nexus":       nexus.NewSelfHostedClient,
+	"quay":        quay.NewSelfHostedClient,
+	"registry":    registry.NewClient,
+}
+
+// ClientTypes returns the names of the clients that can be passed to
+// NewClientOfType
+func ClientTypes() []string {
+	var types []string
+	for t := range clientTypes {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	return types
+}
+
+// NewClientOfType returns a client of the named type for the provided host,
+// regardless of whether the client would be selected for the host by
+// NewClient
+func NewClientOfType(host, clientType string, opts ...seaglass.Option) (seaglass.Client, error) {
+	if _, err := name.NewRegistry(host); err != nil {
+		return nil, fmt.Errorf("parsing registry host: %w", err)
+	}
+
+	factory, ok := clientTypes[clientType]
+	if !ok {
+		return nil, fmt.Errorf("unknown client type: %s", clientType)
+	}
+
+	return factory(host, opts...)
+}
+
+// NewClient returns a client for the provided host. The most specific client
+// for the registry is chosen from the hostname, or by probing the host for
+// registries that can run on any host, falling back to a client for the v2
+// registry API.
+func NewClient(host string, opts ...seaglass.Option) (seaglass.Client, error) {
+	if _, err := name.NewRegistry(host); err != nil {
+		return nil, fmt.Errorf("parsing registry host: %w", err)
+	}
+
+	for _, factory := range clientFactories {
+		client, err := factory(host, opts...)
+		if errors.Is(err, seaglass.ErrNotSupported) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("factory error: %w", err)
+		}
+
+		return client, nil
+	}
+
+	return registry.NewClient(host, opts...)
+}
@@ -0,0 +1,492 @@
+package dockerhub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/jetstack/seaglass/internal/inspect"
+	"github.com/jetstack/seaglass/internal/transport"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+	"golang.org/x/time/rate"
+)
+
+// Client is a client for images hosted in DockerHub
+type Client struct {
+	*inspect.Inspector
+
+	hubURL     *url.URL
+	httpClient *http.Client
+}
+
+// NewClient returns a new client for DockerHub
+func NewClient(host string, opts ...seaglass.Option) (seaglass.Client, error) {
+	if !isDockerHost(host) {
+		return nil, seaglass.ErrNotSupported
+	}
+
+	hubURL, err := url.Parse("https://registry.hub.docker.com")
+	if err != nil {
+		return nil, fmt.Errorf("parsing url: %w", err)
+	}
+
+	// Use the credentials for index.docker.io for the DockerHub API
+	registry, err := name.NewRegistry("index.docker.io")
+	if err != nil {
+		return nil, fmt.Errorf("parsing registry: %w", err)
+	}
+	o := seaglass.NewClientOptions(opts...)
+
+	// The Hub API is rate limited separately from the registry
+	rt := o.Transport(rate.Every(1*time.Second), 15)
+	httpClient := o.HTTPClient(transport.NewTransport(rt, o.Keychain(), registry))
+
+	inspector, err := inspect.New(registry, o.RemoteOptions(o.Transport(0, 0))...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		Inspector:  inspector,
+		hubURL:     hubURL,
+		httpClient: httpClient,
+	}, nil
+}
+
+// ListRepositories lists repositories
+func (c *Client) ListRepositories(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) (*seaglass.RepositoryList, error) {
+	return seaglass.CollectRepositories(repo, c.ListRepositoryPages(ctx, repo, opts))
+}
+
+// ListRepositoryPages lists repositories, yielding each page of repositories in
+// the namespace
+func (c *Client) ListRepositoryPages(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) iter.Seq2[*seaglass.RepositoryList, error] {
+	return seaglass.FilterRepositoryPages(c.listRepositoryPages(ctx, repo, opts), opts)
+}
+
+func (c *Client) listRepositoryPages(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) iter.Seq2[*seaglass.RepositoryList, error] {
+	return func(yield func(*seaglass.RepositoryList, error) bool) {
+		parts := strings.Split(repo, "/")
+		if len(parts) > 2 {
+			yield(nil, seaglass.ErrNotFound)
+			return
+		}
+		if len(parts) > 1 {
+			if err := c.checkRepository(ctx, parts[0], parts[1]); err != nil {
+				yield(nil, err)
+			}
+			return
+		}
+		namespace := parts[0]
+
+		u := c.hubURL.JoinPath(fmt.Sprintf("/v2/namespaces/%s/repositories", namespace))
+
+		// The name parameter only lists repositories that contain the
+		// string. The repositories are still matched against the
+		// options once they've been listed.
+		if name := opts.NameSubstring(); name != "" {
+			u.RawQuery = url.Values{"name": {name}}.Encode()
+		}
+
+		next := u.String()
+		for {
+			results, n, err := c.listRepositories(ctx, next)
+			if err != nil {
+				yield(nil, fmt.Errorf("listing repositories: %w", err))
+				return
+			}
+
+			if !yield(&seaglass.RepositoryList{Name: repo, Repositories: results}, nil) {
+				return
+			}
+
+			if n == "" {
+				break
+			}
+
+			next = n
+		}
+	}
+}
+
+func (c *Client) listRepositories(ctx context.Context, next string) ([]string, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, next, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("listing repositories: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("unexpected response code: %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Next    string `json:"next"`
+		Results []struct {
+			Name string `json:"name"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, "", fmt.Errorf("decoding body: %w", err)
+	}
+
+	var repos []string
+	for _, r := range body.Results {
+		repos = append(repos, r.Name)
+	}
+
+	return repos, body.Next, nil
+}
+
+// ListManifests lists manifests
+func (c *Client) ListManifests(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) (*seaglass.ManifestList, error) {
+	return seaglass.CollectManifests(c.ListManifestPages(ctx, repo, opts))
+}
+
+// ListManifestPages lists manifests, yielding the manifests from each page of
+// tags
+func (c *Client) ListManifestPages(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) iter.Seq2[*seaglass.ManifestList, error] {
+	return seaglass.FilterManifestPages(c.Resolve(ctx, repo, seaglass.GroupArtifactPages(c.listManifestPages(ctx, repo, opts), opts), opts), opts)
+}
+
+func (c *Client) listManifestPages(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) iter.Seq2[*seaglass.ManifestList, error] {
+	return func(yield func(*seaglass.ManifestList, error) bool) {
+		parts := strings.Split(repo, "/")
+		if len(parts) == 1 {
+			return
+		}
+		if len(parts) != 2 {
+			yield(nil, seaglass.ErrNotFound)
+			return
+		}
+		namespace := parts[0]
+		repository := parts[1]
+
+		u := c.hubURL.JoinPath(fmt.Sprintf("/v2/namespaces/%s/repositories/%s/tags", namespace, repository))
+
+		// The name parameter only lists tags that contain the string.
+		// The tags are still matched against the patterns once they've
+		// been listed. The tags of artifacts don't have to match when
+		// they're hidden, so they're searched for separately.
+		names := []string{""}
+		if name := opts.TagSubstring(); name != "" {
+			names = []string{name}
+			if opts.HideArtifacts {
+				names = append(names, "sha256-")
+			}
+		}
+
+		for _, name := range names {
+			next := u.String()
+			if name != "" {
+				next = fmt.Sprintf("%s?%s", next, url.Values{"name": {name}}.Encode())
+			}
+
+			for next != "" {
+				manifests, n, err := c.listManifests(ctx, next)
+				if err != nil {
+					yield(nil, err)
+					return
+				}
+
+				if !yield(&seaglass.ManifestList{Manifests: manifests}, nil) {
+					return
+				}
+
+				next = n
+			}
+		}
+	}
+}
+
+func (c *Client) listManifests(ctx context.Context, next string) ([]seaglass.Manifest, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, next, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("listing repositories: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("unexpected response code: %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Next    string `json:"next"`
+		Results []struct {
+			Name        string    `json:"name"`
+			Digest      string    `json:"digest"`
+			LastUpdated time.Time `json:"last_updated"`
+			Images      []struct {
+				Digest       string    `json:"digest"`
+				Architecture string    `json:"architecture"`
+				OS           string    `json:"os"`
+				Variant      string    `json:"variant"`
+				LastPushed   time.Time `json:"last_pushed"`
+			} `json:"images"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, "", fmt.Errorf("decoding body: %w", err)
+	}
+
+	manifestMap := map[string]*seaglass.Manifest{}
+
+	for _, r := range body.Results {
+		if r.Digest != "" {
+			if _, ok := manifestMap[r.Digest]; !ok {
+				manifestMap[r.Digest] = &seaglass.Manifest{
+					Digest: r.Digest,
+					Tags: []string{
+						r.Name,
+					},
+				}
+				if !r.LastUpdated.IsZero() {
+					manifestMap[r.Digest].Updated = &r.LastUpdated
+				}
+			} else {
+				manifestMap[r.Digest].Tags = append(manifestMap[r.Digest].Tags, r.Name)
+				if manifestMap[r.Digest].Updated == nil {
+					if !r.LastUpdated.IsZero() {
+						manifestMap[r.Digest].Updated = &r.LastUpdated
+					}
+				} else if r.LastUpdated.After(*manifestMap[r.Digest].Updated) {
+					manifestMap[r.Digest].Updated = &r.LastUpdated
+				}
+			}
+		}
+
+		// The images of a tag are the manifests in the index that it
+		// points to or, for a single image, the image itself. Either
+		// way, Docker Hub reports their platforms, so they don't have
+		// to be resolved separately.
+		var children []seaglass.Descriptor
+		for _, img := range r.Images {
+			if img.Digest == "" {
+				continue
+			}
+			var platform *seaglass.Platform
+			if img.OS != "" {
+				platform = &seaglass.Platform{
+					OS:           img.OS,
+					Architecture: img.Architecture,
+					Variant:      img.Variant,
+				}
+			}
+			if img.Digest != r.Digest {
+				children = append(children, seaglass.Descriptor{
+					Digest:   img.Digest,
+					Platform: platform,
+				})
+			}
+			if _, ok := manifestMap[img.Digest]; !ok {
+				manifestMap[img.Digest] = &seaglass.Manifest{
+					Digest:   img.Digest,
+					Platform: platform,
+				}
+				if !img.LastPushed.IsZero() {
+					manifestMap[img.Digest].Updated = &img.LastPushed
+				}
+			} else {
+				if manifestMap[img.Digest].Updated == nil {
+					if !img.LastPushed.IsZero() {
+						manifestMap[img.Digest].Updated = &img.LastPushed
+					}
+				} else if img.LastPushed.After(*manifestMap[img.Digest].Updated) {
+					manifestMap[img.Digest].Updated = &img.LastPushed
+				}
+				if manifestMap[img.Digest].Platform == nil {
+					manifestMap[img.Digest].Platform = platform
+				}
+			}
+		}
+		if m, ok := manifestMap[r.Digest]; ok && len(children) > 0 && m.Manifests == nil {
+			m.Manifests = children
+		}
+	}
+
+	var manifests []seaglass.Manifest
+	for _, manifest := range manifestMap {
+		manifests = append(manifests, *manifest)
+	}
+
+	return manifests, body.Next, nil
+}
+
+// DeleteManifest deletes the tags that point to the manifest. The Hub API
+// deletes content by tag, so a manifest without tags can't be deleted and
+// Docker Hub removes the untagged manifest itself.
+func (c *Client) DeleteManifest(ctx context.Context, repo, digest string) error {
+	found := false
+	var tags []string
+	for page, err := range c.listManifestPages(ctx, repo, nil) {
+		if err != nil {
+			return fmt.Errorf("listing tags: %w", err)
+		}
+		for _, manifest := range page.Manifests {
+			if manifest.Digest == digest {
+				found = true
+				tags = append(tags, manifest.Tags...)
+			}
+		}
+	}
+	if !found {
+		return seaglass.ErrNotFound
+	}
+	if len(tags) == 0 {
+		return fmt.Errorf("deleting untagged manifest %s: %w", digest, seaglass.ErrNotSupported)
+	}
+
+	for _, tag := range tags {
+		if err := c.DeleteTag(ctx, repo, tag); err != nil && !errors.Is(err, seaglass.ErrNotFound) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DeleteTag deletes the tag with the Hub API
+func (c *Client) DeleteTag(ctx context.Context, repo, tag string) error {
+	parts := strings.Split(repo, "/")
+	if len(parts) != 2 {
+		return seaglass.ErrNotFound
+	}
+
+	u := c.hubURL.JoinPath(fmt.Sprintf("/v2/repositories/%s/%s/tags/%s/", parts[0], parts[1], tag)).String()
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, u, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("deleting tag: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return seaglass.ErrNotFound
+	}
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response code: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// ResolveTag returns the manifest that the tag points to with the Hub API,
+// which doesn't count towards the pull rate limits of the registry
+func (c *Client) ResolveTag(ctx context.Context, repo, tag string) (*seaglass.Manifest, error) {
+	parts := strings.Split(repo, "/")
+	if len(parts) != 2 {
+		return nil, seaglass.ErrNotFound
+	}
+
+	u := c.hubURL.JoinPath(fmt.Sprintf("/v2/namespaces/%s/repositories/%s/tags/%s", parts[0], parts[1], tag)).String()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching tag: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, seaglass.ErrNotFound
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response code: %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Digest      string    `json:"digest"`
+		MediaType   string    `json:"media_type"`
+		LastUpdated time.Time `json:"last_updated"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decoding body: %w", err)
+	}
+
+	// Old tags may not have a digest in the Hub API, but the registry
+	// still has one
+	if body.Digest == "" {
+		return c.Inspector.ResolveTag(ctx, repo, tag)
+	}
+
+	manifest := &seaglass.Manifest{
+		Digest:    body.Digest,
+		MediaType: body.MediaType,
+		Tags:      []string{tag},
+	}
+	if !body.LastUpdated.IsZero() {
+		manifest.Updated = &body.LastUpdated
+	}
+
+	return manifest, nil
+}
+
+func (c *Client) checkRepository(ctx context.Context, namespace, repo string) error {
+	u := c.hubURL.JoinPath(fmt.Sprintf("/v2/namespaces/%s/repositories/%s", namespace, repo)).String()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return seaglass.ErrNotFound
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func isDockerHost(host string) bool {
+	if host == "docker.io" {
+		return true
+	}
+
+	if strings.HasSuffix(host, ".docker.io") {
+		return true
+	}
+
+	if host == "docker.com" {
+		return true
+	}
+
+	if strings.HasSuffix(host, ".docker.com") {
+		return true
+	}
+
+	return false
+}
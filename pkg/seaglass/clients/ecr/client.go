@@ -0,0 +1,219 @@
+package ecr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+	"github.com/aws/aws-sdk-go-v2/service/ecr/types"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/jetstack/seaglass/internal/inspect"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+)
+
+// ecrHostPattern matches the hostname of a private ECR registry and captures
+// the registry (account) ID and the region.
+var ecrHostPattern = regexp.MustCompile(`^(\d{12})\.dkr\.ecr(?:-fips)?\.([a-z0-9-]+)\.amazonaws\.com(?:\.cn)?$`)
+
+// API implements the methods of ecr.Client that we use
+type API interface {
+	ecr.DescribeRepositoriesAPIClient
+	ecr.DescribeImagesAPIClient
+}
+
+// Client is a client for AWS Elastic Container Registry
+type Client struct {
+	*inspect.Inspector
+
+	registryID string
+	api        API
+}
+
+// NewClient returns a new client for an AWS Elastic Container Registry
+func NewClient(host string, opts ...seaglass.Option) (seaglass.Client, error) {
+	registryID, region, ok := parseHost(host)
+	if !ok {
+		return nil, seaglass.ErrNotSupported
+	}
+
+	// Credentials are resolved from the default AWS credential chain,
+	// which is the same chain that the ECR credential helper uses to pull
+	// from the registry
+	cfg, err := config.LoadDefaultConfig(context.Background(), config.WithRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("loading aws config: %w", err)
+	}
+
+	registry, err := name.NewRegistry(host)
+	if err != nil {
+		return nil, fmt.Errorf("parsing host: %w", err)
+	}
+
+	// Requests to the ECR API are made with the transport from the
+	// options too. Unless the options set a HTTP client, it's based on
+	// the AWS SDK's own transport, which has the CA bundle from the AWS
+	// config.
+	if bc, ok := cfg.HTTPClient.(*awshttp.BuildableClient); ok {
+		opts = append([]seaglass.Option{seaglass.WithHTTPClient(&http.Client{Transport: bc.GetTransport()})}, opts...)
+	}
+	o := seaglass.NewClientOptions(opts...)
+	rt := o.Transport(0, 0)
+
+	inspector, err := inspect.New(registry, o.RemoteOptions(rt)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		Inspector:  inspector,
+		registryID: registryID,
+		api: ecr.NewFromConfig(cfg, func(eo *ecr.Options) {
+			eo.HTTPClient = o.HTTPClient(rt)
+		}),
+	}, nil
+}
+
+// ListRepositories lists the child repositories of the specified repository.
+//
+// ECR repositories are flat, so this lists every repository in the registry
+// and returns the ones that are nested under the specified repository.
+func (c *Client) ListRepositories(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) (*seaglass.RepositoryList, error) {
+	return seaglass.CollectRepositories(repo, c.ListRepositoryPages(ctx, repo, opts))
+}
+
+// ListRepositoryPages lists the child repositories of the specified
+// repository, yielding the matching repositories from each page of
+// DescribeRepositories.
+func (c *Client) ListRepositoryPages(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) iter.Seq2[*seaglass.RepositoryList, error] {
+	return seaglass.FilterRepositoryPages(c.listRepositoryPages(ctx, repo, opts), opts)
+}
+
+func (c *Client) listRepositoryPages(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) iter.Seq2[*seaglass.RepositoryList, error] {
+	return func(yield func(*seaglass.RepositoryList, error) bool) {
+		found := false
+		childMap := map[string]struct{}{}
+
+		p := ecr.NewDescribeRepositoriesPaginator(c.api, &ecr.DescribeRepositoriesInput{
+			RegistryId: aws.String(c.registryID),
+		})
+		for p.HasMorePages() {
+			page, err := p.NextPage(ctx)
+			if err != nil {
+				yield(nil, fmt.Errorf("describing repositories: %w", err))
+				return
+			}
+
+			var children []string
+			for _, r := range page.Repositories {
+				name := aws.ToString(r.RepositoryName)
+				if name == repo {
+					found = true
+					continue
+				}
+
+				prefix := fmt.Sprintf("%s/", repo)
+				if repo != "" && !strings.HasPrefix(name, prefix) {
+					continue
+				}
+				found = true
+
+				relativePath := strings.TrimPrefix(name, prefix)
+				if opts != nil && opts.Recursive {
+					children = append(children, relativePath)
+				} else {
+					child := strings.Split(relativePath, "/")[0]
+					if _, ok := childMap[child]; !ok {
+						children = append(children, child)
+					}
+					childMap[child] = struct{}{}
+				}
+			}
+
+			if len(children) == 0 {
+				continue
+			}
+
+			if !yield(&seaglass.RepositoryList{Name: repo, Repositories: children}, nil) {
+				return
+			}
+		}
+
+		if !found {
+			yield(nil, seaglass.ErrNotFound)
+		}
+	}
+}
+
+// ListManifests lists the manifests in the repository. The tags, push time,
+// size and media type of every image are returned by DescribeImages, so this
+// doesn't need to make any requests to the registry API.
+func (c *Client) ListManifests(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) (*seaglass.ManifestList, error) {
+	return seaglass.CollectManifests(c.ListManifestPages(ctx, repo, opts))
+}
+
+// ListManifestPages lists the manifests in the repository, yielding each page
+// of DescribeImages.
+func (c *Client) ListManifestPages(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) iter.Seq2[*seaglass.ManifestList, error] {
+	return seaglass.FilterManifestPages(c.Resolve(ctx, repo, seaglass.GroupArtifactPages(c.listManifestPages(ctx, repo, opts), opts), opts), opts)
+}
+
+func (c *Client) listManifestPages(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) iter.Seq2[*seaglass.ManifestList, error] {
+	return func(yield func(*seaglass.ManifestList, error) bool) {
+		p := ecr.NewDescribeImagesPaginator(c.api, &ecr.DescribeImagesInput{
+			RegistryId:     aws.String(c.registryID),
+			RepositoryName: aws.String(repo),
+		})
+		for p.HasMorePages() {
+			page, err := p.NextPage(ctx)
+			if err != nil {
+				var notFound *types.RepositoryNotFoundException
+				if errors.As(err, &notFound) {
+					yield(nil, seaglass.ErrNotFound)
+					return
+				}
+				yield(nil, fmt.Errorf("describing images: %w", err))
+				return
+			}
+
+			var manifests []seaglass.Manifest
+			for _, img := range page.ImageDetails {
+				if aws.ToString(img.ImageDigest) == "" {
+					continue
+				}
+
+				tags := img.ImageTags
+				sort.Strings(tags)
+
+				manifests = append(manifests, seaglass.Manifest{
+					Digest:    aws.ToString(img.ImageDigest),
+					MediaType: aws.ToString(img.ImageManifestMediaType),
+					Tags:      tags,
+					Size:      aws.ToInt64(img.ImageSizeInBytes),
+					Uploaded:  img.ImagePushedAt,
+				})
+			}
+
+			if !yield(&seaglass.ManifestList{Manifests: manifests}, nil) {
+				return
+			}
+		}
+	}
+}
+
+func parseHost(host string) (registryID, region string, ok bool) {
+	m := ecrHostPattern.FindStringSubmatch(host)
+	if m == nil {
+		return "", "", false
+	}
+
+	return m[1], m[2], true
+}
@@ -0,0 +1,316 @@
+package ecr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+)
+
+func sortStrings(a, b string) bool {
+	return a < b
+}
+
+func sortManifests(a, b seaglass.Manifest) bool {
+	return a.Digest < b.Digest
+}
+
+func TestNewClient(t *testing.T) {
+	for host, supported := range map[string]bool{
+		"123456789012.dkr.ecr.eu-west-1.amazonaws.com":      true,
+		"123456789012.dkr.ecr-fips.us-east-1.amazonaws.com": true,
+		"123456789012.dkr.ecr.cn-north-1.amazonaws.com.cn":  true,
+		"public.ecr.aws":                  false,
+		"dkr.ecr.eu-west-1.amazonaws.com": false,
+		"ghcr.io":                         false,
+	} {
+		t.Run(host, func(t *testing.T) {
+			_, err := NewClient(host)
+			if supported && err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+			if !supported && !errors.Is(err, seaglass.ErrNotSupported) {
+				t.Errorf("expected ErrNotSupported, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestNewClientOptions(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "access-key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret-key")
+
+	// Requests to the ECR API are made with the HTTP client and user
+	// agent from the options
+	var userAgents []string
+	httpClient := &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			userAgents = append(userAgents, r.Header.Get("User-Agent"))
+
+			rec := httptest.NewRecorder()
+			rec.Header().Set("Content-Type", "application/x-amz-json-1.1")
+			json.NewEncoder(rec).Encode(map[string][]map[string]string{
+				"repositories": {{"repositoryName": "foo/bar"}},
+			})
+			return rec.Result(), nil
+		}),
+	}
+
+	c, err := NewClient("123456789012.dkr.ecr.eu-west-1.amazonaws.com", seaglass.WithHTTPClient(httpClient), seaglass.WithUserAgent("seaglass-test"))
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %s", err)
+	}
+
+	gotList, err := c.ListRepositories(context.Background(), "foo", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if diff := cmp.Diff(&seaglass.RepositoryList{Name: "foo", Repositories: []string{"bar"}}, gotList); diff != "" {
+		t.Errorf("unexpected result:\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"seaglass-test"}, userAgents); diff != "" {
+		t.Errorf("unexpected user agents:\n%s", diff)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestClientListRepositories(t *testing.T) {
+	repositories := [][]string{
+		{"foo/bar", "foo/bar/baz"},
+		{"foo/baz", "foo/baz/bar/foo", "qux"},
+	}
+
+	t.Run("listing a top-level repository", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, repositories, nil)
+
+		gotList, err := c.ListRepositories(ctx, "foo", nil)
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		wantList := &seaglass.RepositoryList{
+			Name: "foo",
+			Repositories: []string{
+				"bar",
+				"baz",
+			},
+		}
+		if diff := cmp.Diff(wantList, gotList, cmpopts.SortSlices(sortStrings)); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("listing a top-level repository recursive", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, repositories, nil)
+
+		gotList, err := c.ListRepositories(ctx, "foo", &seaglass.RepositoryListOptions{Recursive: true})
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		wantList := &seaglass.RepositoryList{
+			Name: "foo",
+			Repositories: []string{
+				"bar",
+				"bar/baz",
+				"baz",
+				"baz/bar/foo",
+			},
+		}
+		if diff := cmp.Diff(wantList, gotList, cmpopts.SortSlices(sortStrings)); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("listing a sub-repository that doesn't exist", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, repositories, nil)
+
+		gotList, err := c.ListRepositories(ctx, "foo/bar/baz/qux", nil)
+		if !errors.Is(err, seaglass.ErrNotFound) {
+			t.Errorf("unexpected error: %s", err)
+		}
+		if gotList != nil {
+			t.Errorf("unexpected response: %v", gotList)
+		}
+	})
+}
+
+func TestClientListManifests(t *testing.T) {
+	pushedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	images := map[string][][]imageDetail{
+		"foo/bar": {
+			{
+				{
+					ImageDigest:            "sha256:aaaa",
+					ImageTags:              []string{"v1", "latest"},
+					ImagePushedAt:          float64(pushedAt.Unix()),
+					ImageSizeInBytes:       1024,
+					ImageManifestMediaType: "application/vnd.oci.image.manifest.v1+json",
+				},
+			},
+			{
+				{
+					ImageDigest:            "sha256:bbbb",
+					ImagePushedAt:          float64(pushedAt.Unix()),
+					ImageSizeInBytes:       2048,
+					ImageManifestMediaType: "application/vnd.docker.distribution.manifest.v2+json",
+				},
+			},
+		},
+	}
+
+	t.Run("list manifests", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, nil, images)
+
+		gotList, err := c.ListManifests(ctx, "foo/bar", nil)
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		wantList := &seaglass.ManifestList{
+			Manifests: []seaglass.Manifest{
+				{
+					Digest:    "sha256:aaaa",
+					MediaType: "application/vnd.oci.image.manifest.v1+json",
+					Tags:      []string{"latest", "v1"},
+					Size:      1024,
+					Uploaded:  &pushedAt,
+				},
+				{
+					Digest:    "sha256:bbbb",
+					MediaType: "application/vnd.docker.distribution.manifest.v2+json",
+					Size:      2048,
+					Uploaded:  &pushedAt,
+				},
+			},
+		}
+		if diff := cmp.Diff(wantList, gotList, cmpopts.SortSlices(sortManifests)); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("repository not found", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, nil, images)
+
+		gotList, err := c.ListManifests(ctx, "foo/baz", nil)
+		if !errors.Is(err, seaglass.ErrNotFound) {
+			t.Errorf("unexpected error: %s", err)
+		}
+		if gotList != nil {
+			t.Errorf("unexpected response: %v", gotList)
+		}
+	})
+}
+
+type imageDetail struct {
+	ImageDigest            string   `json:"imageDigest"`
+	ImageTags              []string `json:"imageTags,omitempty"`
+	ImagePushedAt          float64  `json:"imagePushedAt"`
+	ImageSizeInBytes       int64    `json:"imageSizeInBytes"`
+	ImageManifestMediaType string   `json:"imageManifestMediaType"`
+}
+
+// setupClient returns a client that talks to a fake implementation of the
+// ECR JSON API. The repositories and images are served one page at a time, in
+// the order provided.
+func setupClient(t *testing.T, repositories [][]string, images map[string][][]imageDetail) *Client {
+	const registryID = "123456789012"
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			RegistryID     string `json:"registryId"`
+			RepositoryName string `json:"repositoryName"`
+			NextToken      int    `json:"nextToken,string"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("unexpected error decoding request: %s", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if body.RegistryID != registryID {
+			t.Errorf("unexpected registry id: %s", body.RegistryID)
+		}
+
+		nextToken := func(pages int) string {
+			if body.NextToken+1 < pages {
+				return fmt.Sprint(body.NextToken + 1)
+			}
+			return ""
+		}
+
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+
+		switch r.Header.Get("X-Amz-Target") {
+		case "AmazonEC2ContainerRegistry_V20150921.DescribeRepositories":
+			var resp struct {
+				Repositories []map[string]string `json:"repositories"`
+				NextToken    string              `json:"nextToken,omitempty"`
+			}
+			if len(repositories) > 0 {
+				for _, name := range repositories[body.NextToken] {
+					resp.Repositories = append(resp.Repositories, map[string]string{"repositoryName": name})
+				}
+				resp.NextToken = nextToken(len(repositories))
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "AmazonEC2ContainerRegistry_V20150921.DescribeImages":
+			pages, ok := images[body.RepositoryName]
+			if !ok {
+				w.Header().Set("X-Amzn-ErrorType", "RepositoryNotFoundException")
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{
+					"__type":  "RepositoryNotFoundException",
+					"message": "repository not found",
+				})
+				return
+			}
+			var resp struct {
+				ImageDetails []imageDetail `json:"imageDetails"`
+				NextToken    string        `json:"nextToken,omitempty"`
+			}
+			resp.ImageDetails = pages[body.NextToken]
+			resp.NextToken = nextToken(len(pages))
+			json.NewEncoder(w).Encode(resp)
+		default:
+			t.Errorf("unexpected target: %s", r.Header.Get("X-Amz-Target"))
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return &Client{
+		registryID: registryID,
+		api: ecr.New(ecr.Options{
+			Region:       "eu-west-1",
+			BaseEndpoint: aws.String(srv.URL),
+			Credentials:  credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+		}),
+	}
+}
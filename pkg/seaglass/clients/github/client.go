@@ -0,0 +1,357 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	githubauthn "github.com/google/go-containerregistry/pkg/authn/github"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-github/v56/github"
+	"github.com/jetstack/seaglass/internal/inspect"
+	"github.com/jetstack/seaglass/internal/transport"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+)
+
+//go:generate mockery --name OrganizationsService --log-level error
+//go:generate mockery --name UsersService --log-level error
+
+// OrganizationsService implements the methods of github.OrganizationsService
+// that we use
+type OrganizationsService interface {
+	PackageGetAllVersions(ctx context.Context, org, packageType, packageName string, opts *github.PackageListOptions) ([]*github.PackageVersion, *github.Response, error)
+	ListPackages(ctx context.Context, org string, opts *github.PackageListOptions) ([]*github.Package, *github.Response, error)
+	PackageDeleteVersion(ctx context.Context, org, packageType, packageName string, packageVersionID int64) (*github.Response, error)
+}
+
+// UsersService implements the methods of github.UsersService that we use
+type UsersService interface {
+	Get(ctx context.Context, user string) (*github.User, *github.Response, error)
+	PackageGetAllVersions(ctx context.Context, org, packageType, packageName string, opts *github.PackageListOptions) ([]*github.PackageVersion, *github.Response, error)
+	ListPackages(ctx context.Context, org string, opts *github.PackageListOptions) ([]*github.Package, *github.Response, error)
+	PackageDeleteVersion(ctx context.Context, user, packageType, packageName string, packageVersionID int64) (*github.Response, error)
+}
+
+// Client is a client for GitHub Container Registry
+type Client struct {
+	*inspect.Inspector
+
+	orgs  OrganizationsService
+	users UsersService
+}
+
+// NewClient returns a new client for GitHub Container Registry
+func NewClient(host string, opts ...seaglass.Option) (seaglass.Client, error) {
+	if host != "ghcr.io" {
+		return nil, seaglass.ErrNotSupported
+	}
+
+	reg, err := name.NewRegistry("ghcr.io")
+	if err != nil {
+		return nil, fmt.Errorf("parsing registry: %w", err)
+	}
+
+	// Configure the GitHub client with a custom http client that uses
+	// credentials from the registry keychain for ghcr.io to authenticate
+	// to GitHub.
+	//
+	// This should hopefully be a more seamless experience for users because
+	// they (hopefully) don't need additional config, beyond what they'd
+	// already need for pulling from ghcr.io.
+	o := seaglass.NewClientOptions(opts...)
+	rt := o.Transport(0, 0)
+	kc := authn.NewMultiKeychain(
+		o.Keychain(),
+		githubauthn.Keychain,
+	)
+	c := github.NewClient(o.HTTPClient(transport.NewTransport(rt, kc, reg)))
+
+	inspector, err := inspect.New(reg, remote.WithAuthFromKeychain(kc), remote.WithTransport(rt))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		Inspector: inspector,
+		orgs:      c.Organizations,
+		users:     c.Users,
+	}, nil
+}
+
+// ListRepositories lists repositories
+func (c *Client) ListRepositories(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) (*seaglass.RepositoryList, error) {
+	return seaglass.CollectRepositories(repo, c.ListRepositoryPages(ctx, repo, opts))
+}
+
+// ListRepositoryPages lists repositories, yielding the repositories from each
+// page of packages
+func (c *Client) ListRepositoryPages(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) iter.Seq2[*seaglass.RepositoryList, error] {
+	return seaglass.FilterRepositoryPages(c.listRepositoryPages(ctx, repo, opts), opts)
+}
+
+func (c *Client) listRepositoryPages(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) iter.Seq2[*seaglass.RepositoryList, error] {
+	return func(yield func(*seaglass.RepositoryList, error) bool) {
+		// Split the repsitory reference to get the organization/user and the
+		// package name
+		orgOrUser, pkgName := parseRepo(repo)
+
+		// Pick the right list packages function, depending on whether the
+		// repository belongs to an organization or a user
+		listPackages := c.orgs.ListPackages
+		isUser, err := c.isUser(ctx, orgOrUser)
+		if err != nil {
+			yield(nil, fmt.Errorf("checking if entity is a user or organization: %w", err))
+			return
+		}
+		if isUser {
+			listPackages = c.users.ListPackages
+		}
+
+		childMap := map[string]struct{}{}
+
+		// List all the container type packages under the org/user
+		listOpts := &github.PackageListOptions{
+			PackageType: github.String("container"),
+			State:       github.String("active"),
+		}
+		for {
+			packages, resp, err := listPackages(ctx, orgOrUser, listOpts)
+			if err != nil {
+				yield(nil, fmt.Errorf("listing packages: %w", err))
+				return
+			}
+
+			var repos []string
+			for _, pkg := range packages {
+				if pkg == nil {
+					continue
+				}
+				if pkg.GetName() == "" {
+					continue
+				}
+				if pkg.GetName() == pkgName {
+					continue
+				}
+
+				prefix := fmt.Sprintf("%s/", pkgName)
+				if pkgName == "" || strings.HasPrefix(pkg.GetName(), prefix) {
+					relativePath := strings.TrimPrefix(pkg.GetName(), prefix)
+					if opts != nil && opts.Recursive {
+						repos = append(repos, relativePath)
+					} else {
+						child := strings.Split(strings.TrimPrefix(pkg.GetName(), prefix), "/")[0]
+						if _, ok := childMap[child]; !ok {
+							repos = append(repos, child)
+						}
+						childMap[child] = struct{}{}
+					}
+
+				}
+			}
+
+			if len(repos) > 0 {
+				if !yield(&seaglass.RepositoryList{Name: repo, Repositories: repos}, nil) {
+					return
+				}
+			}
+
+			if resp.NextPage < 1 {
+				break
+			}
+
+			listOpts.Page = resp.NextPage
+		}
+	}
+}
+
+// ListManifests lists manifests
+func (c *Client) ListManifests(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) (*seaglass.ManifestList, error) {
+	return seaglass.CollectManifests(c.ListManifestPages(ctx, repo, opts))
+}
+
+// ListManifestPages lists manifests, yielding the manifests from each page of
+// package versions
+func (c *Client) ListManifestPages(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) iter.Seq2[*seaglass.ManifestList, error] {
+	return seaglass.FilterManifestPages(c.Resolve(ctx, repo, seaglass.GroupArtifactPages(c.listManifestPages(ctx, repo, opts), opts), opts), opts)
+}
+
+func (c *Client) listManifestPages(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) iter.Seq2[*seaglass.ManifestList, error] {
+	return func(yield func(*seaglass.ManifestList, error) bool) {
+		// Split the repsitory reference to get the organization/user and the
+		// package name
+		orgOrUser, pkgName := parseRepo(repo)
+
+		// The organization/user portion doesn't host any manifests
+		if pkgName == "" {
+			return
+		}
+
+		// Pick the right package versions function, depending on whether the
+		// repository belongs to an organization or a user
+		getAllVersions := c.orgs.PackageGetAllVersions
+		isUser, err := c.isUser(ctx, orgOrUser)
+		if err != nil {
+			yield(nil, fmt.Errorf("checking if entity is a user or organization: %w", err))
+			return
+		}
+		if isUser {
+			getAllVersions = c.users.PackageGetAllVersions
+		}
+
+		listOpts := &github.PackageListOptions{
+			PackageType: github.String("container"),
+			State:       github.String("active"),
+		}
+
+		for {
+			versions, resp, err := getAllVersions(ctx, orgOrUser, "container", url.PathEscape(pkgName), listOpts)
+			if err != nil {
+				yield(nil, fmt.Errorf("getting package versions: %w", err))
+				return
+			}
+
+			var (
+				manifests []seaglass.Manifest
+				oldest    *time.Time
+			)
+			for _, version := range versions {
+				if created := version.CreatedAt.GetTime(); created != nil && (oldest == nil || created.Before(*oldest)) {
+					oldest = created
+				}
+				if version.GetName() == "" {
+					continue
+				}
+				manifest := seaglass.Manifest{
+					Digest:   version.GetName(),
+					Uploaded: version.CreatedAt.GetTime(),
+					Updated:  version.UpdatedAt.GetTime(),
+				}
+				// The container metadata of a version only
+				// includes its tags, so the platforms of images
+				// and the manifests in indexes are resolved
+				// separately, if they're needed
+				if metadata := version.GetMetadata(); metadata != nil {
+					if container := metadata.GetContainer(); container != nil {
+						manifest.Tags = container.Tags
+					}
+				}
+
+				manifests = append(manifests, manifest)
+			}
+
+			if !yield(&seaglass.ManifestList{Manifests: manifests}, nil) {
+				return
+			}
+
+			if resp.NextPage < 1 {
+				break
+			}
+
+			// The API returns the most recently created versions
+			// first, so once the page reaches back past the time
+			// that the versions must be uploaded after, none of the
+			// remaining pages can match
+			if opts != nil && opts.UploadedAfter != nil && oldest != nil && !oldest.After(*opts.UploadedAfter) {
+				break
+			}
+
+			listOpts.Page = resp.NextPage
+		}
+	}
+}
+
+// DeleteManifest deletes the package version for the manifest, which deletes
+// its tags too
+func (c *Client) DeleteManifest(ctx context.Context, repo, digest string) error {
+	orgOrUser, pkgName := parseRepo(repo)
+	if pkgName == "" {
+		return seaglass.ErrNotFound
+	}
+
+	// Pick the right package functions, depending on whether the
+	// repository belongs to an organization or a user
+	getAllVersions := c.orgs.PackageGetAllVersions
+	deleteVersion := c.orgs.PackageDeleteVersion
+	isUser, err := c.isUser(ctx, orgOrUser)
+	if err != nil {
+		return fmt.Errorf("checking if entity is a user or organization: %w", err)
+	}
+	if isUser {
+		getAllVersions = c.users.PackageGetAllVersions
+		deleteVersion = c.users.PackageDeleteVersion
+	}
+
+	// Versions are deleted by their ID, so the version for the digest has
+	// to be found first
+	listOpts := &github.PackageListOptions{
+		PackageType: github.String("container"),
+		State:       github.String("active"),
+	}
+	for {
+		versions, resp, err := getAllVersions(ctx, orgOrUser, "container", url.PathEscape(pkgName), listOpts)
+		if err != nil {
+			return fmt.Errorf("getting package versions: %w", err)
+		}
+
+		for _, version := range versions {
+			if version.GetName() != digest {
+				continue
+			}
+
+			resp, err := deleteVersion(ctx, orgOrUser, "container", url.PathEscape(pkgName), version.GetID())
+			if err != nil {
+				if resp != nil && resp.StatusCode == http.StatusNotFound {
+					return seaglass.ErrNotFound
+				}
+				return fmt.Errorf("deleting package version: %w", err)
+			}
+
+			return nil
+		}
+
+		if resp.NextPage < 1 {
+			return seaglass.ErrNotFound
+		}
+
+		listOpts.Page = resp.NextPage
+	}
+}
+
+// DeleteTag isn't supported, because the GitHub API can't remove a tag from a
+// package version without deleting the version
+func (c *Client) DeleteTag(ctx context.Context, repo, tag string) error {
+	return fmt.Errorf("deleting tags from ghcr.io: %w", seaglass.ErrNotSupported)
+}
+
+func (c *Client) isUser(ctx context.Context, orgOrUser string) (bool, error) {
+	user, _, err := c.users.Get(ctx, orgOrUser)
+	if err != nil {
+		return false, fmt.Errorf("fetching user: %w", err)
+	}
+	switch user.GetType() {
+	case "User":
+		return true, nil
+	case "Organization":
+		return false, nil
+	default:
+		return false, fmt.Errorf("unsupported type: %s", *user.Type)
+	}
+}
+
+func parseRepo(repo string) (orgOrUser, pkg string) {
+	// Split the repsitory reference to get the organization/user and the
+	// package name
+	parts := strings.SplitN(repo, "/", 2)
+	orgOrUser = parts[0]
+	if len(parts) > 1 {
+		pkg = parts[1]
+	}
+
+	return orgOrUser, pkg
+}
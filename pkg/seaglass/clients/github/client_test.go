@@ -2,6 +2,7 @@ package github
 
 import (
 	"context"
+	"errors"
 	"net/url"
 	"testing"
 	"time"
@@ -9,15 +10,15 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/google/go-github/v56/github"
-	v1 "github.com/jetstack/seaglass/internal/v1"
-	"github.com/jetstack/seaglass/internal/v1/clients/github/mocks"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+	"github.com/jetstack/seaglass/pkg/seaglass/clients/github/mocks"
 )
 
 func sortStrings(a, b string) bool {
 	return a < b
 }
 
-func sortManifests(a, b v1.Manifest) bool {
+func sortManifests(a, b seaglass.Manifest) bool {
 	return a.Digest < b.Digest
 }
 
@@ -67,7 +68,7 @@ func TestClientListRepositories(t *testing.T) {
 			t.Errorf("unexpected error: %s", err)
 		}
 
-		wantList := &v1.RepositoryList{
+		wantList := &seaglass.RepositoryList{
 			Name: "foo",
 			Repositories: []string{
 				"bar",
@@ -146,7 +147,7 @@ func TestClientListRepositories(t *testing.T) {
 			t.Errorf("unexpected error: %s", err)
 		}
 
-		wantList := &v1.RepositoryList{
+		wantList := &seaglass.RepositoryList{
 			Name: "foo",
 			Repositories: []string{
 				"bar",
@@ -199,14 +200,14 @@ func TestClientListRepositories(t *testing.T) {
 			nil,
 		)
 
-		gotList, err := c.ListRepositories(ctx, "foo", &v1.RepositoryListOptions{
+		gotList, err := c.ListRepositories(ctx, "foo", &seaglass.RepositoryListOptions{
 			Recursive: true,
 		})
 		if err != nil {
 			t.Errorf("unexpected error: %s", err)
 		}
 
-		wantList := &v1.RepositoryList{
+		wantList := &seaglass.RepositoryList{
 			Name: "foo",
 			Repositories: []string{
 				"bar",
@@ -264,7 +265,7 @@ func TestClientListRepositories(t *testing.T) {
 			t.Errorf("unexpected error: %s", err)
 		}
 
-		wantList := &v1.RepositoryList{
+		wantList := &seaglass.RepositoryList{
 			Name: "foo",
 			Repositories: []string{
 				"bar",
@@ -316,14 +317,14 @@ func TestClientListRepositories(t *testing.T) {
 			nil,
 		)
 
-		gotList, err := c.ListRepositories(ctx, "foo", &v1.RepositoryListOptions{
+		gotList, err := c.ListRepositories(ctx, "foo", &seaglass.RepositoryListOptions{
 			Recursive: true,
 		})
 		if err != nil {
 			t.Errorf("unexpected error: %s", err)
 		}
 
-		wantList := &v1.RepositoryList{
+		wantList := &seaglass.RepositoryList{
 			Name: "foo",
 			Repositories: []string{
 				"bar",
@@ -403,7 +404,7 @@ func TestClientListRepositories(t *testing.T) {
 			t.Errorf("unexpected error: %s", err)
 		}
 
-		wantList := &v1.RepositoryList{
+		wantList := &seaglass.RepositoryList{
 			Name: "foo",
 			Repositories: []string{
 				"bar",
@@ -464,7 +465,7 @@ func TestClientListRepositories(t *testing.T) {
 			t.Errorf("unexpected error: %s", err)
 		}
 
-		wantList := &v1.RepositoryList{
+		wantList := &seaglass.RepositoryList{
 			Name: "foo/bar",
 			Repositories: []string{
 				"baz",
@@ -522,14 +523,14 @@ func TestClientListRepositories(t *testing.T) {
 			nil,
 		)
 
-		gotList, err := c.ListRepositories(ctx, "foo/bar", &v1.RepositoryListOptions{
+		gotList, err := c.ListRepositories(ctx, "foo/bar", &seaglass.RepositoryListOptions{
 			Recursive: true,
 		})
 		if err != nil {
 			t.Errorf("unexpected error: %s", err)
 		}
 
-		wantList := &v1.RepositoryList{
+		wantList := &seaglass.RepositoryList{
 			Name: "foo/bar",
 			Repositories: []string{
 				"baz",
@@ -590,7 +591,7 @@ func TestClientListRepositories(t *testing.T) {
 			t.Errorf("unexpected error: %s", err)
 		}
 
-		wantList := &v1.RepositoryList{
+		wantList := &seaglass.RepositoryList{
 			Name: "foo/bar",
 			Repositories: []string{
 				"baz",
@@ -649,14 +650,14 @@ func TestClientListRepositories(t *testing.T) {
 			nil,
 		)
 
-		gotList, err := c.ListRepositories(ctx, "foo/bar", &v1.RepositoryListOptions{
+		gotList, err := c.ListRepositories(ctx, "foo/bar", &seaglass.RepositoryListOptions{
 			Recursive: true,
 		})
 		if err != nil {
 			t.Errorf("unexpected error: %s", err)
 		}
 
-		wantList := &v1.RepositoryList{
+		wantList := &seaglass.RepositoryList{
 			Name: "foo/bar",
 			Repositories: []string{
 				"baz",
@@ -722,8 +723,8 @@ func TestClientListManifests(t *testing.T) {
 			t.Errorf("unexpected error: %s", err)
 		}
 
-		wantList := &v1.ManifestList{
-			Manifests: []v1.Manifest{
+		wantList := &seaglass.ManifestList{
+			Manifests: []seaglass.Manifest{
 				{
 					Digest:   "sha256:aaaaaaa",
 					Uploaded: &t1,
@@ -792,8 +793,8 @@ func TestClientListManifests(t *testing.T) {
 			t.Errorf("unexpected error: %s", err)
 		}
 
-		wantList := &v1.ManifestList{
-			Manifests: []v1.Manifest{
+		wantList := &seaglass.ManifestList{
+			Manifests: []seaglass.Manifest{
 				{
 					Digest:   "sha256:aaaaaaa",
 					Uploaded: &t1,
@@ -810,4 +811,199 @@ func TestClientListManifests(t *testing.T) {
 			t.Errorf("unexpected result:\n%s", diff)
 		}
 	})
+
+	t.Run("listing manifests uploaded after a time", func(t *testing.T) {
+		ctx := context.Background()
+
+		mockOrgService := mocks.NewOrganizationsService(t)
+		mockUsersService := mocks.NewUsersService(t)
+
+		c := &Client{
+			orgs:  mockOrgService,
+			users: mockUsersService,
+		}
+
+		mockUsersService.On("Get", ctx, "foo").Return(
+			&github.User{
+				Type: github.String("Organization"),
+			},
+			&github.Response{},
+			nil,
+		)
+
+		opts := &github.PackageListOptions{
+			PackageType: github.String("container"),
+			State:       github.String("active"),
+		}
+
+		now := time.Now()
+
+		t1 := now.Add(-60 * time.Minute)
+		t2 := now.Add(-120 * time.Minute)
+		after := now.Add(-90 * time.Minute)
+
+		// The second page isn't requested, because the first page
+		// already reaches back past the time
+		mockOrgService.On("PackageGetAllVersions", ctx, "foo", "container", url.PathEscape("bar/baz"), opts).Return(
+			[]*github.PackageVersion{
+				{
+					Name:      github.String("sha256:aaaaaaa"),
+					CreatedAt: &github.Timestamp{Time: t1},
+					UpdatedAt: &github.Timestamp{Time: t1},
+					Metadata: &github.PackageMetadata{
+						Container: &github.PackageContainerMetadata{
+							Tags: []string{"v1", "latest"},
+						},
+					},
+				},
+				{
+					Name:      github.String("sha256:bbbbbbb"),
+					CreatedAt: &github.Timestamp{Time: t2},
+					UpdatedAt: &github.Timestamp{Time: t2},
+				},
+			},
+			&github.Response{NextPage: 2},
+			nil,
+		).Once()
+
+		gotList, err := c.ListManifests(ctx, "foo/bar/baz", &seaglass.ManifestListOptions{
+			UploadedAfter: &after,
+			ExcludeTags:   []string{"latest"},
+		})
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		wantList := &seaglass.ManifestList{
+			Manifests: []seaglass.Manifest{
+				{
+					Digest:   "sha256:aaaaaaa",
+					Tags:     []string{"v1"},
+					Uploaded: &t1,
+					Updated:  &t1,
+				},
+			},
+		}
+		if diff := cmp.Diff(wantList, gotList, cmpopts.SortSlices(sortManifests)); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+}
+
+func TestClientDeleteManifest(t *testing.T) {
+	versions := []*github.PackageVersion{
+		{
+			ID:   github.Int64(1),
+			Name: github.String("sha256:aaaaaaa"),
+		},
+		{
+			ID:   github.Int64(2),
+			Name: github.String("sha256:bbbbbbb"),
+		},
+	}
+
+	opts := &github.PackageListOptions{
+		PackageType: github.String("container"),
+		State:       github.String("active"),
+	}
+
+	t.Run("deleting organization package version", func(t *testing.T) {
+		ctx := context.Background()
+
+		mockOrgService := mocks.NewOrganizationsService(t)
+		mockUsersService := mocks.NewUsersService(t)
+
+		c := &Client{
+			orgs:  mockOrgService,
+			users: mockUsersService,
+		}
+
+		mockUsersService.On("Get", ctx, "foo").Return(
+			&github.User{
+				Type: github.String("Organization"),
+			},
+			&github.Response{},
+			nil,
+		)
+
+		mockOrgService.On("PackageGetAllVersions", ctx, "foo", "container", url.PathEscape("bar/baz"), opts).Return(
+			versions,
+			&github.Response{},
+			nil,
+		)
+
+		mockOrgService.On("PackageDeleteVersion", ctx, "foo", "container", url.PathEscape("bar/baz"), int64(2)).Return(
+			&github.Response{},
+			nil,
+		)
+
+		if err := c.DeleteManifest(ctx, "foo/bar/baz", "sha256:bbbbbbb"); err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+	})
+
+	t.Run("deleting user package version", func(t *testing.T) {
+		ctx := context.Background()
+
+		mockOrgService := mocks.NewOrganizationsService(t)
+		mockUsersService := mocks.NewUsersService(t)
+
+		c := &Client{
+			orgs:  mockOrgService,
+			users: mockUsersService,
+		}
+
+		mockUsersService.On("Get", ctx, "foo").Return(
+			&github.User{
+				Type: github.String("User"),
+			},
+			&github.Response{},
+			nil,
+		)
+
+		mockUsersService.On("PackageGetAllVersions", ctx, "foo", "container", url.PathEscape("bar"), opts).Return(
+			versions,
+			&github.Response{},
+			nil,
+		)
+
+		mockUsersService.On("PackageDeleteVersion", ctx, "foo", "container", url.PathEscape("bar"), int64(1)).Return(
+			&github.Response{},
+			nil,
+		)
+
+		if err := c.DeleteManifest(ctx, "foo/bar", "sha256:aaaaaaa"); err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+	})
+
+	t.Run("deleting missing package version", func(t *testing.T) {
+		ctx := context.Background()
+
+		mockOrgService := mocks.NewOrganizationsService(t)
+		mockUsersService := mocks.NewUsersService(t)
+
+		c := &Client{
+			orgs:  mockOrgService,
+			users: mockUsersService,
+		}
+
+		mockUsersService.On("Get", ctx, "foo").Return(
+			&github.User{
+				Type: github.String("Organization"),
+			},
+			&github.Response{},
+			nil,
+		)
+
+		mockOrgService.On("PackageGetAllVersions", ctx, "foo", "container", url.PathEscape("bar"), opts).Return(
+			versions,
+			&github.Response{},
+			nil,
+		)
+
+		if err := c.DeleteManifest(ctx, "foo/bar", "sha256:ccccccc"); !errors.Is(err, seaglass.ErrNotFound) {
+			t.Errorf("unexpected error: %s", err)
+		}
+	})
 }
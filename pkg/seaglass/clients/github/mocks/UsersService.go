@@ -84,6 +84,32 @@ func (_m *UsersService) ListPackages(ctx context.Context, org string, opts *gith
 	return r0, r1, r2
 }
 
+// PackageDeleteVersion provides a mock function with given fields: ctx, user, packageType, packageName, packageVersionID
+func (_m *UsersService) PackageDeleteVersion(ctx context.Context, user string, packageType string, packageName string, packageVersionID int64) (*github.Response, error) {
+	ret := _m.Called(ctx, user, packageType, packageName, packageVersionID)
+
+	var r0 *github.Response
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, int64) (*github.Response, error)); ok {
+		return rf(ctx, user, packageType, packageName, packageVersionID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, int64) *github.Response); ok {
+		r0 = rf(ctx, user, packageType, packageName, packageVersionID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*github.Response)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, int64) error); ok {
+		r1 = rf(ctx, user, packageType, packageName, packageVersionID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // PackageGetAllVersions provides a mock function with given fields: ctx, org, packageType, packageName, opts
 func (_m *UsersService) PackageGetAllVersions(ctx context.Context, org string, packageType string, packageName string, opts *github.PackageListOptions) ([]*github.PackageVersion, *github.Response, error) {
 	ret := _m.Called(ctx, org, packageType, packageName, opts)
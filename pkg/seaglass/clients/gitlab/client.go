@@ -0,0 +1,484 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/jetstack/seaglass/internal/inspect"
+	"github.com/jetstack/seaglass/internal/transport"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// pageSize is the number of results to request per page
+	pageSize = 100
+
+	// defaultConcurrency is the number of tags to fetch the details of at
+	// once
+	defaultConcurrency = 10
+)
+
+// Client is a client for GitLab Container Registry
+type Client struct {
+	*inspect.Inspector
+
+	apiURL     *url.URL
+	httpClient *http.Client
+}
+
+// NewClient returns a new client for the GitLab.com Container Registry
+func NewClient(host string, opts ...seaglass.Option) (seaglass.Client, error) {
+	if host != "registry.gitlab.com" {
+		return nil, seaglass.ErrNotSupported
+	}
+
+	return NewSelfHostedClient(host, opts...)
+}
+
+// NewSelfHostedClient returns a new client for the container registry of a
+// self-managed GitLab instance.
+//
+// The API is expected to be served from the same hostname as the registry,
+// without the port or a leading 'registry.', which covers the default
+// configurations of registry.gitlab.example.com and gitlab.example.com:5050.
+func NewSelfHostedClient(host string, opts ...seaglass.Option) (seaglass.Client, error) {
+	registry, err := name.NewRegistry(host)
+	if err != nil {
+		return nil, fmt.Errorf("parsing host: %w", err)
+	}
+
+	apiHost := strings.TrimPrefix(strings.Split(registry.RegistryStr(), ":")[0], "registry.")
+
+	// Use the credentials for the registry to authenticate to the API.
+	// GitLab accepts personal, group and project access tokens as bearer
+	// tokens, which are the same tokens used as passwords for the
+	// registry.
+	o := seaglass.NewClientOptions(opts...)
+	rt := o.Transport(0, 0)
+	kc := &tokenKeychain{kc: o.Keychain()}
+	httpClient := o.HTTPClient(transport.NewTransport(rt, kc, registry))
+
+	inspector, err := inspect.New(registry, o.RemoteOptions(rt)...)
+	if err != nil {
+		return nil, err
+	}
+
+	c := newClient(&url.URL{Scheme: "https", Host: apiHost, Path: "/api/v4"}, httpClient)
+	c.Inspector = inspector
+
+	return c, nil
+}
+
+func newClient(apiURL *url.URL, httpClient *http.Client) *Client {
+	return &Client{
+		apiURL:     apiURL,
+		httpClient: httpClient,
+	}
+}
+
+type registryRepository struct {
+	ID        int    `json:"id"`
+	Path      string `json:"path"`
+	ProjectID int    `json:"project_id"`
+}
+
+// ListRepositories lists the child repositories of the specified repository.
+//
+// If the repository is a group, then this lists the registry repositories of
+// every project in the group and its subgroups. Otherwise it lists the
+// registry repositories of the project that the repository belongs to.
+func (c *Client) ListRepositories(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) (*seaglass.RepositoryList, error) {
+	return seaglass.CollectRepositories(repo, c.ListRepositoryPages(ctx, repo, opts))
+}
+
+// ListRepositoryPages lists the child repositories of the specified
+// repository. For a group, a page is yielded for each page of the group's
+// registry repositories.
+func (c *Client) ListRepositoryPages(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) iter.Seq2[*seaglass.RepositoryList, error] {
+	return seaglass.FilterRepositoryPages(c.listRepositoryPages(ctx, repo, opts), opts)
+}
+
+func (c *Client) listRepositoryPages(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) iter.Seq2[*seaglass.RepositoryList, error] {
+	return func(yield func(*seaglass.RepositoryList, error) bool) {
+		childMap := map[string]struct{}{}
+		children := func(repos []registryRepository) []string {
+			var children []string
+			for _, r := range repos {
+				prefix := fmt.Sprintf("%s/", repo)
+				if !strings.HasPrefix(r.Path, prefix) {
+					continue
+				}
+
+				relativePath := strings.TrimPrefix(r.Path, prefix)
+				if opts != nil && opts.Recursive {
+					children = append(children, relativePath)
+				} else {
+					child := strings.Split(relativePath, "/")[0]
+					if _, ok := childMap[child]; !ok {
+						children = append(children, child)
+					}
+					childMap[child] = struct{}{}
+				}
+			}
+			return children
+		}
+
+		// The group endpoint lists the registry repositories of every
+		// project in the group and its subgroups
+		groupPath := fmt.Sprintf("/groups/%s/registry/repositories", url.PathEscape(repo))
+		for items, err := range c.pages(ctx, groupPath) {
+			if errors.Is(err, seaglass.ErrNotFound) {
+				c.listProjectRepositories(ctx, repo, children, yield)
+				return
+			}
+			if err != nil {
+				yield(nil, fmt.Errorf("listing registry repositories for group %s: %w", repo, err))
+				return
+			}
+
+			var repos []registryRepository
+			if err := decodeItems(items, &repos); err != nil {
+				yield(nil, fmt.Errorf("decoding registry repositories: %w", err))
+				return
+			}
+
+			page := children(repos)
+			if len(page) == 0 {
+				continue
+			}
+			if !yield(&seaglass.RepositoryList{Name: repo, Repositories: page}, nil) {
+				return
+			}
+		}
+	}
+}
+
+// listProjectRepositories yields the child repositories of a repository that
+// isn't a group, which are the registry repositories of the project it
+// belongs to
+func (c *Client) listProjectRepositories(ctx context.Context, repo string, children func([]registryRepository) []string, yield func(*seaglass.RepositoryList, error) bool) {
+	project, repos, err := c.findProjectRepositories(ctx, repo)
+	if err != nil {
+		yield(nil, err)
+		return
+	}
+	if project != repo && !hasRepository(repos, repo) {
+		yield(nil, seaglass.ErrNotFound)
+		return
+	}
+
+	yield(&seaglass.RepositoryList{Name: repo, Repositories: children(repos)}, nil)
+}
+
+// findProjectRepositories finds the project that the repository belongs to and
+// lists its registry repositories.
+//
+// A registry repository is either at the root of the project or nested under
+// it, so this tries the repository and then each of its parents until it
+// finds a project. Returns ErrNotFound if there is no such project.
+func (c *Client) findProjectRepositories(ctx context.Context, repo string) (string, []registryRepository, error) {
+	parts := strings.Split(repo, "/")
+	for i := len(parts); i > 1; i-- {
+		project := strings.Join(parts[:i], "/")
+
+		var repos []registryRepository
+		err := c.list(ctx, fmt.Sprintf("/projects/%s/registry/repositories", url.PathEscape(project)), &repos)
+		if errors.Is(err, seaglass.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return "", nil, fmt.Errorf("listing registry repositories for project %s: %w", project, err)
+		}
+
+		return project, repos, nil
+	}
+
+	return "", nil, seaglass.ErrNotFound
+}
+
+// ListManifests lists the manifests in the repository. GitLab only returns
+// the names of tags when listing them, so this fetches the details of each tag
+// to find its digest.
+func (c *Client) ListManifests(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) (*seaglass.ManifestList, error) {
+	return seaglass.CollectManifests(c.ListManifestPages(ctx, repo, opts))
+}
+
+// ListManifestPages lists the manifests in the repository, yielding the
+// manifests for each page of tags.
+func (c *Client) ListManifestPages(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) iter.Seq2[*seaglass.ManifestList, error] {
+	return seaglass.FilterManifestPages(c.Resolve(ctx, repo, seaglass.GroupArtifactPages(c.listManifestPages(ctx, repo, opts), opts), opts), opts)
+}
+
+func (c *Client) listManifestPages(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) iter.Seq2[*seaglass.ManifestList, error] {
+	return func(yield func(*seaglass.ManifestList, error) bool) {
+		project, repos, err := c.findProjectRepositories(ctx, repo)
+		if errors.Is(err, seaglass.ErrNotFound) {
+			// Groups don't host any manifests
+			if err := c.checkGroup(ctx, repo); err != nil {
+				yield(nil, err)
+			}
+			return
+		}
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		var registryRepo *registryRepository
+		for _, r := range repos {
+			if r.Path == repo {
+				registryRepo = &r
+				break
+			}
+		}
+		if registryRepo == nil {
+			// Projects don't necessarily have a repository at their
+			// root
+			if project != repo {
+				yield(nil, seaglass.ErrNotFound)
+			}
+			return
+		}
+
+		concurrency := defaultConcurrency
+		if opts != nil && opts.Concurrency > 0 {
+			concurrency = opts.Concurrency
+		}
+
+		tagsPath := fmt.Sprintf("/projects/%d/registry/repositories/%d/tags", registryRepo.ProjectID, registryRepo.ID)
+
+		for page, err := range c.pages(ctx, tagsPath) {
+			if err != nil {
+				yield(nil, fmt.Errorf("listing tags: %w", err))
+				return
+			}
+
+			manifests, err := c.tagManifests(ctx, tagsPath, page, concurrency)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			if !yield(&seaglass.ManifestList{Manifests: manifests}, nil) {
+				return
+			}
+		}
+	}
+}
+
+// tagManifests fetches the details of each of the tags in a page of results,
+// making up to concurrency requests at once, and groups them by manifest
+func (c *Client) tagManifests(ctx context.Context, tagsPath string, page []json.RawMessage, concurrency int) ([]seaglass.Manifest, error) {
+	type tagDetail struct {
+		Name      string    `json:"name"`
+		Digest    string    `json:"digest"`
+		TotalSize int64     `json:"total_size"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+	details := make([]tagDetail, len(page))
+
+	// Decode the whole page before fetching any details, so that a bad
+	// item doesn't return while requests are still in flight
+	tags := make([]string, len(page))
+	for i, item := range page {
+		var t struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(item, &t); err != nil {
+			return nil, fmt.Errorf("decoding tag: %w", err)
+		}
+		tags[i] = t.Name
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for i, tag := range tags {
+		g.Go(func() error {
+			if err := c.get(gctx, fmt.Sprintf("%s/%s", tagsPath, url.PathEscape(tag)), nil, &details[i]); err != nil {
+				return fmt.Errorf("getting tag %s: %w", tag, err)
+			}
+			details[i].Name = tag
+
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	manifestMap := map[string]*seaglass.Manifest{}
+	for _, detail := range details {
+		if detail.Digest == "" {
+			continue
+		}
+
+		manifest, ok := manifestMap[detail.Digest]
+		if !ok {
+			manifest = &seaglass.Manifest{
+				Digest: detail.Digest,
+				Size:   detail.TotalSize,
+			}
+			// GitLab reads the created time from the image config
+			if !detail.CreatedAt.IsZero() {
+				manifest.Created = &detail.CreatedAt
+			}
+			manifestMap[detail.Digest] = manifest
+		}
+		manifest.Tags = append(manifest.Tags, detail.Name)
+	}
+
+	var manifests []seaglass.Manifest
+	for _, manifest := range manifestMap {
+		sort.Strings(manifest.Tags)
+		manifests = append(manifests, *manifest)
+	}
+
+	sort.Slice(manifests, func(i, j int) bool {
+		return manifests[i].Digest < manifests[j].Digest
+	})
+
+	return manifests, nil
+}
+
+func (c *Client) checkGroup(ctx context.Context, group string) error {
+	var body struct {
+		FullPath string `json:"full_path"`
+	}
+
+	return c.get(ctx, fmt.Sprintf("/groups/%s", url.PathEscape(group)), nil, &body)
+}
+
+// list fetches every page of results from the path and appends them to v,
+// which must be a pointer to a slice
+func (c *Client) list(ctx context.Context, path string, v any) error {
+	var items []json.RawMessage
+	for page, err := range c.pages(ctx, path) {
+		if err != nil {
+			return err
+		}
+		items = append(items, page...)
+	}
+
+	return decodeItems(items, v)
+}
+
+// decodeItems decodes the items from one or more pages of results into v,
+// which must be a pointer to a slice
+func decodeItems(items []json.RawMessage, v any) error {
+	b, err := json.Marshal(items)
+	if err != nil {
+		return fmt.Errorf("encoding results: %w", err)
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+// pages yields each page of results from the path
+func (c *Client) pages(ctx context.Context, path string) iter.Seq2[[]json.RawMessage, error] {
+	return func(yield func([]json.RawMessage, error) bool) {
+		query := url.Values{}
+		query.Set("per_page", fmt.Sprint(pageSize))
+		for {
+			var page []json.RawMessage
+			resp, err := c.do(ctx, path, query, &page)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(page, nil) {
+				return
+			}
+
+			next := resp.Header.Get("X-Next-Page")
+			if next == "" {
+				break
+			}
+			query.Set("page", next)
+		}
+	}
+}
+
+// get fetches the path from the API and decodes the response into v
+func (c *Client) get(ctx context.Context, path string, query url.Values, v any) error {
+	_, err := c.do(ctx, path, query, v)
+
+	return err
+}
+
+func (c *Client) do(ctx context.Context, path string, query url.Values, v any) (*http.Response, error) {
+	// The path is concatenated, rather than joined, so that the encoded
+	// slashes in group and project paths are preserved
+	u := fmt.Sprintf("%s%s?%s", c.apiURL, path, query.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, seaglass.ErrNotFound
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response code: %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return nil, fmt.Errorf("decoding body: %w", err)
+	}
+
+	return resp, nil
+}
+
+func hasRepository(repos []registryRepository, repo string) bool {
+	for _, r := range repos {
+		if r.Path == repo || strings.HasPrefix(r.Path, fmt.Sprintf("%s/", repo)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// tokenKeychain wraps a keychain so that the password from basic auth
+// credentials is presented as a token instead, which the transport will send
+// as a bearer token.
+type tokenKeychain struct {
+	kc authn.Keychain
+}
+
+func (k *tokenKeychain) Resolve(r authn.Resource) (authn.Authenticator, error) {
+	auth, err := k.kc.Resolve(r)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := auth.Authorization()
+	if err != nil {
+		return nil, fmt.Errorf("fetching auth config: %w", err)
+	}
+	if cfg.Password == "" {
+		return auth, nil
+	}
+
+	return authn.FromConfig(authn.AuthConfig{
+		RegistryToken: cfg.Password,
+	}), nil
+}
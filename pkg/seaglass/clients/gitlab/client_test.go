@@ -0,0 +1,402 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+)
+
+func sortStrings(a, b string) bool {
+	return a < b
+}
+
+func TestClientListRepositories(t *testing.T) {
+	g := &fakeGitLab{
+		groups: []string{"foo", "foo/bar"},
+		projects: map[string][]string{
+			"foo/baz":     {"foo/baz", "foo/baz/qux", "foo/baz/qux/quux"},
+			"foo/bar/baz": {"foo/bar/baz/foo"},
+		},
+	}
+
+	t.Run("listing a group", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, g)
+
+		gotList, err := c.ListRepositories(ctx, "foo", nil)
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		wantList := &seaglass.RepositoryList{
+			Name: "foo",
+			Repositories: []string{
+				"bar",
+				"baz",
+			},
+		}
+		if diff := cmp.Diff(wantList, gotList, cmpopts.SortSlices(sortStrings)); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("listing a group recursive", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, g)
+
+		gotList, err := c.ListRepositories(ctx, "foo", &seaglass.RepositoryListOptions{Recursive: true})
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		wantList := &seaglass.RepositoryList{
+			Name: "foo",
+			Repositories: []string{
+				"bar/baz/foo",
+				"baz",
+				"baz/qux",
+				"baz/qux/quux",
+			},
+		}
+		if diff := cmp.Diff(wantList, gotList, cmpopts.SortSlices(sortStrings)); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("listing a project", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, g)
+
+		gotList, err := c.ListRepositories(ctx, "foo/baz", nil)
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		wantList := &seaglass.RepositoryList{
+			Name: "foo/baz",
+			Repositories: []string{
+				"qux",
+			},
+		}
+		if diff := cmp.Diff(wantList, gotList); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("listing a repository in a project", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, g)
+
+		gotList, err := c.ListRepositories(ctx, "foo/baz/qux", nil)
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		wantList := &seaglass.RepositoryList{
+			Name: "foo/baz/qux",
+			Repositories: []string{
+				"quux",
+			},
+		}
+		if diff := cmp.Diff(wantList, gotList); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("listing a repository that doesn't exist", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, g)
+
+		gotList, err := c.ListRepositories(ctx, "foo/baz/foo", nil)
+		if !errors.Is(err, seaglass.ErrNotFound) {
+			t.Errorf("unexpected error: %s", err)
+		}
+		if gotList != nil {
+			t.Errorf("unexpected response: %v", gotList)
+		}
+	})
+}
+
+func TestClientListManifests(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	g := &fakeGitLab{
+		groups: []string{"foo"},
+		projects: map[string][]string{
+			"foo/bar": {"foo/bar/baz"},
+		},
+		tags: map[string][]tagDetail{
+			"foo/bar/baz": {
+				{Name: "v1", Digest: "sha256:aaaa", TotalSize: 1024, CreatedAt: created},
+				{Name: "latest", Digest: "sha256:aaaa", TotalSize: 1024, CreatedAt: created},
+				{Name: "v0", Digest: "sha256:bbbb", TotalSize: 2048, CreatedAt: created},
+			},
+		},
+	}
+
+	t.Run("list manifests", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, g)
+
+		gotList, err := c.ListManifests(ctx, "foo/bar/baz", nil)
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		wantList := &seaglass.ManifestList{
+			Manifests: []seaglass.Manifest{
+				{
+					Digest:  "sha256:aaaa",
+					Tags:    []string{"latest", "v1"},
+					Size:    1024,
+					Created: &created,
+				},
+				{
+					Digest:  "sha256:bbbb",
+					Tags:    []string{"v0"},
+					Size:    2048,
+					Created: &created,
+				},
+			},
+		}
+		if diff := cmp.Diff(wantList, gotList); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("list manifests concurrently", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, g)
+
+		gotList, err := c.ListManifests(ctx, "foo/bar/baz", &seaglass.ManifestListOptions{Concurrency: 2})
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		wantList := &seaglass.ManifestList{
+			Manifests: []seaglass.Manifest{
+				{
+					Digest:  "sha256:aaaa",
+					Tags:    []string{"latest", "v1"},
+					Size:    1024,
+					Created: &created,
+				},
+				{
+					Digest:  "sha256:bbbb",
+					Tags:    []string{"v0"},
+					Size:    2048,
+					Created: &created,
+				},
+			},
+		}
+		if diff := cmp.Diff(wantList, gotList); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	for _, repo := range []string{"foo", "foo/bar"} {
+		t.Run(fmt.Sprintf("list manifests in %s", repo), func(t *testing.T) {
+			ctx := context.Background()
+
+			c := setupClient(t, g)
+
+			gotList, err := c.ListManifests(ctx, repo, nil)
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+
+			if diff := cmp.Diff(&seaglass.ManifestList{}, gotList); diff != "" {
+				t.Errorf("unexpected result:\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("repository not found", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, g)
+
+		gotList, err := c.ListManifests(ctx, "foo/bar/qux", nil)
+		if !errors.Is(err, seaglass.ErrNotFound) {
+			t.Errorf("unexpected error: %s", err)
+		}
+		if gotList != nil {
+			t.Errorf("unexpected response: %v", gotList)
+		}
+	})
+}
+
+func TestTokenKeychain(t *testing.T) {
+	kc := &tokenKeychain{
+		kc: staticKeychain{Username: "user", Password: "glpat-token"},
+	}
+
+	auth, err := kc.Resolve(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	cfg, err := auth.Authorization()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if diff := cmp.Diff(&authn.AuthConfig{RegistryToken: "glpat-token"}, cfg); diff != "" {
+		t.Errorf("unexpected result:\n%s", diff)
+	}
+}
+
+type staticKeychain authn.AuthConfig
+
+func (k staticKeychain) Resolve(authn.Resource) (authn.Authenticator, error) {
+	return authn.FromConfig(authn.AuthConfig(k)), nil
+}
+
+type tagDetail struct {
+	Name      string    `json:"name"`
+	Digest    string    `json:"digest"`
+	TotalSize int64     `json:"total_size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// fakeGitLab is a fake implementation of the GitLab API. Lists are served one
+// item per page to exercise pagination.
+type fakeGitLab struct {
+	// groups are the paths of the groups
+	groups []string
+
+	// projects maps projects to the paths of their registry repositories
+	projects map[string][]string
+
+	// tags maps registry repositories to their tags
+	tags map[string][]tagDetail
+}
+
+func (g *fakeGitLab) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	page := func(items []any) {
+		p := 1
+		fmt.Sscan(r.URL.Query().Get("page"), &p)
+		if p < len(items) {
+			w.Header().Set("X-Next-Page", fmt.Sprint(p+1))
+		}
+		if len(items) == 0 {
+			json.NewEncoder(w).Encode([]any{})
+			return
+		}
+		json.NewEncoder(w).Encode(items[p-1 : p])
+	}
+
+	registryRepositories := func(projects ...string) []any {
+		var items []any
+		for _, project := range projects {
+			for _, path := range g.projects[project] {
+				items = append(items, registryRepository{
+					ID:        g.repositoryID(path),
+					Path:      path,
+					ProjectID: g.projectID(project),
+				})
+			}
+		}
+		return items
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.EscapedPath(), "/api/v4/"), "/")
+	for i := range parts {
+		parts[i], _ = url.PathUnescape(parts[i])
+	}
+
+	switch {
+	case len(parts) == 2 && parts[0] == "groups":
+		if !slices.Contains(g.groups, parts[1]) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"full_path": parts[1]})
+	case len(parts) == 4 && parts[0] == "groups" && parts[2] == "registry" && parts[3] == "repositories":
+		if !slices.Contains(g.groups, parts[1]) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		// The repositories of projects in subgroups are included
+		var projects []string
+		for _, p := range slices.Sorted(maps.Keys(g.projects)) {
+			if strings.HasPrefix(p, parts[1]+"/") {
+				projects = append(projects, p)
+			}
+		}
+		page(registryRepositories(projects...))
+	case len(parts) == 4 && parts[0] == "projects" && parts[2] == "registry" && parts[3] == "repositories":
+		if _, ok := g.projects[parts[1]]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		page(registryRepositories(parts[1]))
+	case len(parts) >= 6 && parts[0] == "projects" && parts[5] == "tags":
+		var tags []tagDetail
+		for path, t := range g.tags {
+			if fmt.Sprint(g.repositoryID(path)) == parts[4] {
+				tags = t
+			}
+		}
+		if len(parts) == 6 {
+			var items []any
+			for _, t := range tags {
+				items = append(items, map[string]string{"name": t.Name})
+			}
+			page(items)
+			return
+		}
+		for _, t := range tags {
+			if t.Name == parts[6] {
+				json.NewEncoder(w).Encode(t)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// projectID returns a stable id for the project
+func (g *fakeGitLab) projectID(project string) int {
+	return len(project)
+}
+
+// repositoryID returns a stable id for the registry repository
+func (g *fakeGitLab) repositoryID(path string) int {
+	return len(path) * 100
+}
+
+func setupClient(t *testing.T, g *fakeGitLab) *Client {
+	srv := httptest.NewServer(g)
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error parsing server url: %s", err)
+	}
+
+	return newClient(u.JoinPath("/api/v4"), srv.Client())
+}
@@ -0,0 +1,233 @@
+package google
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/google"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/jetstack/seaglass/internal/inspect"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+)
+
+// Client is a client for Google Artifact Registry and Google Container
+// Registry
+type Client struct {
+	*inspect.Inspector
+
+	registry name.Registry
+	kc       authn.Keychain
+	rt       http.RoundTripper
+	listRT   http.RoundTripper
+}
+
+// NewClient returns a new client for a Google Artifact Registry or Google Container
+// Registry registry
+func NewClient(host string, opts ...seaglass.Option) (seaglass.Client, error) {
+	if !isGoogleHost(host) {
+		return nil, seaglass.ErrNotSupported
+	}
+
+	registry, err := name.NewRegistry(host)
+	if err != nil {
+		return nil, fmt.Errorf("parsing host: %w", err)
+	}
+
+	o := seaglass.NewClientOptions(opts...)
+	rt := o.Transport(0, 0)
+	kc := authn.NewMultiKeychain(
+		o.Keychain(),
+		google.Keychain,
+	)
+
+	inspector, err := inspect.New(registry, remote.WithAuthFromKeychain(kc), remote.WithTransport(rt))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		Inspector: inspector,
+		registry:  registry,
+		kc:        kc,
+		rt:        rt,
+		listRT:    o.APITransport(rt),
+	}, nil
+}
+
+// ListRepositories lists repositories
+func (c *Client) ListRepositories(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) (*seaglass.RepositoryList, error) {
+	return seaglass.CollectRepositories(repo, c.ListRepositoryPages(ctx, repo, opts))
+}
+
+// errStopWalk is returned from the walk function to stop walking when the
+// consumer of the iterator stops early
+var errStopWalk = errors.New("stop walk")
+
+// ListRepositoryPages lists repositories. When recursive, a page is yielded
+// for every repository visited by the walk.
+func (c *Client) ListRepositoryPages(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) iter.Seq2[*seaglass.RepositoryList, error] {
+	return seaglass.FilterRepositoryPages(c.listRepositoryPages(ctx, repo, opts), opts)
+}
+
+func (c *Client) listRepositoryPages(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) iter.Seq2[*seaglass.RepositoryList, error] {
+	return func(yield func(*seaglass.RepositoryList, error) bool) {
+		gOpts := []google.Option{
+			google.WithContext(ctx),
+			google.WithAuthFromKeychain(c.kc),
+			google.WithTransport(c.listRT),
+		}
+
+		if opts != nil && opts.Recursive {
+			google.Walk(c.registry.Repo(repo), func(r name.Repository, tags *google.Tags, err error) error {
+				page := &seaglass.RepositoryList{
+					Name: repo,
+					Repositories: []string{
+						strings.TrimPrefix(r.RepositoryStr(), fmt.Sprintf("%s/", repo)),
+					},
+				}
+				if !yield(page, nil) {
+					return errStopWalk
+				}
+
+				return nil
+			}, gOpts...)
+
+			return
+		}
+
+		resp, err := google.List(c.registry.Repo(repo), gOpts...)
+		if err != nil {
+			yield(nil, fmt.Errorf("listing repositories: %w", err))
+			return
+		}
+
+		yield(&seaglass.RepositoryList{
+			Name:         repo,
+			Repositories: resp.Children,
+		}, nil)
+	}
+}
+
+// ListManifests lists manifests
+func (c *Client) ListManifests(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) (*seaglass.ManifestList, error) {
+	return seaglass.CollectManifests(c.ListManifestPages(ctx, repo, opts))
+}
+
+// ListManifestPages lists manifests. The API returns every manifest in a
+// single response, so there is only ever one page.
+func (c *Client) ListManifestPages(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) iter.Seq2[*seaglass.ManifestList, error] {
+	return seaglass.FilterManifestPages(c.Resolve(ctx, repo, seaglass.GroupArtifactPages(c.listManifestPages(ctx, repo, opts), opts), opts), opts)
+}
+
+func (c *Client) listManifestPages(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) iter.Seq2[*seaglass.ManifestList, error] {
+	return func(yield func(*seaglass.ManifestList, error) bool) {
+		gOpts := []google.Option{
+			google.WithContext(ctx),
+			google.WithAuthFromKeychain(c.kc),
+			google.WithTransport(c.listRT),
+		}
+
+		resp, err := google.List(c.registry.Repo(repo), gOpts...)
+		if err != nil {
+			yield(nil, fmt.Errorf("listing repositories: %w", err))
+			return
+		}
+
+		// The listing doesn't include the platforms of images or the
+		// manifests in indexes, only the child repositories, so they
+		// are resolved separately, if they're needed
+		var manifests []seaglass.Manifest
+		for digest, manifest := range resp.Manifests {
+			manifests = append(manifests, seaglass.Manifest{
+				Digest:    digest,
+				MediaType: manifest.MediaType,
+				Tags:      manifest.Tags,
+				Created:   &manifest.Created,
+				Uploaded:  &manifest.Uploaded,
+			})
+		}
+
+		yield(&seaglass.ManifestList{
+			Manifests: manifests,
+		}, nil)
+	}
+}
+
+// DeleteManifest deletes the manifest with the v2 API. Container Registry
+// refuses to delete a manifest that still has tags, so its tags are deleted
+// first.
+func (c *Client) DeleteManifest(ctx context.Context, repo, digest string) error {
+	ref, err := name.NewDigest(fmt.Sprintf("%s@%s", c.registry.Repo(repo), digest))
+	if err != nil {
+		return fmt.Errorf("parsing digest: %w", err)
+	}
+
+	resp, err := google.List(c.registry.Repo(repo), google.WithContext(ctx), google.WithAuthFromKeychain(c.kc), google.WithTransport(c.listRT))
+	if err != nil {
+		return fmt.Errorf("listing manifests: %w", err)
+	}
+	manifest, ok := resp.Manifests[digest]
+	if !ok {
+		return seaglass.ErrNotFound
+	}
+	for _, tag := range manifest.Tags {
+		if err := c.DeleteTag(ctx, repo, tag); err != nil && !errors.Is(err, seaglass.ErrNotFound) {
+			return err
+		}
+	}
+
+	return c.delete(ctx, ref)
+}
+
+// DeleteTag deletes the tag with the v2 API
+func (c *Client) DeleteTag(ctx context.Context, repo, tag string) error {
+	ref, err := name.NewTag(fmt.Sprintf("%s:%s", c.registry.Repo(repo), tag))
+	if err != nil {
+		return fmt.Errorf("parsing tag: %w", err)
+	}
+
+	return c.delete(ctx, ref)
+}
+
+func (c *Client) delete(ctx context.Context, ref name.Reference) error {
+	if err := remote.Delete(ref, remote.WithContext(ctx), remote.WithAuthFromKeychain(c.kc), remote.WithTransport(c.rt)); err != nil {
+		var terr *transport.Error
+		if errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound {
+			return seaglass.ErrNotFound
+		}
+		return fmt.Errorf("deleting %s: %w", ref, err)
+	}
+
+	return nil
+}
+
+func isGoogleHost(host string) bool {
+	if host == "gcr.io" {
+		return true
+	}
+
+	if strings.HasSuffix(host, ".gcr.io") {
+		return true
+	}
+
+	if strings.HasSuffix(host, ".pkg.dev") {
+		return true
+	}
+
+	if host == "k8s.io" {
+		return true
+	}
+
+	if strings.HasSuffix(host, ".k8s.io") {
+		return true
+	}
+
+	return false
+}
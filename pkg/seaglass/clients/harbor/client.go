@@ -0,0 +1,449 @@
+package harbor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/jetstack/seaglass/internal/inspect"
+	"github.com/jetstack/seaglass/internal/transport"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+)
+
+// probeTimeout is how long to wait for a response from the systeminfo
+// endpoint when probing whether a host is running Harbor
+const probeTimeout = 1 * time.Second
+
+// probed records the result of probing each host, so that a host is only
+// probed once per process
+var (
+	probedMu sync.Mutex
+	probed   = map[string]bool{}
+)
+
+// pageSize is the number of results to request per page
+const pageSize = 100
+
+// Client is a client for Harbor
+type Client struct {
+	*inspect.Inspector
+
+	apiURL     *url.URL
+	httpClient *http.Client
+}
+
+// NewClient returns a new client for Harbor. Harbor can run on any host, so
+// this probes the host's systeminfo endpoint to find out whether it's a Harbor
+// instance.
+//
+// Loopback, private and other plain HTTP hosts are never probed. Use
+// NewSelfHostedClient for Harbor instances on those hosts.
+func NewClient(host string, opts ...seaglass.Option) (seaglass.Client, error) {
+	c, err := newClient(host, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if !c.probe() {
+		return nil, seaglass.ErrNotSupported
+	}
+
+	return c, nil
+}
+
+// NewSelfHostedClient returns a new client for Harbor without probing the
+// host first
+func NewSelfHostedClient(host string, opts ...seaglass.Option) (seaglass.Client, error) {
+	return newClient(host, opts...)
+}
+
+func newClient(host string, opts ...seaglass.Option) (*Client, error) {
+	registry, err := name.NewRegistry(host)
+	if err != nil {
+		return nil, fmt.Errorf("parsing host: %w", err)
+	}
+
+	// Harbor accepts the same credentials for the API as it does for the
+	// registry, so use the registry credentials from the keychain
+	o := seaglass.NewClientOptions(opts...)
+	rt := o.Transport(0, 0)
+	httpClient := o.HTTPClient(transport.NewTransport(rt, o.Keychain(), registry))
+
+	inspector, err := inspect.New(registry, o.RemoteOptions(rt)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		Inspector: inspector,
+		apiURL: &url.URL{
+			Scheme: registry.Scheme(),
+			Host:   registry.RegistryStr(),
+			Path:   "/api/v2.0",
+		},
+		httpClient: httpClient,
+	}, nil
+}
+
+// ListRepositories lists the child repositories of the specified repository.
+// The first component of the repository is the Harbor project.
+func (c *Client) ListRepositories(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) (*seaglass.RepositoryList, error) {
+	return seaglass.CollectRepositories(repo, c.ListRepositoryPages(ctx, repo, opts))
+}
+
+// ListRepositoryPages lists the child repositories of the specified
+// repository, yielding the matching repositories from each page of the
+// project's repositories.
+func (c *Client) ListRepositoryPages(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) iter.Seq2[*seaglass.RepositoryList, error] {
+	return seaglass.FilterRepositoryPages(c.listRepositoryPages(ctx, repo, opts), opts)
+}
+
+func (c *Client) listRepositoryPages(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) iter.Seq2[*seaglass.RepositoryList, error] {
+	return func(yield func(*seaglass.RepositoryList, error) bool) {
+		if repo == "" {
+			c.listProjects(ctx, opts, yield)
+			return
+		}
+
+		project, _ := parseRepo(repo)
+
+		found := false
+		childMap := map[string]struct{}{}
+
+		// Harbor can fuzzy match the full name of the repositories,
+		// which narrows down the repositories that are matched against
+		// the options once they've been listed
+		var query url.Values
+		name := opts.NameSubstring()
+		if name != "" {
+			query = url.Values{"q": {fmt.Sprintf("name=~%s", name)}}
+		}
+
+		next := c.url(fmt.Sprintf("/projects/%s/repositories", url.PathEscape(project)), query)
+		for next != "" {
+			var body []struct {
+				Name string `json:"name"`
+			}
+			n, err := c.get(ctx, next, &body)
+			if err != nil {
+				yield(nil, fmt.Errorf("listing repositories: %w", err))
+				return
+			}
+
+			var children []string
+			for _, r := range body {
+				if r.Name == repo {
+					found = true
+				}
+				prefix := fmt.Sprintf("%s/", repo)
+				if strings.HasPrefix(r.Name, prefix) {
+					found = true
+					relativePath := strings.TrimPrefix(r.Name, prefix)
+					if opts != nil && opts.Recursive {
+						children = append(children, relativePath)
+					} else {
+						child := strings.Split(relativePath, "/")[0]
+						if _, ok := childMap[child]; !ok {
+							children = append(children, child)
+						}
+						childMap[child] = struct{}{}
+					}
+				}
+			}
+
+			if len(children) > 0 && !yield(&seaglass.RepositoryList{Name: repo, Repositories: children}, nil) {
+				return
+			}
+
+			next = n
+		}
+
+		// A project is a valid repository to list from, even if it's
+		// empty. When the repositories are searched by name, the
+		// repository may exist even though none of them matched.
+		if !found && repo != project && name == "" {
+			yield(nil, seaglass.ErrNotFound)
+		}
+	}
+}
+
+// listProjects yields the projects at the root of the registry and, if
+// recursive, all the repositories in each project
+func (c *Client) listProjects(ctx context.Context, opts *seaglass.RepositoryListOptions, yield func(*seaglass.RepositoryList, error) bool) {
+	next := c.url("/projects", nil)
+	for next != "" {
+		var body []struct {
+			Name string `json:"name"`
+		}
+		n, err := c.get(ctx, next, &body)
+		if err != nil {
+			yield(nil, fmt.Errorf("listing projects: %w", err))
+			return
+		}
+
+		for _, p := range body {
+			if !yield(&seaglass.RepositoryList{Repositories: []string{p.Name}}, nil) {
+				return
+			}
+
+			if opts == nil || !opts.Recursive || opts.MaxDepth == 1 {
+				continue
+			}
+
+			for children, err := range c.listRepositoryPages(ctx, p.Name, opts) {
+				if err != nil {
+					yield(nil, fmt.Errorf("listing repositories for project %s: %w", p.Name, err))
+					return
+				}
+
+				var repos []string
+				for _, child := range children.Repositories {
+					repos = append(repos, fmt.Sprintf("%s/%s", p.Name, child))
+				}
+				if !yield(&seaglass.RepositoryList{Repositories: repos}, nil) {
+					return
+				}
+			}
+		}
+
+		next = n
+	}
+}
+
+// ListManifests lists the manifests in the repository using the artifacts API,
+// which returns the tags, push time and pull time of every artifact.
+func (c *Client) ListManifests(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) (*seaglass.ManifestList, error) {
+	return seaglass.CollectManifests(c.ListManifestPages(ctx, repo, opts))
+}
+
+// ListManifestPages lists the manifests in the repository, yielding each page
+// of artifacts.
+func (c *Client) ListManifestPages(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) iter.Seq2[*seaglass.ManifestList, error] {
+	return seaglass.FilterManifestPages(c.Resolve(ctx, repo, seaglass.GroupArtifactPages(c.listManifestPages(ctx, repo, opts), opts), opts), opts)
+}
+
+func (c *Client) listManifestPages(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) iter.Seq2[*seaglass.ManifestList, error] {
+	return func(yield func(*seaglass.ManifestList, error) bool) {
+		project, repository := parseRepo(repo)
+
+		// The project itself doesn't host any manifests
+		if repository == "" {
+			return
+		}
+
+		// Harbor expects slashes in the repository name to be double
+		// encoded
+		path := fmt.Sprintf(
+			"/projects/%s/repositories/%s/artifacts",
+			url.PathEscape(project),
+			url.PathEscape(url.PathEscape(repository)),
+		)
+		next := c.url(path, url.Values{"with_tag": []string{"true"}})
+		for next != "" {
+			var body []struct {
+				Digest            string    `json:"digest"`
+				ManifestMediaType string    `json:"manifest_media_type"`
+				Size              int64     `json:"size"`
+				PushTime          time.Time `json:"push_time"`
+				PullTime          time.Time `json:"pull_time"`
+				ExtraAttrs        struct {
+					Created      time.Time `json:"created"`
+					OS           string    `json:"os"`
+					Architecture string    `json:"architecture"`
+				} `json:"extra_attrs"`
+				Tags []struct {
+					Name string `json:"name"`
+				} `json:"tags"`
+				References []struct {
+					ChildDigest string `json:"child_digest"`
+					Platform    *struct {
+						OS           string `json:"os"`
+						Architecture string `json:"architecture"`
+						Variant      string `json:"variant"`
+					} `json:"platform"`
+				} `json:"references"`
+			}
+			n, err := c.get(ctx, next, &body)
+			if err != nil {
+				yield(nil, fmt.Errorf("listing artifacts: %w", err))
+				return
+			}
+
+			var manifests []seaglass.Manifest
+			for _, a := range body {
+				if a.Digest == "" {
+					continue
+				}
+
+				manifest := seaglass.Manifest{
+					Digest:    a.Digest,
+					MediaType: a.ManifestMediaType,
+					Size:      a.Size,
+				}
+				for _, tag := range a.Tags {
+					manifest.Tags = append(manifest.Tags, tag.Name)
+				}
+				if !a.ExtraAttrs.Created.IsZero() {
+					manifest.Created = &a.ExtraAttrs.Created
+				}
+				// Harbor reports the platform of images and the
+				// manifests in indexes, so they don't have to be
+				// resolved separately
+				if a.ExtraAttrs.OS != "" {
+					manifest.Platform = &seaglass.Platform{
+						OS:           a.ExtraAttrs.OS,
+						Architecture: a.ExtraAttrs.Architecture,
+					}
+				}
+				for _, ref := range a.References {
+					d := seaglass.Descriptor{Digest: ref.ChildDigest}
+					if ref.Platform != nil {
+						d.Platform = &seaglass.Platform{
+							OS:           ref.Platform.OS,
+							Architecture: ref.Platform.Architecture,
+							Variant:      ref.Platform.Variant,
+						}
+					}
+					manifest.Manifests = append(manifest.Manifests, d)
+				}
+				if !a.PushTime.IsZero() {
+					manifest.Uploaded = &a.PushTime
+				}
+				// Harbor reports the zero time as the pull time
+				// of artifacts that have never been pulled
+				if !a.PullTime.IsZero() {
+					manifest.Pulled = &a.PullTime
+				}
+
+				manifests = append(manifests, manifest)
+			}
+
+			if !yield(&seaglass.ManifestList{Manifests: manifests}, nil) {
+				return
+			}
+
+			next = n
+		}
+	}
+}
+
+// probe returns true if the host is running Harbor, reusing the result of an
+// earlier probe of the same host
+func (c *Client) probe() bool {
+	if c.apiURL.Scheme == "http" {
+		return false
+	}
+
+	probedMu.Lock()
+	ok, found := probed[c.apiURL.Host]
+	probedMu.Unlock()
+	if found {
+		return ok
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+
+	ok = c.isHarbor(ctx)
+
+	probedMu.Lock()
+	probed[c.apiURL.Host] = ok
+	probedMu.Unlock()
+
+	return ok
+}
+
+// isHarbor returns true if the systeminfo endpoint responds with a Harbor
+// version
+func (c *Client) isHarbor(ctx context.Context) bool {
+	var body struct {
+		HarborVersion string `json:"harbor_version"`
+	}
+	if _, err := c.get(ctx, c.url("/systeminfo", nil), &body); err != nil {
+		return false
+	}
+
+	return body.HarborVersion != ""
+}
+
+// get fetches the url and decodes the response into v. Returns the url of the
+// next page of results, if there is one.
+func (c *Client) get(ctx context.Context, u string, v any) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", seaglass.ErrNotFound
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected response code: %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return "", fmt.Errorf("decoding body: %w", err)
+	}
+
+	return next(resp)
+}
+
+func (c *Client) url(path string, query url.Values) string {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("page_size", fmt.Sprint(pageSize))
+
+	// The path is concatenated, rather than joined, so that escaped
+	// characters in the path are preserved
+	return fmt.Sprintf("%s%s?%s", c.apiURL, path, query.Encode())
+}
+
+// next parses the url of the next page from the Link header, which may
+// contain both a "prev" and a "next" link.
+func next(resp *http.Response) (string, error) {
+	for _, link := range strings.Split(resp.Header.Get("Link"), ",") {
+		if !strings.Contains(link, `rel="next"`) {
+			continue
+		}
+
+		start, end := strings.Index(link, "<"), strings.Index(link, ">")
+		if start < 0 || end < start {
+			return "", fmt.Errorf("parsing link header: %s", link)
+		}
+
+		u, err := url.Parse(link[start+1 : end])
+		if err != nil {
+			return "", fmt.Errorf("parsing link header: %w", err)
+		}
+
+		return resp.Request.URL.ResolveReference(u).String(), nil
+	}
+
+	return "", nil
+}
+
+func parseRepo(repo string) (project, repository string) {
+	parts := strings.SplitN(repo, "/", 2)
+	project = parts[0]
+	if len(parts) > 1 {
+		repository = parts[1]
+	}
+
+	return project, repository
+}
@@ -0,0 +1,522 @@
+package harbor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+)
+
+func sortStrings(a, b string) bool {
+	return a < b
+}
+
+func sortManifests(a, b seaglass.Manifest) bool {
+	return a.Digest < b.Digest
+}
+
+func TestNewClient(t *testing.T) {
+	t.Run("plain http host", func(t *testing.T) {
+		host := setupHarbor(t, &fakeHarbor{})
+
+		_, err := NewClient(host)
+		if !errors.Is(err, seaglass.ErrNotSupported) {
+			t.Errorf("expected ErrNotSupported, got: %v", err)
+		}
+	})
+
+	t.Run("probed host", func(t *testing.T) {
+		probedMu.Lock()
+		probed["harbor.example.com"] = true
+		probedMu.Unlock()
+		t.Cleanup(func() {
+			probedMu.Lock()
+			delete(probed, "harbor.example.com")
+			probedMu.Unlock()
+		})
+
+		if _, err := NewClient("harbor.example.com"); err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+	})
+}
+
+func TestClientIsHarbor(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("harbor", func(t *testing.T) {
+		c := setupClient(t, &fakeHarbor{})
+
+		if !c.isHarbor(ctx) {
+			t.Errorf("expected host to be detected as Harbor")
+		}
+	})
+
+	t.Run("not harbor", func(t *testing.T) {
+		srv := httptest.NewServer(http.NotFoundHandler())
+		t.Cleanup(srv.Close)
+
+		u, err := url.Parse(srv.URL)
+		if err != nil {
+			t.Fatalf("unexpected error parsing server url: %s", err)
+		}
+
+		c, err := newClient(u.Host)
+		if err != nil {
+			t.Fatalf("unexpected error creating client: %s", err)
+		}
+
+		if c.isHarbor(ctx) {
+			t.Errorf("expected host not to be detected as Harbor")
+		}
+	})
+}
+
+func TestClientListRepositories(t *testing.T) {
+	h := &fakeHarbor{
+		projects: map[string][]string{
+			"foo": {"foo/bar", "foo/bar/baz", "foo/baz", "foo/baz/bar/foo"},
+			"qux": {},
+		},
+	}
+
+	t.Run("listing a project", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, h)
+
+		gotList, err := c.ListRepositories(ctx, "foo", nil)
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		wantList := &seaglass.RepositoryList{
+			Name: "foo",
+			Repositories: []string{
+				"bar",
+				"baz",
+			},
+		}
+		if diff := cmp.Diff(wantList, gotList, cmpopts.SortSlices(sortStrings)); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("listing a sub-repository recursive", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, h)
+
+		gotList, err := c.ListRepositories(ctx, "foo/baz", &seaglass.RepositoryListOptions{Recursive: true})
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		wantList := &seaglass.RepositoryList{
+			Name: "foo/baz",
+			Repositories: []string{
+				"bar/foo",
+			},
+		}
+		if diff := cmp.Diff(wantList, gotList, cmpopts.SortSlices(sortStrings)); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("listing a project with filters", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, h)
+
+		gotList, err := c.ListRepositories(ctx, "foo", &seaglass.RepositoryListOptions{
+			Recursive: true,
+			MaxDepth:  2,
+			Include:   []string{"ba*/*"},
+			Exclude:   []string{"*/bar"},
+		})
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		wantList := &seaglass.RepositoryList{
+			Name: "foo",
+			Repositories: []string{
+				"bar/baz",
+			},
+		}
+		if diff := cmp.Diff(wantList, gotList, cmpopts.SortSlices(sortStrings)); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("searching a sub-repository by name", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, h)
+
+		gotList, err := c.ListRepositories(ctx, "foo/bar", &seaglass.RepositoryListOptions{
+			Recursive:    true,
+			NameContains: "qux",
+		})
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		wantList := &seaglass.RepositoryList{
+			Name: "foo/bar",
+		}
+		if diff := cmp.Diff(wantList, gotList, cmpopts.SortSlices(sortStrings)); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("listing the projects recursive", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, h)
+
+		gotList, err := c.ListRepositories(ctx, "", &seaglass.RepositoryListOptions{Recursive: true})
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		wantList := &seaglass.RepositoryList{
+			Repositories: []string{
+				"foo",
+				"foo/bar",
+				"foo/bar/baz",
+				"foo/baz",
+				"foo/baz/bar/foo",
+				"qux",
+			},
+		}
+		if diff := cmp.Diff(wantList, gotList, cmpopts.SortSlices(sortStrings)); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("listing an empty project", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, h)
+
+		gotList, err := c.ListRepositories(ctx, "qux", nil)
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		wantList := &seaglass.RepositoryList{
+			Name: "qux",
+		}
+		if diff := cmp.Diff(wantList, gotList); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("listing a project that doesn't exist", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, h)
+
+		gotList, err := c.ListRepositories(ctx, "bar", nil)
+		if !errors.Is(err, seaglass.ErrNotFound) {
+			t.Errorf("unexpected error: %s", err)
+		}
+		if gotList != nil {
+			t.Errorf("unexpected response: %v", gotList)
+		}
+	})
+}
+
+func TestClientListManifests(t *testing.T) {
+	created := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	pushed := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	pulled := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	h := &fakeHarbor{
+		artifacts: map[string][]artifact{
+			"foo/bar/baz": {
+				newArtifact("sha256:aaaa", 1024, created, pushed, pulled, "latest", "v1"),
+				newArtifact("sha256:bbbb", 2048, created, pushed, time.Time{}),
+			},
+		},
+	}
+
+	t.Run("list manifests", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, h)
+
+		gotList, err := c.ListManifests(ctx, "foo/bar/baz", nil)
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		wantList := &seaglass.ManifestList{
+			Manifests: []seaglass.Manifest{
+				{
+					Digest:    "sha256:aaaa",
+					MediaType: "application/vnd.oci.image.manifest.v1+json",
+					Tags:      []string{"latest", "v1"},
+					Size:      1024,
+					Created:   &created,
+					Uploaded:  &pushed,
+					Pulled:    &pulled,
+				},
+				{
+					Digest:    "sha256:bbbb",
+					MediaType: "application/vnd.oci.image.manifest.v1+json",
+					Size:      2048,
+					Created:   &created,
+					Uploaded:  &pushed,
+				},
+			},
+		}
+		if diff := cmp.Diff(wantList, gotList, cmpopts.SortSlices(sortManifests)); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("list manifests for a platform", func(t *testing.T) {
+		ctx := context.Background()
+
+		index := newArtifact("sha256:cccc", 512, created, pushed, time.Time{}, "latest")
+		index.ManifestMediaType = "application/vnd.oci.image.index.v1+json"
+		index.References = []reference{
+			newReference("sha256:dddd", "linux", "amd64"),
+			newReference("sha256:eeee", "linux", "arm64"),
+		}
+		image := newArtifact("sha256:ffff", 1024, created, pushed, time.Time{}, "amd64")
+		image.ExtraAttrs.OS = "linux"
+		image.ExtraAttrs.Architecture = "amd64"
+
+		c := setupClient(t, &fakeHarbor{
+			projects: h.projects,
+			artifacts: map[string][]artifact{
+				"foo/bar/baz": {index, image},
+			},
+		})
+
+		gotList, err := c.ListManifests(ctx, "foo/bar/baz", &seaglass.ManifestListOptions{
+			Platforms: []string{"linux/arm64"},
+		})
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		wantList := &seaglass.ManifestList{
+			Manifests: []seaglass.Manifest{
+				{
+					Digest:    "sha256:cccc",
+					MediaType: "application/vnd.oci.image.index.v1+json",
+					Tags:      []string{"latest"},
+					Size:      512,
+					Created:   &created,
+					Uploaded:  &pushed,
+					Manifests: []seaglass.Descriptor{
+						{Digest: "sha256:dddd", Platform: &seaglass.Platform{OS: "linux", Architecture: "amd64"}},
+						{Digest: "sha256:eeee", Platform: &seaglass.Platform{OS: "linux", Architecture: "arm64"}},
+					},
+				},
+			},
+		}
+		if diff := cmp.Diff(wantList, gotList, cmpopts.SortSlices(sortManifests)); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("list manifests in a project", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, h)
+
+		gotList, err := c.ListManifests(ctx, "foo", nil)
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		if diff := cmp.Diff(&seaglass.ManifestList{}, gotList); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("repository not found", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, h)
+
+		gotList, err := c.ListManifests(ctx, "foo/baz", nil)
+		if !errors.Is(err, seaglass.ErrNotFound) {
+			t.Errorf("unexpected error: %s", err)
+		}
+		if gotList != nil {
+			t.Errorf("unexpected response: %v", gotList)
+		}
+	})
+}
+
+type artifact struct {
+	Digest            string    `json:"digest"`
+	ManifestMediaType string    `json:"manifest_media_type"`
+	Size              int64     `json:"size"`
+	PushTime          time.Time `json:"push_time"`
+	PullTime          time.Time `json:"pull_time"`
+	ExtraAttrs        struct {
+		Created      time.Time `json:"created,omitempty"`
+		OS           string    `json:"os,omitempty"`
+		Architecture string    `json:"architecture,omitempty"`
+	} `json:"extra_attrs"`
+	Tags []struct {
+		Name string `json:"name"`
+	} `json:"tags"`
+	References []reference `json:"references,omitempty"`
+}
+
+type reference struct {
+	ChildDigest string `json:"child_digest"`
+	Platform    struct {
+		OS           string `json:"os"`
+		Architecture string `json:"architecture"`
+	} `json:"platform"`
+}
+
+func newReference(digest, os, arch string) reference {
+	r := reference{ChildDigest: digest}
+	r.Platform.OS = os
+	r.Platform.Architecture = arch
+
+	return r
+}
+
+func newArtifact(digest string, size int64, created, pushed, pulled time.Time, tags ...string) artifact {
+	a := artifact{
+		Digest:            digest,
+		ManifestMediaType: "application/vnd.oci.image.manifest.v1+json",
+		Size:              size,
+		PushTime:          pushed,
+		PullTime:          pulled,
+	}
+	a.ExtraAttrs.Created = created
+	for _, tag := range tags {
+		a.Tags = append(a.Tags, struct {
+			Name string `json:"name"`
+		}{Name: tag})
+	}
+
+	return a
+}
+
+// fakeHarbor is a fake implementation of the Harbor v2.0 API. Lists are
+// served one item per page to exercise pagination.
+type fakeHarbor struct {
+	// projects maps project names to the repositories in the project
+	projects map[string][]string
+
+	// artifacts maps repository names to their artifacts
+	artifacts map[string][]artifact
+}
+
+func (h *fakeHarbor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// page writes a single item from the list, with a link to the next page
+	page := func(items []any) {
+		p := 1
+		fmt.Sscan(r.URL.Query().Get("page"), &p)
+		if p < len(items) {
+			q := r.URL.Query()
+			q.Set("page", fmt.Sprint(p+1))
+			w.Header().Set("Link", fmt.Sprintf(`<%s?%s>; rel="next"`, r.URL.EscapedPath(), q.Encode()))
+		}
+		if len(items) == 0 {
+			json.NewEncoder(w).Encode([]any{})
+			return
+		}
+		json.NewEncoder(w).Encode(items[p-1 : p])
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.EscapedPath(), "/api/v2.0/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "systeminfo":
+		json.NewEncoder(w).Encode(map[string]string{"harbor_version": "v2.10.0"})
+	case len(parts) == 1 && parts[0] == "projects":
+		var items []any
+		for _, p := range slices.Sorted(maps.Keys(h.projects)) {
+			items = append(items, map[string]string{"name": p})
+		}
+		page(items)
+	case len(parts) == 3 && parts[0] == "projects" && parts[2] == "repositories":
+		repos, ok := h.projects[parts[1]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var items []any
+		for _, repo := range repos {
+			q := r.URL.Query().Get("q")
+			if name, ok := strings.CutPrefix(q, "name=~"); ok && !strings.Contains(repo, name) {
+				continue
+			}
+			items = append(items, map[string]string{"name": repo})
+		}
+		page(items)
+	case len(parts) == 5 && parts[0] == "projects" && parts[2] == "repositories" && parts[4] == "artifacts":
+		if r.URL.Query().Get("with_tag") != "true" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		repo, err := url.PathUnescape(parts[3])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		repo, err = url.PathUnescape(repo)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		artifacts, ok := h.artifacts[fmt.Sprintf("%s/%s", parts[1], repo)]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var items []any
+		for _, a := range artifacts {
+			items = append(items, a)
+		}
+		page(items)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func setupHarbor(t *testing.T, h *fakeHarbor) string {
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error parsing server url: %s", err)
+	}
+
+	return u.Host
+}
+
+func setupClient(t *testing.T, h *fakeHarbor) *Client {
+	c, err := newClient(setupHarbor(t, h))
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %s", err)
+	}
+
+	return c
+}
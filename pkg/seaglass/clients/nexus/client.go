@@ -0,0 +1,359 @@
+package nexus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/jetstack/seaglass/internal/inspect"
+	"github.com/jetstack/seaglass/internal/transport"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+)
+
+// Client is a client for Sonatype Nexus Repository
+type Client struct {
+	*inspect.Inspector
+
+	apiURL     *url.URL
+	httpClient *http.Client
+}
+
+// NewSelfHostedClient returns a new client for a Nexus Repository instance.
+//
+// Nexus must be configured to use path based routing for Docker repositories,
+// so that the first component of the repository is the name of the Nexus
+// repository.
+func NewSelfHostedClient(host string, opts ...seaglass.Option) (seaglass.Client, error) {
+	registry, err := name.NewRegistry(host)
+	if err != nil {
+		return nil, fmt.Errorf("parsing host: %w", err)
+	}
+
+	// Nexus accepts the same credentials for the API as it does for the
+	// registry
+	o := seaglass.NewClientOptions(opts...)
+	rt := o.Transport(0, 0)
+	httpClient := o.HTTPClient(transport.NewTransport(rt, o.Keychain(), registry))
+
+	inspector, err := inspect.New(registry, o.RemoteOptions(rt)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		Inspector: inspector,
+		apiURL: &url.URL{
+			Scheme: registry.Scheme(),
+			Host:   registry.RegistryStr(),
+			Path:   "/service/rest/v1",
+		},
+		httpClient: httpClient,
+	}, nil
+}
+
+// component is a Docker component returned by the search API. The name is
+// the image name and the version is the tag.
+type component struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Assets  []struct {
+		Path           string    `json:"path"`
+		ContentType    string    `json:"contentType"`
+		LastModified   time.Time `json:"lastModified"`
+		LastDownloaded time.Time `json:"lastDownloaded"`
+		BlobCreated    time.Time `json:"blobCreated"`
+		Checksum       struct {
+			SHA256 string `json:"sha256"`
+		} `json:"checksum"`
+	} `json:"assets"`
+}
+
+// ListRepositories lists the child repositories of the specified repository.
+//
+// At the root of the registry, this lists the Docker repositories in Nexus.
+// Otherwise, it searches for the Docker components in the Nexus repository.
+func (c *Client) ListRepositories(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) (*seaglass.RepositoryList, error) {
+	return seaglass.CollectRepositories(repo, c.ListRepositoryPages(ctx, repo, opts))
+}
+
+// ListRepositoryPages lists the child repositories of the specified
+// repository, yielding the new repositories from each page of search results.
+func (c *Client) ListRepositoryPages(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) iter.Seq2[*seaglass.RepositoryList, error] {
+	return seaglass.FilterRepositoryPages(c.listRepositoryPages(ctx, repo, opts), opts)
+}
+
+func (c *Client) listRepositoryPages(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) iter.Seq2[*seaglass.RepositoryList, error] {
+	return func(yield func(*seaglass.RepositoryList, error) bool) {
+		if repo == "" {
+			c.listNexusRepositories(ctx, opts, yield)
+			return
+		}
+
+		repository, image := parseRepo(repo)
+
+		found := false
+		childMap := map[string]struct{}{}
+		for components, err := range c.search(ctx, repository, "") {
+			if err != nil {
+				yield(nil, fmt.Errorf("searching for components: %w", err))
+				return
+			}
+
+			var children []string
+			for _, comp := range components {
+				if comp.Name == image {
+					found = true
+					continue
+				}
+
+				prefix := fmt.Sprintf("%s/", image)
+				if image != "" && !strings.HasPrefix(comp.Name, prefix) {
+					continue
+				}
+				found = true
+
+				// There's a component per tag, so the same image
+				// will be seen more than once, even when recursive
+				relativePath := strings.TrimPrefix(comp.Name, prefix)
+				child := relativePath
+				if opts == nil || !opts.Recursive {
+					child = strings.Split(relativePath, "/")[0]
+				}
+				if _, ok := childMap[child]; !ok {
+					children = append(children, child)
+				}
+				childMap[child] = struct{}{}
+			}
+
+			if len(children) > 0 && !yield(&seaglass.RepositoryList{Name: repo, Repositories: children}, nil) {
+				return
+			}
+		}
+
+		// The Nexus repository is a valid repository to list from, even
+		// if it's empty
+		if !found && image != "" {
+			yield(nil, seaglass.ErrNotFound)
+		}
+	}
+}
+
+// listNexusRepositories yields the Docker repositories in Nexus and, if
+// recursive, the images in each of them
+func (c *Client) listNexusRepositories(ctx context.Context, opts *seaglass.RepositoryListOptions, yield func(*seaglass.RepositoryList, error) bool) {
+	var body []struct {
+		Name   string `json:"name"`
+		Format string `json:"format"`
+	}
+	if err := c.get(ctx, "/repositories", nil, &body); err != nil {
+		yield(nil, fmt.Errorf("listing repositories: %w", err))
+		return
+	}
+
+	for _, r := range body {
+		if r.Format != "docker" {
+			continue
+		}
+		if !yield(&seaglass.RepositoryList{Repositories: []string{r.Name}}, nil) {
+			return
+		}
+
+		if opts == nil || !opts.Recursive || opts.MaxDepth == 1 {
+			continue
+		}
+
+		for children, err := range c.listRepositoryPages(ctx, r.Name, opts) {
+			if err != nil {
+				yield(nil, fmt.Errorf("listing repositories for %s: %w", r.Name, err))
+				return
+			}
+
+			var repos []string
+			for _, child := range children.Repositories {
+				repos = append(repos, fmt.Sprintf("%s/%s", r.Name, child))
+			}
+			if !yield(&seaglass.RepositoryList{Repositories: repos}, nil) {
+				return
+			}
+		}
+	}
+}
+
+// ListManifests lists the manifests in the repository. The search API returns
+// a component for every tag, with the manifest as its asset.
+func (c *Client) ListManifests(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) (*seaglass.ManifestList, error) {
+	return seaglass.CollectManifests(c.ListManifestPages(ctx, repo, opts))
+}
+
+// ListManifestPages lists the manifests in the repository, yielding the
+// manifests from each page of search results
+func (c *Client) ListManifestPages(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) iter.Seq2[*seaglass.ManifestList, error] {
+	return seaglass.FilterManifestPages(c.Resolve(ctx, repo, seaglass.GroupArtifactPages(c.listManifestPages(ctx, repo, opts), opts), opts), opts)
+}
+
+func (c *Client) listManifestPages(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) iter.Seq2[*seaglass.ManifestList, error] {
+	return func(yield func(*seaglass.ManifestList, error) bool) {
+		repository, image := parseRepo(repo)
+
+		// The Nexus repository itself doesn't host any manifests
+		if image == "" {
+			return
+		}
+
+		found := false
+		for components, err := range c.search(ctx, repository, image) {
+			if err != nil {
+				yield(nil, fmt.Errorf("searching for components: %w", err))
+				return
+			}
+
+			manifests := componentManifests(image, components)
+			if len(manifests) == 0 {
+				continue
+			}
+			found = true
+
+			if !yield(&seaglass.ManifestList{Manifests: manifests}, nil) {
+				return
+			}
+		}
+
+		if !found {
+			yield(nil, seaglass.ErrNotFound)
+		}
+	}
+}
+
+// componentManifests groups the manifest assets of the image's components by
+// digest
+func componentManifests(image string, components []component) []seaglass.Manifest {
+	manifestMap := map[string]*seaglass.Manifest{}
+	for _, comp := range components {
+		// The search is a keyword search, so it may return images that
+		// have a similar name
+		if comp.Name != image {
+			continue
+		}
+
+		for _, asset := range comp.Assets {
+			if asset.Checksum.SHA256 == "" || !strings.Contains(asset.Path, "/manifests/") {
+				continue
+			}
+			digest := fmt.Sprintf("sha256:%s", asset.Checksum.SHA256)
+
+			manifest, ok := manifestMap[digest]
+			if !ok {
+				manifest = &seaglass.Manifest{
+					Digest:    digest,
+					MediaType: asset.ContentType,
+				}
+				manifestMap[digest] = manifest
+			}
+			if comp.Version != "" && !strings.HasPrefix(comp.Version, "sha256:") {
+				manifest.Tags = append(manifest.Tags, comp.Version)
+			}
+
+			if !asset.BlobCreated.IsZero() && (manifest.Uploaded == nil || asset.BlobCreated.Before(*manifest.Uploaded)) {
+				manifest.Uploaded = &asset.BlobCreated
+			}
+			if !asset.LastModified.IsZero() && (manifest.Updated == nil || asset.LastModified.After(*manifest.Updated)) {
+				manifest.Updated = &asset.LastModified
+			}
+			if !asset.LastDownloaded.IsZero() && (manifest.Pulled == nil || asset.LastDownloaded.After(*manifest.Pulled)) {
+				manifest.Pulled = &asset.LastDownloaded
+			}
+		}
+	}
+
+	var manifests []seaglass.Manifest
+	for _, manifest := range manifestMap {
+		sort.Strings(manifest.Tags)
+		manifests = append(manifests, *manifest)
+	}
+
+	sort.Slice(manifests, func(i, j int) bool {
+		return manifests[i].Digest < manifests[j].Digest
+	})
+
+	return manifests
+}
+
+// search yields each page of Docker components in the Nexus repository,
+// optionally filtered by image name
+func (c *Client) search(ctx context.Context, repository, image string) iter.Seq2[[]component, error] {
+	return func(yield func([]component, error) bool) {
+		query := url.Values{}
+		query.Set("format", "docker")
+		query.Set("repository", repository)
+		if image != "" {
+			query.Set("name", image)
+		}
+		for {
+			var body struct {
+				Items             []component `json:"items"`
+				ContinuationToken string      `json:"continuationToken"`
+			}
+			if err := c.get(ctx, "/search", query, &body); err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(body.Items, nil) {
+				return
+			}
+
+			if body.ContinuationToken == "" {
+				break
+			}
+
+			query.Set("continuationToken", body.ContinuationToken)
+		}
+	}
+}
+
+// get fetches the path from the API and decodes the response into v
+func (c *Client) get(ctx context.Context, path string, query url.Values, v any) error {
+	u := c.apiURL.JoinPath(path)
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return seaglass.ErrNotFound
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response code: %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decoding body: %w", err)
+	}
+
+	return nil
+}
+
+func parseRepo(repo string) (repository, image string) {
+	parts := strings.SplitN(repo, "/", 2)
+	repository = parts[0]
+	if len(parts) > 1 {
+		image = parts[1]
+	}
+
+	return repository, image
+}
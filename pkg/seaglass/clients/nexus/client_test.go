@@ -0,0 +1,267 @@
+package nexus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+)
+
+func sortStrings(a, b string) bool {
+	return a < b
+}
+
+func TestClientListRepositories(t *testing.T) {
+	n := &fakeNexus{
+		components: map[string][]fakeComponent{
+			"docker-hosted": {
+				{Name: "foo/bar", Version: "v1"},
+				{Name: "foo/bar", Version: "v2"},
+				{Name: "foo/bar/baz", Version: "v1"},
+				{Name: "foo/baz", Version: "v1"},
+				{Name: "qux", Version: "v1"},
+			},
+		},
+	}
+
+	t.Run("listing a nexus repository", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, n)
+
+		gotList, err := c.ListRepositories(ctx, "docker-hosted", nil)
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		wantList := &seaglass.RepositoryList{
+			Name: "docker-hosted",
+			Repositories: []string{
+				"foo",
+				"qux",
+			},
+		}
+		if diff := cmp.Diff(wantList, gotList, cmpopts.SortSlices(sortStrings)); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("listing an image recursive", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, n)
+
+		gotList, err := c.ListRepositories(ctx, "docker-hosted/foo", &seaglass.RepositoryListOptions{Recursive: true})
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		wantList := &seaglass.RepositoryList{
+			Name: "docker-hosted/foo",
+			Repositories: []string{
+				"bar",
+				"bar/baz",
+				"baz",
+			},
+		}
+		if diff := cmp.Diff(wantList, gotList, cmpopts.SortSlices(sortStrings)); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("listing the root", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, n)
+
+		gotList, err := c.ListRepositories(ctx, "", nil)
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		wantList := &seaglass.RepositoryList{
+			Repositories: []string{
+				"docker-hosted",
+			},
+		}
+		if diff := cmp.Diff(wantList, gotList); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("listing an image that doesn't exist", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, n)
+
+		gotList, err := c.ListRepositories(ctx, "docker-hosted/bar", nil)
+		if !errors.Is(err, seaglass.ErrNotFound) {
+			t.Errorf("unexpected error: %s", err)
+		}
+		if gotList != nil {
+			t.Errorf("unexpected response: %v", gotList)
+		}
+	})
+}
+
+func TestClientListManifests(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	modified := time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC)
+	downloaded := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	n := &fakeNexus{
+		components: map[string][]fakeComponent{
+			"docker-hosted": {
+				{Name: "foo/bar", Version: "v1", SHA256: "aaaa", Created: created, Modified: created, Downloaded: downloaded},
+				{Name: "foo/bar", Version: "latest", SHA256: "aaaa", Created: modified, Modified: modified},
+				{Name: "foo/bar", Version: "v0", SHA256: "bbbb", Created: created, Modified: created},
+				{Name: "foo/bar/baz", Version: "v1", SHA256: "cccc", Created: created, Modified: created},
+			},
+		},
+	}
+
+	t.Run("list manifests", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, n)
+
+		gotList, err := c.ListManifests(ctx, "docker-hosted/foo/bar", nil)
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		wantList := &seaglass.ManifestList{
+			Manifests: []seaglass.Manifest{
+				{
+					Digest:    "sha256:aaaa",
+					MediaType: "application/vnd.oci.image.manifest.v1+json",
+					Tags:      []string{"latest", "v1"},
+					Uploaded:  &created,
+					Updated:   &modified,
+					Pulled:    &downloaded,
+				},
+				{
+					Digest:    "sha256:bbbb",
+					MediaType: "application/vnd.oci.image.manifest.v1+json",
+					Tags:      []string{"v0"},
+					Uploaded:  &created,
+					Updated:   &created,
+				},
+			},
+		}
+		if diff := cmp.Diff(wantList, gotList); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("repository not found", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, n)
+
+		gotList, err := c.ListManifests(ctx, "docker-hosted/foo/qux", nil)
+		if !errors.Is(err, seaglass.ErrNotFound) {
+			t.Errorf("unexpected error: %s", err)
+		}
+		if gotList != nil {
+			t.Errorf("unexpected response: %v", gotList)
+		}
+	})
+}
+
+type fakeComponent struct {
+	Name       string
+	Version    string
+	SHA256     string
+	Created    time.Time
+	Modified   time.Time
+	Downloaded time.Time
+}
+
+// fakeNexus is a fake implementation of the Nexus API. Search results are
+// served one item per page to exercise pagination.
+type fakeNexus struct {
+	// components maps nexus repositories to their components
+	components map[string][]fakeComponent
+}
+
+func (n *fakeNexus) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	switch r.URL.Path {
+	case "/service/rest/v1/repositories":
+		repos := []map[string]string{{"name": "maven-central", "format": "maven2"}}
+		for name := range n.components {
+			repos = append(repos, map[string]string{"name": name, "format": "docker"})
+		}
+		json.NewEncoder(w).Encode(repos)
+	case "/service/rest/v1/search":
+		if query.Get("format") != "docker" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var items []map[string]any
+		for _, comp := range n.components[query.Get("repository")] {
+			// Emulate the keyword search by matching on prefix
+			if !strings.HasPrefix(comp.Name, query.Get("name")) {
+				continue
+			}
+			items = append(items, map[string]any{
+				"name":    comp.Name,
+				"version": comp.Version,
+				"assets": []map[string]any{
+					{
+						"path":           fmt.Sprintf("v2/%s/manifests/%s", comp.Name, comp.Version),
+						"contentType":    "application/vnd.oci.image.manifest.v1+json",
+						"lastModified":   comp.Modified,
+						"lastDownloaded": comp.Downloaded,
+						"blobCreated":    comp.Created,
+						"checksum":       map[string]string{"sha256": comp.SHA256},
+					},
+				},
+			})
+		}
+
+		i := 0
+		fmt.Sscan(query.Get("continuationToken"), &i)
+
+		resp := map[string]any{"items": []any{}}
+		if i < len(items) {
+			resp["items"] = items[i : i+1]
+		}
+		if i+1 < len(items) {
+			resp["continuationToken"] = fmt.Sprint(i + 1)
+		}
+		json.NewEncoder(w).Encode(resp)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func setupClient(t *testing.T, n *fakeNexus) seaglass.Client {
+	srv := httptest.NewServer(n)
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error parsing server url: %s", err)
+	}
+
+	c, err := NewSelfHostedClient(u.Host)
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %s", err)
+	}
+
+	return c
+}
@@ -0,0 +1,305 @@
+package quay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/jetstack/seaglass/internal/inspect"
+	"github.com/jetstack/seaglass/internal/transport"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+)
+
+// pageSize is the number of tags to request per page
+const pageSize = 100
+
+// Client is a client for Quay.io and Red Hat Quay
+type Client struct {
+	*inspect.Inspector
+
+	apiURL     *url.URL
+	httpClient *http.Client
+}
+
+// NewClient returns a new client for Quay.io
+func NewClient(host string, opts ...seaglass.Option) (seaglass.Client, error) {
+	if host != "quay.io" {
+		return nil, seaglass.ErrNotSupported
+	}
+
+	return NewSelfHostedClient(host, opts...)
+}
+
+// NewSelfHostedClient returns a new client for a Quay instance running on any
+// host, i.e a self-hosted Red Hat Quay installation
+func NewSelfHostedClient(host string, opts ...seaglass.Option) (seaglass.Client, error) {
+	registry, err := name.NewRegistry(host)
+	if err != nil {
+		return nil, fmt.Errorf("parsing host: %w", err)
+	}
+
+	// Use the credentials for the registry to authenticate to the API.
+	// The API doesn't support basic auth, so this is only useful when
+	// the keychain provides a token, but public repositories can be
+	// listed anonymously.
+	o := seaglass.NewClientOptions(opts...)
+	rt := o.Transport(0, 0)
+	httpClient := o.HTTPClient(transport.NewTransport(rt, o.Keychain(), registry))
+
+	inspector, err := inspect.New(registry, o.RemoteOptions(rt)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		Inspector: inspector,
+		apiURL: &url.URL{
+			Scheme: registry.Scheme(),
+			Host:   registry.RegistryStr(),
+			Path:   "/api/v1",
+		},
+		httpClient: httpClient,
+	}, nil
+}
+
+// ListRepositories lists the child repositories of the specified repository.
+// The first component of the repository is the Quay namespace (an
+// organization or user). Quay can't list namespaces, so listing the root of the
+// registry isn't supported.
+func (c *Client) ListRepositories(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) (*seaglass.RepositoryList, error) {
+	return seaglass.CollectRepositories(repo, c.ListRepositoryPages(ctx, repo, opts))
+}
+
+// ListRepositoryPages lists the child repositories of the specified
+// repository, yielding the matching repositories from each page of the
+// namespace's repositories.
+func (c *Client) ListRepositoryPages(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) iter.Seq2[*seaglass.RepositoryList, error] {
+	return seaglass.FilterRepositoryPages(c.listRepositoryPages(ctx, repo, opts), opts)
+}
+
+func (c *Client) listRepositoryPages(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) iter.Seq2[*seaglass.RepositoryList, error] {
+	return func(yield func(*seaglass.RepositoryList, error) bool) {
+		// The repository API requires a namespace unless it's listing
+		// public or starred repositories, neither of which is the root
+		if repo == "" {
+			yield(nil, fmt.Errorf("listing namespaces: %w", seaglass.ErrNotSupported))
+			return
+		}
+
+		namespace, repository := parseRepo(repo)
+
+		found := false
+		childMap := map[string]struct{}{}
+
+		query := url.Values{}
+		query.Set("namespace", namespace)
+		for {
+			var body struct {
+				Repositories []struct {
+					Namespace string `json:"namespace"`
+					Name      string `json:"name"`
+				} `json:"repositories"`
+				NextPage string `json:"next_page"`
+			}
+			if err := c.get(ctx, "/repository", query, &body); err != nil {
+				yield(nil, fmt.Errorf("listing repositories: %w", err))
+				return
+			}
+
+			var children []string
+			for _, r := range body.Repositories {
+				if r.Name == "" {
+					continue
+				}
+				if r.Name == repository {
+					found = true
+					continue
+				}
+
+				prefix := fmt.Sprintf("%s/", repository)
+				if repository != "" && !strings.HasPrefix(r.Name, prefix) {
+					continue
+				}
+				found = true
+
+				relativePath := strings.TrimPrefix(r.Name, prefix)
+				if opts != nil && opts.Recursive {
+					children = append(children, relativePath)
+				} else {
+					child := strings.Split(relativePath, "/")[0]
+					if _, ok := childMap[child]; !ok {
+						children = append(children, child)
+					}
+					childMap[child] = struct{}{}
+				}
+			}
+
+			if len(children) > 0 && !yield(&seaglass.RepositoryList{Name: repo, Repositories: children}, nil) {
+				return
+			}
+
+			if body.NextPage == "" {
+				break
+			}
+
+			query.Set("next_page", body.NextPage)
+		}
+
+		// A namespace is a valid repository to list from, even if it's
+		// empty
+		if !found && repository != "" {
+			yield(nil, seaglass.ErrNotFound)
+		}
+	}
+}
+
+// ListManifests lists the manifests in the repository. The tags API returns
+// the manifest digest for every active tag, along with the time the tag was
+// pushed.
+func (c *Client) ListManifests(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) (*seaglass.ManifestList, error) {
+	return seaglass.CollectManifests(c.ListManifestPages(ctx, repo, opts))
+}
+
+// ListManifestPages lists the manifests in the repository, yielding the
+// manifests from each page of tags
+func (c *Client) ListManifestPages(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) iter.Seq2[*seaglass.ManifestList, error] {
+	return seaglass.FilterManifestPages(c.Resolve(ctx, repo, seaglass.GroupArtifactPages(c.listManifestPages(ctx, repo, opts), opts), opts), opts)
+}
+
+func (c *Client) listManifestPages(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) iter.Seq2[*seaglass.ManifestList, error] {
+	return func(yield func(*seaglass.ManifestList, error) bool) {
+		namespace, repository := parseRepo(repo)
+
+		// The namespace doesn't host any manifests
+		if repository == "" {
+			return
+		}
+
+		query := url.Values{}
+		query.Set("limit", fmt.Sprint(pageSize))
+		query.Set("onlyActiveTags", "true")
+		for page := 1; ; page++ {
+			query.Set("page", fmt.Sprint(page))
+
+			var body struct {
+				Tags []struct {
+					Name           string `json:"name"`
+					ManifestDigest string `json:"manifest_digest"`
+					IsManifestList bool   `json:"is_manifest_list"`
+					Size           int64  `json:"size"`
+					StartTS        int64  `json:"start_ts"`
+				} `json:"tags"`
+				HasAdditional bool `json:"has_additional"`
+			}
+			if err := c.get(ctx, fmt.Sprintf("/repository/%s/%s/tag/", namespace, repository), query, &body); err != nil {
+				yield(nil, fmt.Errorf("listing tags: %w", err))
+				return
+			}
+
+			manifestMap := map[string]*seaglass.Manifest{}
+			for _, t := range body.Tags {
+				if t.ManifestDigest == "" {
+					continue
+				}
+
+				// The start time of a tag is when it was pushed or
+				// moved to the manifest. The earliest start time is
+				// the closest we can get to when the manifest was
+				// uploaded and the latest is when it was last
+				// updated.
+				pushed := time.Unix(t.StartTS, 0).UTC()
+
+				manifest, ok := manifestMap[t.ManifestDigest]
+				if !ok {
+					manifest = &seaglass.Manifest{
+						Digest: t.ManifestDigest,
+					}
+					// Quay only reports the size of image manifests
+					if !t.IsManifestList {
+						manifest.Size = t.Size
+					}
+					manifestMap[t.ManifestDigest] = manifest
+				}
+				manifest.Tags = append(manifest.Tags, t.Name)
+
+				if t.StartTS == 0 {
+					continue
+				}
+				if manifest.Uploaded == nil || pushed.Before(*manifest.Uploaded) {
+					uploaded := pushed
+					manifest.Uploaded = &uploaded
+				}
+				if manifest.Updated == nil || pushed.After(*manifest.Updated) {
+					updated := pushed
+					manifest.Updated = &updated
+				}
+			}
+
+			var manifests []seaglass.Manifest
+			for _, manifest := range manifestMap {
+				sort.Strings(manifest.Tags)
+				manifests = append(manifests, *manifest)
+			}
+
+			sort.Slice(manifests, func(i, j int) bool {
+				return manifests[i].Digest < manifests[j].Digest
+			})
+
+			if !yield(&seaglass.ManifestList{Manifests: manifests}, nil) {
+				return
+			}
+
+			if !body.HasAdditional {
+				break
+			}
+		}
+	}
+}
+
+// get fetches the path from the API and decodes the response into v
+func (c *Client) get(ctx context.Context, path string, query url.Values, v any) error {
+	u := c.apiURL.JoinPath(path)
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return seaglass.ErrNotFound
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response code: %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decoding body: %w", err)
+	}
+
+	return nil
+}
+
+func parseRepo(repo string) (namespace, repository string) {
+	parts := strings.SplitN(repo, "/", 2)
+	namespace = parts[0]
+	if len(parts) > 1 {
+		repository = parts[1]
+	}
+
+	return namespace, repository
+}
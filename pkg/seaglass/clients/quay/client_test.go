@@ -0,0 +1,307 @@
+package quay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+)
+
+func sortStrings(a, b string) bool {
+	return a < b
+}
+
+func TestClientListRepositories(t *testing.T) {
+	q := &fakeQuay{
+		repositories: map[string][]string{
+			"foo": {"bar", "bar/baz", "baz", "baz/bar/foo"},
+			"qux": {},
+		},
+	}
+
+	t.Run("listing a namespace", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, q)
+
+		gotList, err := c.ListRepositories(ctx, "foo", nil)
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		wantList := &seaglass.RepositoryList{
+			Name: "foo",
+			Repositories: []string{
+				"bar",
+				"baz",
+			},
+		}
+		if diff := cmp.Diff(wantList, gotList, cmpopts.SortSlices(sortStrings)); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("listing a namespace recursive", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, q)
+
+		gotList, err := c.ListRepositories(ctx, "foo", &seaglass.RepositoryListOptions{Recursive: true})
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		wantList := &seaglass.RepositoryList{
+			Name: "foo",
+			Repositories: []string{
+				"bar",
+				"bar/baz",
+				"baz",
+				"baz/bar/foo",
+			},
+		}
+		if diff := cmp.Diff(wantList, gotList, cmpopts.SortSlices(sortStrings)); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("listing a repository", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, q)
+
+		gotList, err := c.ListRepositories(ctx, "foo/bar", nil)
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		wantList := &seaglass.RepositoryList{
+			Name: "foo/bar",
+			Repositories: []string{
+				"baz",
+			},
+		}
+		if diff := cmp.Diff(wantList, gotList); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("listing an empty namespace", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, q)
+
+		gotList, err := c.ListRepositories(ctx, "qux", nil)
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		wantList := &seaglass.RepositoryList{
+			Name: "qux",
+		}
+		if diff := cmp.Diff(wantList, gotList); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("listing a repository that doesn't exist", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, q)
+
+		gotList, err := c.ListRepositories(ctx, "foo/qux", nil)
+		if !errors.Is(err, seaglass.ErrNotFound) {
+			t.Errorf("unexpected error: %s", err)
+		}
+		if gotList != nil {
+			t.Errorf("unexpected response: %v", gotList)
+		}
+	})
+
+	t.Run("listing the root", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, q)
+
+		gotList, err := c.ListRepositories(ctx, "", nil)
+		if !errors.Is(err, seaglass.ErrNotSupported) {
+			t.Errorf("unexpected error: %s", err)
+		}
+		if gotList != nil {
+			t.Errorf("unexpected response: %v", gotList)
+		}
+	})
+}
+
+func TestClientListManifests(t *testing.T) {
+	first := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	second := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	q := &fakeQuay{
+		tags: map[string][]tag{
+			"foo/bar": {
+				{Name: "v1", ManifestDigest: "sha256:aaaa", Size: 1024, StartTS: first.Unix()},
+				{Name: "latest", ManifestDigest: "sha256:aaaa", Size: 1024, StartTS: second.Unix()},
+				{Name: "v2", ManifestDigest: "sha256:bbbb", Size: 2048, IsManifestList: true, StartTS: second.Unix()},
+			},
+		},
+	}
+
+	t.Run("list manifests", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, q)
+
+		gotList, err := c.ListManifests(ctx, "foo/bar", nil)
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		wantList := &seaglass.ManifestList{
+			Manifests: []seaglass.Manifest{
+				{
+					Digest:   "sha256:aaaa",
+					Tags:     []string{"latest", "v1"},
+					Size:     1024,
+					Uploaded: &first,
+					Updated:  &second,
+				},
+				{
+					Digest:   "sha256:bbbb",
+					Tags:     []string{"v2"},
+					Uploaded: &second,
+					Updated:  &second,
+				},
+			},
+		}
+		if diff := cmp.Diff(wantList, gotList); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("list manifests in a namespace", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, q)
+
+		gotList, err := c.ListManifests(ctx, "foo", nil)
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		if diff := cmp.Diff(&seaglass.ManifestList{}, gotList); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("repository not found", func(t *testing.T) {
+		ctx := context.Background()
+
+		c := setupClient(t, q)
+
+		gotList, err := c.ListManifests(ctx, "foo/baz", nil)
+		if !errors.Is(err, seaglass.ErrNotFound) {
+			t.Errorf("unexpected error: %s", err)
+		}
+		if gotList != nil {
+			t.Errorf("unexpected response: %v", gotList)
+		}
+	})
+}
+
+type tag struct {
+	Name           string `json:"name"`
+	ManifestDigest string `json:"manifest_digest"`
+	IsManifestList bool   `json:"is_manifest_list"`
+	Size           int64  `json:"size"`
+	StartTS        int64  `json:"start_ts"`
+}
+
+// fakeQuay is a fake implementation of the Quay API. Lists are served one
+// item per page to exercise pagination.
+type fakeQuay struct {
+	// repositories maps namespaces to the repositories in the namespace
+	repositories map[string][]string
+
+	// tags maps repositories to their tags
+	tags map[string][]tag
+}
+
+func (q *fakeQuay) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	switch {
+	case r.URL.Path == "/api/v1/repository":
+		repos, ok := q.repositories[query.Get("namespace")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		i := 0
+		fmt.Sscan(query.Get("next_page"), &i)
+
+		resp := map[string]any{}
+		var results []map[string]string
+		if i < len(repos) {
+			results = append(results, map[string]string{
+				"namespace": query.Get("namespace"),
+				"name":      repos[i],
+			})
+		}
+		resp["repositories"] = results
+		if i+1 < len(repos) {
+			resp["next_page"] = fmt.Sprint(i + 1)
+		}
+		json.NewEncoder(w).Encode(resp)
+	case strings.HasPrefix(r.URL.Path, "/api/v1/repository/") && strings.HasSuffix(r.URL.Path, "/tag/"):
+		repo := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/repository/"), "/tag/")
+		tags, ok := q.tags[repo]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if query.Get("onlyActiveTags") != "true" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		page := 1
+		fmt.Sscan(query.Get("page"), &page)
+
+		json.NewEncoder(w).Encode(map[string]any{
+			"tags":           tags[page-1 : page],
+			"page":           page,
+			"has_additional": page < len(tags),
+		})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func setupClient(t *testing.T, q *fakeQuay) seaglass.Client {
+	srv := httptest.NewServer(q)
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error parsing server url: %s", err)
+	}
+
+	c, err := NewSelfHostedClient(u.Host)
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %s", err)
+	}
+
+	return c
+}
@@ -0,0 +1,332 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/jetstack/seaglass/internal/inspect"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// defaultConcurrency is the number of manifests to fetch at once when
+	// the concurrency isn't set in the options
+	defaultConcurrency = 10
+
+	// rateLimit is the maximum number of requests per second to make to
+	// the registry, unless the options set another limit
+	rateLimit = 50
+)
+
+// Client is a client for a v2 registry. In general, this client is only
+// suitable where a more specific client for the actual registry doesn't exist.
+type Client struct {
+	*inspect.Inspector
+
+	registry name.Registry
+	puller   *remote.Puller
+	pusher   *remote.Pusher
+}
+
+// NewClient returns a new client
+func NewClient(host string, opts ...seaglass.Option) (seaglass.Client, error) {
+	reg, err := name.NewRegistry(host)
+	if err != nil {
+		return nil, fmt.Errorf("parsing registry host: %w", err)
+	}
+
+	// Every request to the registry goes through the same limiter, so
+	// that listing manifests concurrently doesn't overwhelm the registry
+	o := seaglass.NewClientOptions(opts...)
+	remoteOpts := o.RemoteOptions(o.Transport(rateLimit, rateLimit))
+
+	puller, err := remote.NewPuller(remoteOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("creating puller: %w", err)
+	}
+
+	pusher, err := remote.NewPusher(remoteOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("creating pusher: %w", err)
+	}
+
+	inspector, err := inspect.New(reg, remoteOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		Inspector: inspector,
+		registry:  reg,
+		puller:    puller,
+		pusher:    pusher,
+	}, nil
+}
+
+// ListRepositories lists the child repositories of the specified repository.
+// This requires that the upstream registry supports the /v2/_catalog API.
+//
+// This may be wildly inefficient, depending on the implementation details of the
+// underlying registry or the number of objects in the registry.
+func (c *Client) ListRepositories(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) (*seaglass.RepositoryList, error) {
+	return seaglass.CollectRepositories(repo, c.ListRepositoryPages(ctx, repo, opts))
+}
+
+// ListRepositoryPages lists the child repositories of the specified repository,
+// yielding the matching repositories from each page of the catalog.
+func (c *Client) ListRepositoryPages(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) iter.Seq2[*seaglass.RepositoryList, error] {
+	return seaglass.FilterRepositoryPages(c.listRepositoryPages(ctx, repo, opts), opts)
+}
+
+func (c *Client) listRepositoryPages(ctx context.Context, repo string, opts *seaglass.RepositoryListOptions) iter.Seq2[*seaglass.RepositoryList, error] {
+	return func(yield func(*seaglass.RepositoryList, error) bool) {
+		catalogger, err := c.puller.Catalogger(ctx, c.registry)
+		if err != nil {
+			yield(nil, fmt.Errorf("calling catalog: %w", err))
+			return
+		}
+
+		found := false
+		childMap := map[string]struct{}{}
+		for catalogger.HasNext() {
+			page, err := catalogger.Next(ctx)
+			if err != nil {
+				yield(nil, fmt.Errorf("calling catalog: %w", err))
+				return
+			}
+
+			var children []string
+			for _, r := range page.Repos {
+				if r == repo {
+					found = true
+				}
+				prefix := fmt.Sprintf("%s/", repo)
+				if strings.HasPrefix(r, prefix) {
+					found = true
+					relativePath := strings.TrimPrefix(r, prefix)
+					if opts != nil && opts.Recursive {
+						children = append(children, relativePath)
+					} else {
+						child := strings.Split(relativePath, "/")[0]
+						if _, ok := childMap[child]; !ok {
+							children = append(children, child)
+						}
+						childMap[child] = struct{}{}
+					}
+				}
+			}
+
+			if len(children) == 0 {
+				continue
+			}
+
+			if !yield(&seaglass.RepositoryList{Name: repo, Repositories: children}, nil) {
+				return
+			}
+		}
+
+		if !found {
+			yield(nil, seaglass.ErrNotFound)
+		}
+	}
+}
+
+// ListManifests lists the manifests in the repository. Lists all the tags in
+// the repository and then issues a HEAD request to get the manifest details for
+// each tag.
+func (c *Client) ListManifests(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) (*seaglass.ManifestList, error) {
+	return seaglass.CollectManifests(c.ListManifestPages(ctx, repo, opts))
+}
+
+// ListManifestPages lists the manifests in the repository, yielding the
+// manifests for each page of tags.
+func (c *Client) ListManifestPages(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) iter.Seq2[*seaglass.ManifestList, error] {
+	return seaglass.FilterManifestPages(c.Resolve(ctx, repo, seaglass.GroupArtifactPages(c.listManifestPages(ctx, repo, opts), opts), opts), opts)
+}
+
+func (c *Client) listManifestPages(ctx context.Context, repo string, opts *seaglass.ManifestListOptions) iter.Seq2[*seaglass.ManifestList, error] {
+	return func(yield func(*seaglass.ManifestList, error) bool) {
+		concurrency := defaultConcurrency
+		if opts != nil && opts.Concurrency > 0 {
+			concurrency = opts.Concurrency
+		}
+
+		// Every manifest is found through a tag, so tags that don't
+		// match the filter don't need to be fetched at all
+		filter, err := seaglass.NewManifestFilter(opts)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		lister, err := c.puller.Lister(ctx, c.registry.Repo(repo))
+		if err != nil {
+			var terr *transport.Error
+			if errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound {
+				yield(nil, seaglass.ErrNotFound)
+				return
+			}
+			yield(nil, fmt.Errorf("listing tags: %w", err))
+			return
+		}
+
+		// The tags of artifacts are kept until every other tag has
+		// been listed, so that only the artifacts of manifests that
+		// match the filter have to be fetched. Their subject is part of
+		// the tag, so it's known before they're fetched.
+		hideArtifacts := opts != nil && opts.HideArtifacts
+		var artifactTags []string
+		listed := map[string]struct{}{}
+
+		for lister.HasNext() {
+			page, err := lister.Next(ctx)
+			if err != nil {
+				yield(nil, fmt.Errorf("listing tags: %w", err))
+				return
+			}
+
+			tags := slices.DeleteFunc(page.Tags, func(tag string) bool {
+				if _, _, ok := seaglass.ParseReferrerTag(tag); ok && hideArtifacts {
+					artifactTags = append(artifactTags, tag)
+					return true
+				}
+				return !filter.MatchTag(tag)
+			})
+
+			manifests, err := c.headTags(ctx, repo, tags, concurrency)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for _, m := range manifests {
+				listed[m.Digest] = struct{}{}
+			}
+
+			if !yield(&seaglass.ManifestList{Manifests: manifests}, nil) {
+				return
+			}
+		}
+
+		artifactTags = slices.DeleteFunc(artifactTags, func(tag string) bool {
+			subject, _, _ := seaglass.ParseReferrerTag(tag)
+			_, ok := listed[subject]
+			return !ok
+		})
+		if len(artifactTags) == 0 {
+			return
+		}
+
+		manifests, err := c.headTags(ctx, repo, artifactTags, concurrency)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		yield(&seaglass.ManifestList{Manifests: manifests}, nil)
+	}
+}
+
+// headTags fetches the descriptor for each of the tags, making up to
+// concurrency requests at once, and groups the tags by manifest
+func (c *Client) headTags(ctx context.Context, repo string, tags []string, concurrency int) ([]seaglass.Manifest, error) {
+	descs := make([]*ggcrv1.Descriptor, len(tags))
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for i, tag := range tags {
+		g.Go(func() error {
+			desc, err := c.puller.Head(gctx, c.registry.Repo(repo).Tag(tag))
+			if err != nil {
+				return fmt.Errorf("fetching descriptor for tag %s: %w", tag, err)
+			}
+			descs[i] = desc
+
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	manifestMap := map[string]*seaglass.Manifest{}
+	for i, tag := range tags {
+		digest := descs[i].Digest.String()
+
+		manifest, ok := manifestMap[digest]
+		if !ok {
+			manifestMap[digest] = &seaglass.Manifest{
+				Digest:    digest,
+				MediaType: string(descs[i].MediaType),
+				Tags: []string{
+					tag,
+				},
+			}
+		} else {
+			manifest.Tags = append(manifest.Tags, tag)
+		}
+	}
+
+	var manifests []seaglass.Manifest
+	for _, manifest := range manifestMap {
+		sort.Strings(manifest.Tags)
+		manifests = append(manifests, *manifest)
+	}
+
+	sort.Slice(manifests, func(i, j int) bool {
+		return manifests[i].Digest < manifests[j].Digest
+	})
+
+	return manifests, nil
+}
+
+// DeleteManifest deletes the manifest with the v2 API. Registries that
+// implement the distribution spec delete the tags that point to it too.
+func (c *Client) DeleteManifest(ctx context.Context, repo, digest string) error {
+	ref, err := name.NewDigest(fmt.Sprintf("%s@%s", c.registry.Repo(repo), digest))
+	if err != nil {
+		return fmt.Errorf("parsing digest: %w", err)
+	}
+
+	return c.delete(ctx, ref)
+}
+
+// DeleteTag deletes the tag with the v2 API. Not every registry supports
+// deleting tags, in which case ErrNotSupported is returned.
+func (c *Client) DeleteTag(ctx context.Context, repo, tag string) error {
+	ref, err := name.NewTag(fmt.Sprintf("%s:%s", c.registry.Repo(repo), tag))
+	if err != nil {
+		return fmt.Errorf("parsing tag: %w", err)
+	}
+
+	return c.delete(ctx, ref)
+}
+
+func (c *Client) delete(ctx context.Context, ref name.Reference) error {
+	if err := c.pusher.Delete(ctx, ref); err != nil {
+		var terr *transport.Error
+		if errors.As(err, &terr) {
+			switch {
+			case terr.StatusCode == http.StatusNotFound:
+				return seaglass.ErrNotFound
+			case terr.StatusCode == http.StatusMethodNotAllowed, slices.ContainsFunc(terr.Errors, func(d transport.Diagnostic) bool {
+				return d.Code == transport.UnsupportedErrorCode
+			}):
+				return fmt.Errorf("deleting %s: %w", ref, seaglass.ErrNotSupported)
+			}
+		}
+		return fmt.Errorf("deleting %s: %w", ref, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,663 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	ggcrv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/jetstack/seaglass/pkg/seaglass"
+)
+
+func sortStrings(a, b string) bool {
+	return a < b
+}
+
+func sortManifests(a, b seaglass.Manifest) bool {
+	return a.Digest < b.Digest
+}
+
+func TestClientListRepositories(t *testing.T) {
+	t.Run("listing a top-level repository", func(t *testing.T) {
+		ctx := context.Background()
+
+		host := setupRegistry(t)
+		c, err := NewClient(host)
+		if err != nil {
+			t.Fatalf("unexpected error creating new client: %s", err)
+		}
+
+		img, err := random.Image(1024, 1)
+		if err != nil {
+			t.Fatalf("unexpected error creating test image: %s", err)
+		}
+
+		reg, err := name.NewRegistry(host)
+		if err != nil {
+			t.Fatalf("unexpected error parsing registry: %s", err)
+		}
+
+		for _, repo := range []string{"foo/bar", "foo/bar/baz", "foo/baz", "foo/baz/bar/foo"} {
+			if err := remote.Write(reg.Repo(repo).Tag("latest"), img); err != nil {
+				t.Fatalf("unexpected error pusing to registry: %s", err)
+			}
+		}
+
+		gotList, err := c.ListRepositories(ctx, "foo", nil)
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		wantList := &seaglass.RepositoryList{
+			Name: "foo",
+			Repositories: []string{
+				"bar",
+				"baz",
+			},
+		}
+		if diff := cmp.Diff(wantList, gotList, cmpopts.SortSlices(sortStrings)); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("listing a sub-repository", func(t *testing.T) {
+		ctx := context.Background()
+
+		host := setupRegistry(t)
+		c, err := NewClient(host)
+		if err != nil {
+			t.Fatalf("unexpected error creating new client: %s", err)
+		}
+
+		img, err := random.Image(1024, 1)
+		if err != nil {
+			t.Fatalf("unexpected error creating test image: %s", err)
+		}
+
+		reg, err := name.NewRegistry(host)
+		if err != nil {
+			t.Fatalf("unexpected error parsing registry: %s", err)
+		}
+
+		for _, repo := range []string{"foo/bar", "foo/bar/baz", "foo/baz", "foo/baz/bar/foo"} {
+			if err := remote.Write(reg.Repo(repo).Tag("latest"), img); err != nil {
+				t.Fatalf("unexpected error pusing to registry: %s", err)
+			}
+		}
+
+		gotList, err := c.ListRepositories(ctx, "foo/bar", nil)
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		wantList := &seaglass.RepositoryList{
+			Name: "foo/bar",
+			Repositories: []string{
+				"baz",
+			},
+		}
+		if diff := cmp.Diff(wantList, gotList); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("listing a sub-repository that doesn't exist", func(t *testing.T) {
+		ctx := context.Background()
+
+		host := setupRegistry(t)
+		c, err := NewClient(host)
+		if err != nil {
+			t.Fatalf("unexpected error creating new client: %s", err)
+		}
+
+		img, err := random.Image(1024, 1)
+		if err != nil {
+			t.Fatalf("unexpected error creating test image: %s", err)
+		}
+
+		reg, err := name.NewRegistry(host)
+		if err != nil {
+			t.Fatalf("unexpected error parsing registry: %s", err)
+		}
+
+		for _, repo := range []string{"foo/bar"} {
+			if err := remote.Write(reg.Repo(repo).Tag("latest"), img); err != nil {
+				t.Fatalf("unexpected error pusing to registry: %s", err)
+			}
+		}
+
+		gotList, err := c.ListRepositories(ctx, "foo/bar/baz", nil)
+		if !errors.Is(err, seaglass.ErrNotFound) {
+			t.Errorf("unexpected error: %s", err)
+		}
+		if gotList != nil {
+			t.Errorf("unexpected response: %v", gotList)
+		}
+	})
+}
+
+func TestClientListManifests(t *testing.T) {
+	t.Run("repository not found", func(t *testing.T) {
+		ctx := context.Background()
+
+		host := setupRegistry(t)
+		c, err := NewClient(host)
+		if err != nil {
+			t.Fatalf("unexpected error creating new client: %s", err)
+		}
+
+		gotList, err := c.ListManifests(ctx, "foo/bar", nil)
+		if !errors.Is(err, seaglass.ErrNotFound) {
+			t.Errorf("unexpected error: %s", err)
+		}
+		if gotList != nil {
+			t.Errorf("unexpected response: %s", err)
+		}
+	})
+
+	t.Run("list manifests", func(t *testing.T) {
+		ctx := context.Background()
+
+		host := setupRegistry(t)
+		c, err := NewClient(host)
+		if err != nil {
+			t.Fatalf("unexpected error creating new client: %s", err)
+		}
+
+		reg, err := name.NewRegistry(host)
+		if err != nil {
+			t.Fatalf("unexpected error parsing registry: %s", err)
+		}
+
+		var manifests []seaglass.Manifest
+		for _, tags := range [][]string{{"bar", "foo"}, {"baz"}} {
+			img, err := random.Image(1024, 1)
+			if err != nil {
+				t.Fatalf("unexpected error creating test image: %s", err)
+			}
+
+			digest, err := img.Digest()
+			if err != nil {
+				t.Fatalf("unexpected error getting digest from image: %s", err)
+			}
+
+			mt, err := img.MediaType()
+			if err != nil {
+				t.Fatalf("unexpected error getting mediaType from image: %s", err)
+			}
+
+			for _, tag := range tags {
+				if err := remote.Write(reg.Repo("foo/bar").Tag(tag), img); err != nil {
+					t.Fatalf("unexpected error pushing image: %s", err)
+				}
+			}
+
+			manifests = append(manifests, seaglass.Manifest{
+				Digest:    digest.String(),
+				MediaType: string(mt),
+				Tags:      tags,
+			})
+		}
+
+		less := func(a, b seaglass.Manifest) bool { return a.Digest < b.Digest }
+
+		gotList, err := c.ListManifests(ctx, "foo/bar", nil)
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		wantList := &seaglass.ManifestList{
+			Manifests: manifests,
+		}
+		if diff := cmp.Diff(wantList, gotList, cmpopts.SortSlices(less)); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+}
+
+func TestClientListManifestsFilter(t *testing.T) {
+	ctx := context.Background()
+
+	host := setupRegistry(t)
+	c, err := NewClient(host)
+	if err != nil {
+		t.Fatalf("unexpected error creating new client: %s", err)
+	}
+
+	reg, err := name.NewRegistry(host)
+	if err != nil {
+		t.Fatalf("unexpected error parsing registry: %s", err)
+	}
+
+	var manifests []seaglass.Manifest
+	for _, tags := range [][]string{{"latest", "v1.1.0", "v1.1.0-alpine"}, {"v1.0.0"}} {
+		img, err := random.Image(1024, 1)
+		if err != nil {
+			t.Fatalf("unexpected error creating test image: %s", err)
+		}
+
+		digest, err := img.Digest()
+		if err != nil {
+			t.Fatalf("unexpected error getting digest from image: %s", err)
+		}
+
+		mt, err := img.MediaType()
+		if err != nil {
+			t.Fatalf("unexpected error getting mediaType from image: %s", err)
+		}
+
+		for _, tag := range tags {
+			if err := remote.Write(reg.Repo("foo/bar").Tag(tag), img); err != nil {
+				t.Fatalf("unexpected error pushing image: %s", err)
+			}
+		}
+
+		manifests = append(manifests, seaglass.Manifest{
+			Digest:    digest.String(),
+			MediaType: string(mt),
+			Tags:      tags,
+		})
+	}
+
+	gotList, err := c.ListManifests(ctx, "foo/bar", &seaglass.ManifestListOptions{
+		IncludeTags: []string{"v1.*"},
+		ExcludeTags: []string{"*-alpine"},
+	})
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	manifests[0].Tags = []string{"v1.1.0"}
+	wantList := &seaglass.ManifestList{
+		Manifests: manifests,
+	}
+	if diff := cmp.Diff(wantList, gotList, cmpopts.SortSlices(sortManifests)); diff != "" {
+		t.Errorf("unexpected result:\n%s", diff)
+	}
+}
+
+func TestClientListManifestsHideArtifacts(t *testing.T) {
+	ctx := context.Background()
+
+	host := setupRegistry(t)
+	c, err := NewClient(host)
+	if err != nil {
+		t.Fatalf("unexpected error creating new client: %s", err)
+	}
+
+	reg, err := name.NewRegistry(host)
+	if err != nil {
+		t.Fatalf("unexpected error parsing registry: %s", err)
+	}
+
+	img, err := random.Image(1024, 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating test image: %s", err)
+	}
+	digest, err := img.Digest()
+	if err != nil {
+		t.Fatalf("unexpected error getting digest from image: %s", err)
+	}
+	mt, err := img.MediaType()
+	if err != nil {
+		t.Fatalf("unexpected error getting mediaType from image: %s", err)
+	}
+	if err := remote.Write(reg.Repo("foo/bar").Tag("v1.0.0"), img); err != nil {
+		t.Fatalf("unexpected error pushing image: %s", err)
+	}
+
+	sig, err := random.Image(256, 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating test signature: %s", err)
+	}
+	sigDigest, err := sig.Digest()
+	if err != nil {
+		t.Fatalf("unexpected error getting digest from signature: %s", err)
+	}
+	sigTag := seaglass.ReferrerTag(digest.String(), ".sig")
+	if err := remote.Write(reg.Repo("foo/bar").Tag(sigTag), sig); err != nil {
+		t.Fatalf("unexpected error pushing signature: %s", err)
+	}
+
+	// The signature of an image that doesn't match the filter isn't
+	// fetched at all
+	other, err := random.Image(1024, 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating test image: %s", err)
+	}
+	otherDigest, err := other.Digest()
+	if err != nil {
+		t.Fatalf("unexpected error getting digest from image: %s", err)
+	}
+	if err := remote.Write(reg.Repo("foo/bar").Tag("v2.0.0"), other); err != nil {
+		t.Fatalf("unexpected error pushing image: %s", err)
+	}
+	otherSigTag := seaglass.ReferrerTag(otherDigest.String(), ".sig")
+	if err := remote.Write(reg.Repo("foo/bar").Tag(otherSigTag), sig); err != nil {
+		t.Fatalf("unexpected error pushing signature: %s", err)
+	}
+
+	opts := &seaglass.ManifestListOptions{
+		IncludeTags:   []string{"v1.*"},
+		HideArtifacts: true,
+	}
+	for page, err := range c.(*Client).listManifestPages(ctx, "foo/bar", opts) {
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		for _, m := range page.Manifests {
+			if slices.Contains(m.Tags, otherSigTag) {
+				t.Errorf("unexpected artifact tag listed: %s", otherSigTag)
+			}
+		}
+	}
+
+	// The tag of the signature is listed to attach it to the image, even
+	// though it doesn't match the tag filter
+	gotList, err := c.ListManifests(ctx, "foo/bar", opts)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	wantList := &seaglass.ManifestList{
+		Manifests: []seaglass.Manifest{
+			{
+				Digest:    digest.String(),
+				MediaType: string(mt),
+				Tags:      []string{"v1.0.0"},
+				Referrers: []seaglass.Referrer{
+					{
+						Descriptor: seaglass.Descriptor{
+							Digest:       sigDigest.String(),
+							MediaType:    string(mt),
+							ArtifactType: seaglass.ArtifactTypeCosignSignature,
+						},
+						Tag: sigTag,
+					},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(wantList, gotList); diff != "" {
+		t.Errorf("unexpected result:\n%s", diff)
+	}
+}
+
+func TestClientListManifestsPlatforms(t *testing.T) {
+	ctx := context.Background()
+
+	host := setupRegistry(t)
+	c, err := NewClient(host)
+	if err != nil {
+		t.Fatalf("unexpected error creating new client: %s", err)
+	}
+
+	reg, err := name.NewRegistry(host)
+	if err != nil {
+		t.Fatalf("unexpected error parsing registry: %s", err)
+	}
+
+	// An index for linux/amd64 and linux/arm64/v8, and a separate
+	// linux/amd64 image
+	idx, err := random.Index(1024, 1, 2)
+	if err != nil {
+		t.Fatalf("unexpected error creating test index: %s", err)
+	}
+	im, err := idx.IndexManifest()
+	if err != nil {
+		t.Fatalf("unexpected error getting index manifest: %s", err)
+	}
+	platforms := []*ggcrv1.Platform{
+		{OS: "linux", Architecture: "amd64"},
+		{OS: "linux", Architecture: "arm64", Variant: "v8"},
+	}
+	var adds []mutate.IndexAddendum
+	for i, desc := range im.Manifests {
+		img, err := idx.Image(desc.Digest)
+		if err != nil {
+			t.Fatalf("unexpected error getting image: %s", err)
+		}
+		adds = append(adds, mutate.IndexAddendum{
+			Add:        img,
+			Descriptor: ggcrv1.Descriptor{Platform: platforms[i]},
+		})
+	}
+	idx = mutate.AppendManifests(empty.Index, adds...)
+	if err := remote.WriteIndex(reg.Repo("foo/bar").Tag("multi"), idx); err != nil {
+		t.Fatalf("unexpected error pushing index: %s", err)
+	}
+
+	img, err := random.Image(1024, 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating test image: %s", err)
+	}
+	img, err = mutate.ConfigFile(img, &ggcrv1.ConfigFile{OS: "linux", Architecture: "amd64"})
+	if err != nil {
+		t.Fatalf("unexpected error setting config: %s", err)
+	}
+	if err := remote.Write(reg.Repo("foo/bar").Tag("amd64"), img); err != nil {
+		t.Fatalf("unexpected error pushing image: %s", err)
+	}
+
+	gotList, err := c.ListManifests(ctx, "foo/bar", &seaglass.ManifestListOptions{
+		Platforms: []string{"linux/arm64"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	digest, err := idx.Digest()
+	if err != nil {
+		t.Fatalf("unexpected error getting digest: %s", err)
+	}
+	im, err = idx.IndexManifest()
+	if err != nil {
+		t.Fatalf("unexpected error getting index manifest: %s", err)
+	}
+	var children []seaglass.Descriptor
+	for i, desc := range im.Manifests {
+		children = append(children, seaglass.Descriptor{
+			Digest:    desc.Digest.String(),
+			MediaType: string(desc.MediaType),
+			Size:      desc.Size,
+			Platform: &seaglass.Platform{
+				OS:           platforms[i].OS,
+				Architecture: platforms[i].Architecture,
+				Variant:      platforms[i].Variant,
+			},
+		})
+	}
+
+	wantList := &seaglass.ManifestList{
+		Manifests: []seaglass.Manifest{
+			{
+				Digest:    digest.String(),
+				MediaType: string(types.OCIImageIndex),
+				Tags:      []string{"multi"},
+				Manifests: children,
+			},
+		},
+	}
+	if diff := cmp.Diff(wantList, gotList); diff != "" {
+		t.Errorf("unexpected result:\n%s", diff)
+	}
+}
+
+func TestClientListManifestsConcurrency(t *testing.T) {
+	host := setupRegistry(t)
+	c, err := NewClient(host)
+	if err != nil {
+		t.Fatalf("unexpected error creating new client: %s", err)
+	}
+
+	reg, err := name.NewRegistry(host)
+	if err != nil {
+		t.Fatalf("unexpected error parsing registry: %s", err)
+	}
+
+	img, err := random.Image(1024, 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating test image: %s", err)
+	}
+
+	digest, err := img.Digest()
+	if err != nil {
+		t.Fatalf("unexpected error getting digest from image: %s", err)
+	}
+
+	mt, err := img.MediaType()
+	if err != nil {
+		t.Fatalf("unexpected error getting mediaType from image: %s", err)
+	}
+
+	var tags []string
+	for i := range 50 {
+		tag := fmt.Sprintf("v%d", i)
+		if err := remote.Tag(reg.Repo("foo/bar").Tag(tag), img); err != nil {
+			t.Fatalf("unexpected error pushing image: %s", err)
+		}
+		tags = append(tags, tag)
+	}
+
+	t.Run("list manifests concurrently", func(t *testing.T) {
+		ctx := context.Background()
+
+		gotList, err := c.ListManifests(ctx, "foo/bar", &seaglass.ManifestListOptions{Concurrency: 4})
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		wantList := &seaglass.ManifestList{
+			Manifests: []seaglass.Manifest{
+				{
+					Digest:    digest.String(),
+					MediaType: string(mt),
+					Tags:      tags,
+				},
+			},
+		}
+		if diff := cmp.Diff(wantList, gotList, cmpopts.SortSlices(sortStrings)); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		gotList, err := c.ListManifests(ctx, "foo/bar", &seaglass.ManifestListOptions{Concurrency: 4})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("unexpected error: %s", err)
+		}
+		if gotList != nil {
+			t.Errorf("unexpected response: %v", gotList)
+		}
+	})
+}
+
+func TestClientDelete(t *testing.T) {
+	ctx := context.Background()
+
+	host := setupRegistry(t)
+	c, err := NewClient(host)
+	if err != nil {
+		t.Fatalf("unexpected error creating new client: %s", err)
+	}
+	d, ok := c.(seaglass.Deleter)
+	if !ok {
+		t.Fatalf("client doesn't implement seaglass.Deleter")
+	}
+
+	reg, err := name.NewRegistry(host)
+	if err != nil {
+		t.Fatalf("unexpected error parsing registry: %s", err)
+	}
+
+	img, err := random.Image(1024, 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating test image: %s", err)
+	}
+	digest, err := img.Digest()
+	if err != nil {
+		t.Fatalf("unexpected error getting digest from image: %s", err)
+	}
+	for _, tag := range []string{"v1", "latest"} {
+		if err := remote.Write(reg.Repo("foo/bar").Tag(tag), img); err != nil {
+			t.Fatalf("unexpected error pushing image: %s", err)
+		}
+	}
+
+	t.Run("delete tag", func(t *testing.T) {
+		if err := d.DeleteTag(ctx, "foo/bar", "latest"); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		gotList, err := c.ListManifests(ctx, "foo/bar", nil)
+		if err != nil {
+			t.Fatalf("unexpected error listing manifests: %s", err)
+		}
+		wantList := &seaglass.ManifestList{
+			Manifests: []seaglass.Manifest{
+				{
+					Digest:    digest.String(),
+					MediaType: string(types.DockerManifestSchema2),
+					Tags:      []string{"v1"},
+				},
+			},
+		}
+		if diff := cmp.Diff(wantList, gotList); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("delete missing tag", func(t *testing.T) {
+		if err := d.DeleteTag(ctx, "foo/bar", "missing"); !errors.Is(err, seaglass.ErrNotFound) {
+			t.Errorf("unexpected error: %s", err)
+		}
+	})
+
+	t.Run("delete manifest", func(t *testing.T) {
+		if err := d.DeleteManifest(ctx, "foo/bar", digest.String()); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if _, err := c.GetManifest(ctx, "foo/bar", digest.String()); !errors.Is(err, seaglass.ErrNotFound) {
+			t.Errorf("unexpected error getting deleted manifest: %s", err)
+		}
+	})
+
+	t.Run("delete missing manifest", func(t *testing.T) {
+		if err := d.DeleteManifest(ctx, "foo/bar", digest.String()); !errors.Is(err, seaglass.ErrNotFound) {
+			t.Errorf("unexpected error: %s", err)
+		}
+	})
+
+	t.Run("invalid digest", func(t *testing.T) {
+		if err := d.DeleteManifest(ctx, "foo/bar", "latest"); err == nil {
+			t.Errorf("expected error")
+		}
+	})
+}
+
+func setupRegistry(t *testing.T) string {
+	r := httptest.NewServer(registry.New())
+	t.Cleanup(r.Close)
+	u, err := url.Parse(r.URL)
+	if err != nil {
+		t.Fatalf("unexpected error parsing registry url: %s", err)
+	}
+	return u.Host
+}
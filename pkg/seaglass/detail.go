@@ -0,0 +1,140 @@
+package seaglass
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ManifestDetail describes a single manifest in full, as fetched from the
+// registry
+type ManifestDetail struct {
+	// Digest is the digest of the manifest.
+	Digest string `json:"digest"`
+
+	// MediaType is the media type of the manifest.
+	MediaType string `json:"mediaType"`
+
+	// ArtifactType is the type of an artifact, for manifests that set
+	// it.
+	ArtifactType string `json:"artifactType,omitempty"`
+
+	// Size is the size of the manifest itself, in bytes.
+	Size int64 `json:"size"`
+
+	// TotalSize is the size of the manifest, its config and all of its
+	// layers, in bytes. For an index, it's the size of the index and the
+	// manifests it references, not including their layers.
+	TotalSize int64 `json:"totalSize"`
+
+	// Annotations are the annotations on the manifest, such as
+	// org.opencontainers.image.source.
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Config is the config of an image. It's nil for an index, or for an
+	// artifact that doesn't have an image config.
+	Config *ImageConfig `json:"config,omitempty"`
+
+	// Layers are the layers of an image or artifact.
+	Layers []Descriptor `json:"layers,omitempty"`
+
+	// Manifests are the manifests in an index, with their platforms.
+	Manifests []Descriptor `json:"manifests,omitempty"`
+
+	// Subject is the manifest that this manifest refers to, if any.
+	Subject *Descriptor `json:"subject,omitempty"`
+}
+
+// Descriptor describes content referenced by a manifest
+type Descriptor struct {
+	// Digest is the digest of the content.
+	Digest string `json:"digest"`
+
+	// MediaType is the media type of the content.
+	MediaType string `json:"mediaType"`
+
+	// ArtifactType is the type of an artifact, for descriptors that set
+	// it.
+	ArtifactType string `json:"artifactType,omitempty"`
+
+	// Size is the size of the content, in bytes.
+	Size int64 `json:"size"`
+
+	// Annotations are the annotations on the descriptor.
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Platform is the platform of an image in an index.
+	Platform *Platform `json:"platform,omitempty"`
+}
+
+// Platform is the platform that an image runs on
+type Platform struct {
+	OS           string   `json:"os"`
+	Architecture string   `json:"architecture"`
+	Variant      string   `json:"variant,omitempty"`
+	OSVersion    string   `json:"osVersion,omitempty"`
+	OSFeatures   []string `json:"osFeatures,omitempty"`
+}
+
+// String returns the platform in the form os/arch[/variant]
+func (p Platform) String() string {
+	s := p.OS + "/" + p.Architecture
+	if p.Variant != "" {
+		s += "/" + p.Variant
+	}
+
+	return s
+}
+
+// ParsePlatform parses a platform in the form os/arch[/variant]
+func ParsePlatform(s string) (Platform, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return Platform{}, fmt.Errorf("invalid platform %q: must be of the form os/arch[/variant]", s)
+	}
+
+	p := Platform{
+		OS:           parts[0],
+		Architecture: parts[1],
+	}
+	if len(parts) == 3 {
+		p.Variant = parts[2]
+	}
+
+	return p, nil
+}
+
+// Matches returns true if the platform matches the other platform. An empty
+// variant in the other platform matches any variant.
+func (p Platform) Matches(other Platform) bool {
+	if p.OS != other.OS || p.Architecture != other.Architecture {
+		return false
+	}
+
+	return other.Variant == "" || p.Variant == other.Variant
+}
+
+// ImageConfig describes the config of an image
+type ImageConfig struct {
+	// Digest is the digest of the config blob.
+	Digest string `json:"digest"`
+
+	// MediaType is the media type of the config blob.
+	MediaType string `json:"mediaType"`
+
+	// Size is the size of the config blob, in bytes.
+	Size int64 `json:"size"`
+
+	// Created is when the image was created, according to the config.
+	Created *time.Time `json:"created,omitempty"`
+
+	// Author is the author of the image.
+	Author string `json:"author,omitempty"`
+
+	// Platform is the platform the image runs on, if the config sets
+	// it.
+	Platform *Platform `json:"platform,omitempty"`
+
+	// Labels are the labels in the image config.
+	Labels map[string]string `json:"labels,omitempty"`
+}
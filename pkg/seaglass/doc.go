@@ -0,0 +1,33 @@
+// Package seaglass is the public API for listing content in container
+// registries: the Client interface that every registry implements, the types
+// it lists and the options that clients are created with.
+//
+// Clients for specific registries are in the subpackages of
+// github.com/jetstack/seaglass/pkg/seaglass/clients, and clients.NewClient
+// picks the right one for a host:
+//
+//	c, err := clients.NewClient("ghcr.io", seaglass.WithUserAgent("my-service"))
+//	if err != nil {
+//		return err
+//	}
+//	list, err := c.ListManifests(ctx, "jetstack/tally", &seaglass.ManifestListOptions{
+//		IncludeTags: []string{"v*"},
+//	})
+//
+// # Compatibility
+//
+// This package and the packages under it follow semantic versioning. Within a
+// major version, exported identifiers aren't removed or changed in a way that
+// breaks code that uses them as documented, with these exceptions:
+//
+//   - Methods may be added to interfaces that clients implement, like Client.
+//     Embed an implementation, rather than implementing them from scratch, to
+//     stay compatible.
+//   - Fields may be added to structs, so use keyed fields in composite
+//     literals.
+//   - New clients may be chosen for a host by clients.NewClient when they're
+//     more specific than the one that was chosen before.
+//
+// The packages under internal, and the seaglass command, are not part of the
+// API and may change at any time.
+package seaglass
@@ -0,0 +1,367 @@
+package seaglass
+
+import (
+	"fmt"
+	"iter"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ManifestFilter filters manifests by the options in ManifestListOptions
+type ManifestFilter struct {
+	opts          *ManifestListOptions
+	includeRegexp []*regexp.Regexp
+	excludeRegexp []*regexp.Regexp
+	platforms     []Platform
+}
+
+// NewManifestFilter returns a filter for the options. Returns an error if any
+// of the patterns are invalid.
+func NewManifestFilter(opts *ManifestListOptions) (*ManifestFilter, error) {
+	if opts == nil {
+		opts = &ManifestListOptions{}
+	}
+	if opts.Tagged && opts.Untagged {
+		return nil, fmt.Errorf("tagged and untagged are mutually exclusive")
+	}
+
+	f := &ManifestFilter{
+		opts: opts,
+	}
+	for _, pattern := range append(slices.Clone(opts.IncludeTags), opts.ExcludeTags...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("parsing tag pattern %q: %w", pattern, err)
+		}
+	}
+	for _, platform := range opts.Platforms {
+		p, err := ParsePlatform(platform)
+		if err != nil {
+			return nil, err
+		}
+		f.platforms = append(f.platforms, p)
+	}
+	for _, expr := range opts.IncludeTagsRegexp {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("parsing tag regexp: %w", err)
+		}
+		f.includeRegexp = append(f.includeRegexp, re)
+	}
+	for _, expr := range opts.ExcludeTagsRegexp {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("parsing tag regexp: %w", err)
+		}
+		f.excludeRegexp = append(f.excludeRegexp, re)
+	}
+
+	return f, nil
+}
+
+// Filter returns the manifest with only the tags that match the filter, and
+// whether the manifest matches the filter at all.
+//
+// A manifest that had tags, but doesn't have any after the tags are
+// filtered, doesn't match. An untagged manifest only matches the tag filters
+// if there are no include patterns.
+func (f *ManifestFilter) Filter(m Manifest) (Manifest, bool) {
+	o := f.opts
+
+	if o.Tagged && len(m.Tags) == 0 {
+		return m, false
+	}
+	if o.Untagged && len(m.Tags) > 0 {
+		return m, false
+	}
+
+	if len(o.MediaTypes) > 0 && !slices.Contains(o.MediaTypes, m.MediaType) {
+		return m, false
+	}
+
+	if !f.MatchPlatform(m) {
+		return m, false
+	}
+
+	if !inRange(m.Created, o.CreatedBefore, o.CreatedAfter) ||
+		!inRange(m.Uploaded, o.UploadedBefore, o.UploadedAfter) ||
+		!inRange(m.Updated, o.UpdatedBefore, o.UpdatedAfter) {
+		return m, false
+	}
+
+	if !f.filtersTags() {
+		return m, true
+	}
+	if len(m.Tags) == 0 {
+		return m, len(o.IncludeTags) == 0 && len(f.includeRegexp) == 0
+	}
+
+	var tags []string
+	for _, tag := range m.Tags {
+		if f.MatchTag(tag) {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) == 0 {
+		return m, false
+	}
+	m.Tags = tags
+
+	return m, true
+}
+
+// MatchTag returns true if the tag matches the include and exclude patterns
+func (f *ManifestFilter) MatchTag(tag string) bool {
+	o := f.opts
+
+	if len(o.IncludeTags) > 0 || len(f.includeRegexp) > 0 {
+		included := slices.ContainsFunc(o.IncludeTags, func(pattern string) bool {
+			ok, _ := path.Match(pattern, tag)
+			return ok
+		}) || slices.ContainsFunc(f.includeRegexp, func(re *regexp.Regexp) bool {
+			return re.MatchString(tag)
+		})
+		if !included {
+			return false
+		}
+	}
+
+	excluded := slices.ContainsFunc(o.ExcludeTags, func(pattern string) bool {
+		ok, _ := path.Match(pattern, tag)
+		return ok
+	}) || slices.ContainsFunc(f.excludeRegexp, func(re *regexp.Regexp) bool {
+		return re.MatchString(tag)
+	})
+
+	return !excluded
+}
+
+// MatchPlatform returns true if the manifest is an image for one of the
+// platforms, or an index that contains one. A manifest with an unknown platform
+// only matches if there are no platforms to match.
+func (f *ManifestFilter) MatchPlatform(m Manifest) bool {
+	if len(f.platforms) == 0 {
+		return true
+	}
+
+	return slices.ContainsFunc(f.platforms, func(p Platform) bool {
+		if m.Platform != nil && m.Platform.Matches(p) {
+			return true
+		}
+		return slices.ContainsFunc(m.Manifests, func(d Descriptor) bool {
+			return d.Platform != nil && d.Platform.Matches(p)
+		})
+	})
+}
+
+func (f *ManifestFilter) filtersTags() bool {
+	return len(f.opts.IncludeTags) > 0 ||
+		len(f.opts.ExcludeTags) > 0 ||
+		len(f.includeRegexp) > 0 ||
+		len(f.excludeRegexp) > 0
+}
+
+// inRange returns true if the time is within the bounds. A missing time is
+// never within the bounds, if there are any.
+func inRange(t, before, after *time.Time) bool {
+	if before == nil && after == nil {
+		return true
+	}
+	if t == nil {
+		return false
+	}
+	if before != nil && !t.Before(*before) {
+		return false
+	}
+	if after != nil && !t.After(*after) {
+		return false
+	}
+
+	return true
+}
+
+// FilterManifestPages filters each page of manifests by the options. Clients
+// use this to apply the filters that they can't push down to the registry.
+//
+// If the options are invalid, the error is yielded before any pages are
+// listed.
+func FilterManifestPages(pages iter.Seq2[*ManifestList, error], opts *ManifestListOptions) iter.Seq2[*ManifestList, error] {
+	return func(yield func(*ManifestList, error) bool) {
+		f, err := NewManifestFilter(opts)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		for page, err := range pages {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			var manifests []Manifest
+			for _, m := range page.Manifests {
+				if m, ok := f.Filter(m); ok {
+					manifests = append(manifests, m)
+				}
+			}
+
+			if !yield(&ManifestList{Manifests: manifests}, nil) {
+				return
+			}
+		}
+	}
+}
+
+// TagSubstring returns a string that every tag matching the include patterns
+// contains, for clients that can search for tags by name. Returns an empty
+// string if there isn't one.
+//
+// If HideArtifacts is set, the tags of artifacts don't have to match the
+// patterns, so clients that search for tags must list them separately.
+func (o *ManifestListOptions) TagSubstring() string {
+	if o == nil || len(o.IncludeTags) != 1 || len(o.IncludeTagsRegexp) > 0 {
+		return ""
+	}
+
+	return globLiteral(o.IncludeTags[0])
+}
+
+// RepositoryFilter filters repositories by the options in
+// RepositoryListOptions
+type RepositoryFilter struct {
+	opts *RepositoryListOptions
+}
+
+// NewRepositoryFilter returns a filter for the options. Returns an error if
+// any of the patterns are invalid.
+func NewRepositoryFilter(opts *RepositoryListOptions) (*RepositoryFilter, error) {
+	if opts == nil {
+		opts = &RepositoryListOptions{}
+	}
+	if opts.MaxDepth < 0 {
+		return nil, fmt.Errorf("max depth must not be negative")
+	}
+	for _, pattern := range append(slices.Clone(opts.Include), opts.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("parsing repository pattern %q: %w", pattern, err)
+		}
+	}
+
+	return &RepositoryFilter{opts: opts}, nil
+}
+
+// Match returns true if the repository, relative to the parent that's being
+// listed, matches the filter
+func (f *RepositoryFilter) Match(repo string) bool {
+	o := f.opts
+
+	if o.Recursive && o.MaxDepth > 0 && strings.Count(repo, "/")+1 > o.MaxDepth {
+		return false
+	}
+	if !strings.HasPrefix(repo, o.NamePrefix) || !strings.Contains(repo, o.NameContains) {
+		return false
+	}
+
+	match := func(pattern string) bool {
+		ok, _ := path.Match(pattern, repo)
+		return ok
+	}
+	if len(o.Include) > 0 && !slices.ContainsFunc(o.Include, match) {
+		return false
+	}
+
+	return !slices.ContainsFunc(o.Exclude, match)
+}
+
+// Filters returns true if the filter excludes any repositories by name
+func (f *RepositoryFilter) Filters() bool {
+	return len(f.opts.Include) > 0 || f.opts.NamePrefix != "" || f.opts.NameContains != ""
+}
+
+// NameSubstring returns a string that every repository matching the options
+// contains, for clients that can search for repositories by name. Returns an
+// empty string if there isn't one.
+func (o *RepositoryListOptions) NameSubstring() string {
+	if o == nil {
+		return ""
+	}
+	if o.NameContains != "" {
+		return o.NameContains
+	}
+	if o.NamePrefix != "" {
+		return o.NamePrefix
+	}
+	if len(o.Include) == 1 {
+		return globLiteral(o.Include[0])
+	}
+
+	return ""
+}
+
+// FilterRepositoryPages filters each page of repositories by the options.
+// Clients use this to apply the filters that they can't push down to the
+// registry. Pages that are empty after they're filtered are skipped.
+//
+// If the options are invalid, the error is yielded before any pages are
+// listed.
+func FilterRepositoryPages(pages iter.Seq2[*RepositoryList, error], opts *RepositoryListOptions) iter.Seq2[*RepositoryList, error] {
+	return func(yield func(*RepositoryList, error) bool) {
+		f, err := NewRepositoryFilter(opts)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		for page, err := range pages {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			var repos []string
+			for _, repo := range page.Repositories {
+				if f.Match(repo) {
+					repos = append(repos, repo)
+				}
+			}
+			if len(repos) == 0 {
+				continue
+			}
+
+			if !yield(&RepositoryList{Name: page.Name, Repositories: repos}, nil) {
+				return
+			}
+		}
+	}
+}
+
+// globLiteral returns the longest run of literal characters in the glob
+// pattern, skipping over character classes and escapes
+func globLiteral(pattern string) string {
+	var longest string
+	start := 0
+	for i := 0; i <= len(pattern); i++ {
+		if i < len(pattern) && !strings.ContainsRune(`*?[\`, rune(pattern[i])) {
+			continue
+		}
+		if i-start > len(longest) {
+			longest = pattern[start:i]
+		}
+		if i < len(pattern) {
+			switch pattern[i] {
+			case '[':
+				for i < len(pattern) && pattern[i] != ']' {
+					i++
+				}
+			case '\\':
+				i++
+			}
+		}
+		start = i + 1
+	}
+
+	return longest
+}
@@ -0,0 +1,351 @@
+package seaglass
+
+import (
+	"errors"
+	"iter"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFilterManifestPages(t *testing.T) {
+	now := time.Now()
+	hourAgo := now.Add(-1 * time.Hour)
+	dayAgo := now.Add(-24 * time.Hour)
+
+	manifests := []Manifest{
+		{
+			Digest:    "sha256:aaaaaaa",
+			MediaType: "application/vnd.oci.image.index.v1+json",
+			Tags:      []string{"latest", "v1.1.0", "v1.1.0-alpine"},
+			Created:   &hourAgo,
+			Uploaded:  &hourAgo,
+			Updated:   &now,
+			Manifests: []Descriptor{
+				{Digest: "sha256:ddddddd", Platform: &Platform{OS: "linux", Architecture: "amd64"}},
+				{Digest: "sha256:eeeeeee", Platform: &Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}},
+			},
+		},
+		{
+			Digest:    "sha256:bbbbbbb",
+			MediaType: "application/vnd.oci.image.manifest.v1+json",
+			Tags:      []string{"v1.0.0"},
+			Created:   &dayAgo,
+			Uploaded:  &dayAgo,
+			Updated:   &dayAgo,
+			Platform:  &Platform{OS: "linux", Architecture: "amd64"},
+		},
+		{
+			Digest:    "sha256:ccccccc",
+			MediaType: "application/vnd.oci.image.manifest.v1+json",
+		},
+	}
+
+	testCases := map[string]struct {
+		opts    *ManifestListOptions
+		want    []Manifest
+		wantErr bool
+	}{
+		"no filters": {
+			opts: nil,
+			want: manifests,
+		},
+		"include tags": {
+			opts: &ManifestListOptions{
+				IncludeTags: []string{"v1.*"},
+			},
+			want: []Manifest{
+				withTags(manifests[0], "v1.1.0", "v1.1.0-alpine"),
+				manifests[1],
+			},
+		},
+		"exclude tags": {
+			opts: &ManifestListOptions{
+				ExcludeTags: []string{"*-alpine", "v1.0.0"},
+			},
+			want: []Manifest{
+				withTags(manifests[0], "latest", "v1.1.0"),
+				manifests[2],
+			},
+		},
+		"include and exclude tag regexps": {
+			opts: &ManifestListOptions{
+				IncludeTagsRegexp: []string{`^v\d+\.\d+\.\d+`},
+				ExcludeTagsRegexp: []string{`-alpine$`},
+			},
+			want: []Manifest{
+				withTags(manifests[0], "v1.1.0"),
+				manifests[1],
+			},
+		},
+		"created after": {
+			opts: &ManifestListOptions{
+				CreatedAfter: ptr(now.Add(-2 * time.Hour)),
+			},
+			want: []Manifest{
+				manifests[0],
+			},
+		},
+		"uploaded before": {
+			opts: &ManifestListOptions{
+				UploadedBefore: ptr(now.Add(-2 * time.Hour)),
+			},
+			want: []Manifest{
+				manifests[1],
+			},
+		},
+		"updated between": {
+			opts: &ManifestListOptions{
+				UpdatedAfter:  ptr(now.Add(-48 * time.Hour)),
+				UpdatedBefore: ptr(now.Add(-2 * time.Hour)),
+			},
+			want: []Manifest{
+				manifests[1],
+			},
+		},
+		"media type": {
+			opts: &ManifestListOptions{
+				MediaTypes: []string{"application/vnd.oci.image.index.v1+json"},
+			},
+			want: []Manifest{
+				manifests[0],
+			},
+		},
+		"tagged": {
+			opts: &ManifestListOptions{
+				Tagged: true,
+			},
+			want: manifests[:2],
+		},
+		"untagged": {
+			opts: &ManifestListOptions{
+				Untagged: true,
+			},
+			want: manifests[2:],
+		},
+		"platform": {
+			opts: &ManifestListOptions{
+				Platforms: []string{"linux/amd64"},
+			},
+			want: manifests[:2],
+		},
+		"platform with variant": {
+			opts: &ManifestListOptions{
+				Platforms: []string{"linux/arm64/v8"},
+			},
+			want: manifests[:1],
+		},
+		"invalid platform": {
+			opts: &ManifestListOptions{
+				Platforms: []string{"linux"},
+			},
+			wantErr: true,
+		},
+		"tagged and untagged": {
+			opts: &ManifestListOptions{
+				Tagged:   true,
+				Untagged: true,
+			},
+			wantErr: true,
+		},
+		"invalid glob": {
+			opts: &ManifestListOptions{
+				IncludeTags: []string{"[v1"},
+			},
+			wantErr: true,
+		},
+		"invalid regexp": {
+			opts: &ManifestListOptions{
+				ExcludeTagsRegexp: []string{"(v1"},
+			},
+			wantErr: true,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			pages := func(yield func(*ManifestList, error) bool) {
+				for _, m := range manifests {
+					if !yield(&ManifestList{Manifests: []Manifest{m}}, nil) {
+						return
+					}
+				}
+			}
+
+			var got []Manifest
+			for page, err := range FilterManifestPages(pages, tc.opts) {
+				if err != nil {
+					if !tc.wantErr {
+						t.Fatalf("unexpected error: %s", err)
+					}
+					return
+				}
+				got = append(got, page.Manifests...)
+			}
+			if tc.wantErr {
+				t.Fatalf("expected error")
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("unexpected result:\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("error listing pages", func(t *testing.T) {
+		wantErr := errors.New("error")
+
+		var pages iter.Seq2[*ManifestList, error] = func(yield func(*ManifestList, error) bool) {
+			yield(nil, wantErr)
+		}
+
+		for _, err := range FilterManifestPages(pages, nil) {
+			if !errors.Is(err, wantErr) {
+				t.Errorf("unexpected error: %s", err)
+			}
+		}
+	})
+}
+
+func withTags(m Manifest, tags ...string) Manifest {
+	m.Tags = tags
+	return m
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestFilterRepositoryPages(t *testing.T) {
+	repos := []string{"app", "app/api", "app/api/v1", "app/web", "tools", "tools/app"}
+
+	testCases := map[string]struct {
+		opts    *RepositoryListOptions
+		want    []string
+		wantErr bool
+	}{
+		"no filters": {
+			opts: nil,
+			want: repos,
+		},
+		"max depth": {
+			opts: &RepositoryListOptions{
+				Recursive: true,
+				MaxDepth:  2,
+			},
+			want: []string{"app", "app/api", "app/web", "tools", "tools/app"},
+		},
+		"include and exclude": {
+			opts: &RepositoryListOptions{
+				Recursive: true,
+				Include:   []string{"app/*", "tools"},
+				Exclude:   []string{"*/web"},
+			},
+			want: []string{"app/api", "tools"},
+		},
+		"name prefix": {
+			opts: &RepositoryListOptions{
+				NamePrefix: "app/",
+			},
+			want: []string{"app/api", "app/api/v1", "app/web"},
+		},
+		"name contains": {
+			opts: &RepositoryListOptions{
+				NameContains: "app",
+			},
+			want: []string{"app", "app/api", "app/api/v1", "app/web", "tools/app"},
+		},
+		"negative max depth": {
+			opts: &RepositoryListOptions{
+				MaxDepth: -1,
+			},
+			wantErr: true,
+		},
+		"invalid glob": {
+			opts: &RepositoryListOptions{
+				Exclude: []string{"[app"},
+			},
+			wantErr: true,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			pages := func(yield func(*RepositoryList, error) bool) {
+				for _, repo := range repos {
+					if !yield(&RepositoryList{Repositories: []string{repo}}, nil) {
+						return
+					}
+				}
+			}
+
+			var got []string
+			for page, err := range FilterRepositoryPages(pages, tc.opts) {
+				if err != nil {
+					if !tc.wantErr {
+						t.Fatalf("unexpected error: %s", err)
+					}
+					return
+				}
+				got = append(got, page.Repositories...)
+			}
+			if tc.wantErr {
+				t.Fatalf("expected error")
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("unexpected result:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGlobLiteral(t *testing.T) {
+	testCases := map[string]string{
+		"v1.*":           "v1.",
+		"v[12].0-alpine": ".0-alpine",
+		"*-alpine*":      "-alpine",
+		"latest":         "latest",
+		`a\*bcd`:         "bcd",
+		"*":              "",
+	}
+	for pattern, want := range testCases {
+		if got := globLiteral(pattern); got != want {
+			t.Errorf("unexpected literal for %q: %q", pattern, got)
+		}
+	}
+}
+
+func TestTagSubstring(t *testing.T) {
+	testCases := map[string]struct {
+		opts *ManifestListOptions
+		want string
+	}{
+		"no options": {
+			opts: nil,
+			want: "",
+		},
+		"one pattern": {
+			opts: &ManifestListOptions{IncludeTags: []string{"v1.*"}},
+			want: "v1.",
+		},
+		"one pattern with artifacts hidden": {
+			opts: &ManifestListOptions{IncludeTags: []string{"v1.*"}, HideArtifacts: true},
+			want: "v1.",
+		},
+		"more than one pattern": {
+			opts: &ManifestListOptions{IncludeTags: []string{"v1.*", "v2.*"}},
+			want: "",
+		},
+		"regular expression": {
+			opts: &ManifestListOptions{IncludeTags: []string{"v1.*"}, IncludeTagsRegexp: []string{"^v"}},
+			want: "",
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if got := tc.opts.TagSubstring(); got != tc.want {
+				t.Errorf("unexpected substring: %q", got)
+			}
+		})
+	}
+}
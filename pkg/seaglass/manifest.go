@@ -0,0 +1,208 @@
+package seaglass
+
+import (
+	"iter"
+	"slices"
+	"sort"
+	"time"
+)
+
+// Manifest describes a manifest in a container registry
+type Manifest struct {
+	// Digest is the digest of the manifest.
+	Digest string `json:"digest"`
+
+	// MediaType is the media type of the manifest.
+	MediaType string `json:"mediaType,omitempty"`
+
+	// Tags contains a list of tags associated with this object.
+	Tags []string `json:"tags,omitempty"`
+
+	// Size is the size of the content referenced by the manifest, in
+	// bytes, if the registry reports it.
+	Size int64 `json:"size,omitempty"`
+
+	// Created is when the manifest was created.
+	//
+	// This value is an immutable property taken from the manifest itself.
+	//
+	// It's a user-controlled (and therefore not 100% reliable) property of
+	// the image config.
+	//
+	// Typically the tool that builds the image (i.e docker) will set it
+	// to the time the build finished but it's increasingly common for
+	// builders to set it to a value like the Unix Epoch time for the sake
+	// of reproducibility.
+	Created *time.Time `json:"timeCreated,omitempty"`
+
+	// Uploaded is when the manifest was uploaded to the registry
+	Uploaded *time.Time `json:"timeUploaded,omitempty"`
+
+	// Updated is when the manifest was updated in the registry.
+	//
+	// Different registries may have different definitions of what
+	// constitutes an 'update'. That may include when tags are updated on
+	// the manifest, some other property or it may not actually be possible
+	// for a manifest to be 'updated' because the content is immutable.
+	Updated *time.Time `json:"timeUpdated,omitempty"`
+
+	// Pulled is when the manifest was last pulled from the registry, for
+	// registries that track it.
+	Pulled *time.Time `json:"timePulled,omitempty"`
+
+	// Platform is the platform of an image, if it's known.
+	Platform *Platform `json:"platform,omitempty"`
+
+	// Manifests are the manifests in an image index or manifest list,
+	// with their platforms, if they're known.
+	Manifests []Descriptor `json:"manifests,omitempty"`
+
+	// Referrers are the manifests that refer to this one, like
+	// signatures, SBOMs and attestations, if they were listed.
+	Referrers []Referrer `json:"referrers,omitempty"`
+}
+
+// ManifestListOptions are options for listing manifests
+type ManifestListOptions struct {
+	ListOptions
+
+	// Concurrency is the maximum number of requests that a client will
+	// make at once when it has to fetch the details of each tag
+	// individually. If it's zero, the client uses its own default.
+	Concurrency int `json:"concurrency,omitempty"`
+
+	// IncludeTags are glob patterns, as in path.Match. If any are set,
+	// only tags that match one of them are listed.
+	IncludeTags []string `json:"includeTags,omitempty"`
+
+	// ExcludeTags are glob patterns for tags that shouldn't be listed.
+	ExcludeTags []string `json:"excludeTags,omitempty"`
+
+	// IncludeTagsRegexp are regular expressions. If any are set, only
+	// tags that match one of them are listed.
+	IncludeTagsRegexp []string `json:"includeTagsRegexp,omitempty"`
+
+	// ExcludeTagsRegexp are regular expressions for tags that shouldn't
+	// be listed.
+	ExcludeTagsRegexp []string `json:"excludeTagsRegexp,omitempty"`
+
+	// CreatedBefore and CreatedAfter only list manifests that were
+	// created before or after the time.
+	CreatedBefore *time.Time `json:"createdBefore,omitempty"`
+	CreatedAfter  *time.Time `json:"createdAfter,omitempty"`
+
+	// UploadedBefore and UploadedAfter only list manifests that were
+	// uploaded before or after the time.
+	UploadedBefore *time.Time `json:"uploadedBefore,omitempty"`
+	UploadedAfter  *time.Time `json:"uploadedAfter,omitempty"`
+
+	// UpdatedBefore and UpdatedAfter only list manifests that were
+	// updated before or after the time.
+	UpdatedBefore *time.Time `json:"updatedBefore,omitempty"`
+	UpdatedAfter  *time.Time `json:"updatedAfter,omitempty"`
+
+	// MediaTypes only lists manifests with one of the media types.
+	MediaTypes []string `json:"mediaTypes,omitempty"`
+
+	// Tagged only lists manifests that have at least one tag.
+	Tagged bool `json:"tagged,omitempty"`
+
+	// Untagged only lists manifests that don't have any tags.
+	Untagged bool `json:"untagged,omitempty"`
+
+	// Platforms only lists images for one of the platforms, in the form
+	// os/arch[/variant], and indexes that contain an image for one of
+	// them. If the variant is left out, it matches any variant.
+	//
+	// Setting this implies ResolvePlatforms.
+	Platforms []string `json:"platforms,omitempty"`
+
+	// ResolvePlatforms fetches the platform of each image and the
+	// manifests in each index, for clients that don't get them from the
+	// listing itself. This costs up to two extra requests per manifest.
+	ResolvePlatforms bool `json:"resolvePlatforms,omitempty"`
+
+	// Referrers lists the referrers of each manifest. This costs extra
+	// requests per manifest.
+	Referrers bool `json:"referrers,omitempty"`
+
+	// HideArtifacts doesn't list the tags in the referrer tag schemes,
+	// like cosign's sha256-<hex>.sig, as tags. The artifacts they point to
+	// are added to the referrers of the manifest they refer to instead.
+	// See GroupArtifactPages.
+	HideArtifacts bool `json:"hideArtifacts,omitempty"`
+}
+
+// ManifestList is a list of manifests
+type ManifestList struct {
+	Manifests []Manifest `json:"manifests"`
+}
+
+// Merge merges another entry for the same manifest into this one. The tags are
+// combined and the timestamps are widened to cover both entries.
+func (m *Manifest) Merge(other Manifest) {
+	for _, tag := range other.Tags {
+		if !slices.Contains(m.Tags, tag) {
+			m.Tags = append(m.Tags, tag)
+		}
+	}
+	if m.MediaType == "" {
+		m.MediaType = other.MediaType
+	}
+	if m.Size == 0 {
+		m.Size = other.Size
+	}
+	if m.Created == nil {
+		m.Created = other.Created
+	}
+	if other.Uploaded != nil && (m.Uploaded == nil || other.Uploaded.Before(*m.Uploaded)) {
+		m.Uploaded = other.Uploaded
+	}
+	if other.Updated != nil && (m.Updated == nil || other.Updated.After(*m.Updated)) {
+		m.Updated = other.Updated
+	}
+	if other.Pulled != nil && (m.Pulled == nil || other.Pulled.After(*m.Pulled)) {
+		m.Pulled = other.Pulled
+	}
+	if m.Platform == nil {
+		m.Platform = other.Platform
+	}
+	if m.Manifests == nil {
+		m.Manifests = other.Manifests
+	}
+	if m.Referrers == nil && other.Referrers != nil {
+		m.Referrers = []Referrer{}
+	}
+	m.AddReferrers(other.Referrers...)
+}
+
+// CollectManifests collects the pages of manifests yielded by
+// Client.ListManifestPages into a single list. Manifests that appear in more
+// than one page are merged and the tags of each manifest are sorted.
+func CollectManifests(pages iter.Seq2[*ManifestList, error]) (*ManifestList, error) {
+	var manifests []Manifest
+
+	index := map[string]int{}
+	for page, err := range pages {
+		if err != nil {
+			return nil, err
+		}
+		for _, manifest := range page.Manifests {
+			i, ok := index[manifest.Digest]
+			if !ok {
+				index[manifest.Digest] = len(manifests)
+				manifests = append(manifests, manifest)
+				continue
+			}
+			manifests[i].Merge(manifest)
+		}
+	}
+
+	for _, manifest := range manifests {
+		sort.Strings(manifest.Tags)
+	}
+
+	return &ManifestList{
+		Manifests: manifests,
+	}, nil
+}
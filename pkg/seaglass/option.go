@@ -0,0 +1,151 @@
+package seaglass
+
+import (
+	"net/http"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/jetstack/seaglass/internal/transport"
+	"golang.org/x/time/rate"
+)
+
+// Option configures a client. Pass options to a ClientFactory, like
+// clients.NewClient.
+type Option func(*ClientOptions)
+
+// WithKeychain sets the keychain that credentials for the registry, and for
+// its API, are resolved from. The default is authn.DefaultKeychain, which
+// reads the Docker config. Clients for registries with their own credential
+// helpers, like Google Artifact Registry and GitHub, add them to the keychain.
+func WithKeychain(kc authn.Keychain) Option {
+	return func(o *ClientOptions) {
+		o.keychain = kc
+	}
+}
+
+// WithHTTPClient sets the HTTP client that requests are made with. Clients add
+// authentication, the user agent and rate limiting to its transport, without
+// modifying the client itself.
+func WithHTTPClient(c *http.Client) Option {
+	return func(o *ClientOptions) {
+		o.httpClient = c
+	}
+}
+
+// WithAPITransport wraps the transport of requests to the registry's API for
+// listing repositories and tags, like the Docker Hub and GitHub APIs and the
+// tags list of Google Container Registry. Requests for manifests and blobs
+// don't go through it.
+func WithAPITransport(wrap func(http.RoundTripper) http.RoundTripper) Option {
+	return func(o *ClientOptions) {
+		o.apiTransport = wrap
+	}
+}
+
+// WithUserAgent sets the User-Agent header of every request
+func WithUserAgent(ua string) Option {
+	return func(o *ClientOptions) {
+		o.userAgent = ua
+	}
+}
+
+// WithRateLimit limits the requests that a client makes to limit per second,
+// with bursts of up to burst requests. Clients that have their own default
+// limit, because the registry enforces one, use this instead of it.
+func WithRateLimit(limit rate.Limit, burst int) Option {
+	return func(o *ClientOptions) {
+		o.rateLimit = limit
+		o.burst = burst
+		o.rateLimitSet = true
+	}
+}
+
+// ClientOptions are the options that a client is created with. Client
+// implementations use them to make requests, so that every client is
+// configured in the same way.
+type ClientOptions struct {
+	keychain     authn.Keychain
+	httpClient   *http.Client
+	apiTransport func(http.RoundTripper) http.RoundTripper
+	userAgent    string
+	rateLimit    rate.Limit
+	burst        int
+	rateLimitSet bool
+}
+
+// NewClientOptions applies the options to the defaults
+func NewClientOptions(opts ...Option) *ClientOptions {
+	o := &ClientOptions{
+		keychain: authn.DefaultKeychain,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// Keychain returns the keychain to resolve credentials from
+func (o *ClientOptions) Keychain() authn.Keychain {
+	return o.keychain
+}
+
+// Transport returns a transport for requests to the registry. It sets the
+// user agent and, if there's a rate limit, waits for it before each request.
+// The limit is the one set with WithRateLimit or otherwise the limit and burst
+// passed in. A limit of zero means no limit.
+//
+// Each transport has its own rate limiter, so a client should create one
+// transport and share it between all of its requests.
+func (o *ClientOptions) Transport(limit rate.Limit, burst int) http.RoundTripper {
+	var rt http.RoundTripper = remote.DefaultTransport
+	if o.httpClient != nil && o.httpClient.Transport != nil {
+		rt = o.httpClient.Transport
+	}
+
+	if o.rateLimitSet {
+		limit, burst = o.rateLimit, o.burst
+	}
+	if limit > 0 {
+		rt = transport.NewRateLimitTransport(rt, rate.NewLimiter(limit, burst))
+	}
+
+	if o.userAgent != "" {
+		rt = transport.NewUserAgentTransport(rt, o.userAgent)
+	}
+
+	return rt
+}
+
+// APITransport returns the transport wrapped with the one set with
+// WithAPITransport, for requests to the registry's API
+func (o *ClientOptions) APITransport(rt http.RoundTripper) http.RoundTripper {
+	if o.apiTransport == nil {
+		return rt
+	}
+
+	return o.apiTransport(rt)
+}
+
+// HTTPClient returns a copy of the HTTP client from the options, or a new one,
+// that makes requests to the registry's API with the transport, wrapped with
+// APITransport
+func (o *ClientOptions) HTTPClient(rt http.RoundTripper) *http.Client {
+	c := &http.Client{}
+	if o.httpClient != nil {
+		*c = *o.httpClient
+	}
+	c.Transport = o.APITransport(rt)
+
+	return c
+}
+
+// RemoteOptions returns the options for requests to the registry with
+// go-containerregistry, which authenticate with the keychain and make requests
+// with the transport
+func (o *ClientOptions) RemoteOptions(rt http.RoundTripper) []remote.Option {
+	return []remote.Option{
+		remote.WithAuthFromKeychain(o.keychain),
+		remote.WithTransport(rt),
+	}
+}
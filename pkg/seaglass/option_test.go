@@ -0,0 +1,112 @@
+package seaglass
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"golang.org/x/time/rate"
+)
+
+func TestClientOptions(t *testing.T) {
+	var userAgent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+	}))
+	defer srv.Close()
+
+	t.Run("defaults", func(t *testing.T) {
+		o := NewClientOptions()
+		if o.Keychain() != authn.DefaultKeychain {
+			t.Errorf("expected the default keychain")
+		}
+
+		resp, err := o.HTTPClient(o.Transport(0, 0)).Get(srv.URL)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		resp.Body.Close()
+		if userAgent != "Go-http-client/1.1" {
+			t.Errorf("unexpected user agent: %s", userAgent)
+		}
+	})
+
+	t.Run("options", func(t *testing.T) {
+		httpClient := &http.Client{Timeout: time.Minute}
+		o := NewClientOptions(
+			WithKeychain(authn.NewMultiKeychain()),
+			WithHTTPClient(httpClient),
+			WithUserAgent("test"),
+		)
+		if o.Keychain() == authn.DefaultKeychain {
+			t.Errorf("expected the keychain from the options")
+		}
+
+		c := o.HTTPClient(o.Transport(0, 0))
+		if c == httpClient || httpClient.Transport != nil {
+			t.Errorf("expected a copy of the http client")
+		}
+		if c.Timeout != time.Minute {
+			t.Errorf("expected the timeout of the http client, got: %s", c.Timeout)
+		}
+
+		resp, err := c.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		resp.Body.Close()
+		if userAgent != "test" {
+			t.Errorf("unexpected user agent: %s", userAgent)
+		}
+	})
+
+	t.Run("rate limit", func(t *testing.T) {
+		// The limit from the options replaces the client's own
+		// limit, which would allow every request at once
+		o := NewClientOptions(WithRateLimit(rate.Every(50*time.Millisecond), 1))
+		c := o.HTTPClient(o.Transport(rate.Inf, 0))
+
+		start := time.Now()
+		for range 3 {
+			resp, err := c.Get(srv.URL)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			resp.Body.Close()
+		}
+		if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+			t.Errorf("expected requests to be rate limited, took %s", elapsed)
+		}
+	})
+
+	t.Run("api transport", func(t *testing.T) {
+		var wrapped int
+		o := NewClientOptions(WithAPITransport(func(rt http.RoundTripper) http.RoundTripper {
+			return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				wrapped++
+				return rt.RoundTrip(r)
+			})
+		}))
+
+		// Only requests to the API go through the wrapped transport
+		rt := o.Transport(0, 0)
+		for _, c := range []*http.Client{o.HTTPClient(rt), {Transport: rt}} {
+			resp, err := c.Get(srv.URL)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			resp.Body.Close()
+		}
+		if wrapped != 1 {
+			t.Errorf("expected 1 request through the api transport, got: %d", wrapped)
+		}
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
@@ -1,4 +1,4 @@
-package v1
+package seaglass
 
 // ListOptions are generic options for listing content
 type ListOptions struct{}
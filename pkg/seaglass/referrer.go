@@ -0,0 +1,148 @@
+package seaglass
+
+import (
+	"iter"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const (
+	// ArtifactTypeCosignSignature is the artifact type of a cosign
+	// signature
+	ArtifactTypeCosignSignature = "application/vnd.dev.cosign.artifact.sig.v1+json"
+
+	// ArtifactTypeCosignAttestation is the artifact type of a cosign
+	// attestation
+	ArtifactTypeCosignAttestation = "application/vnd.dev.cosign.artifact.att.v1+json"
+
+	// ArtifactTypeCosignSBOM is the artifact type of an SBOM attached by
+	// cosign
+	ArtifactTypeCosignSBOM = "application/vnd.dev.cosign.artifact.sbom.v1+json"
+)
+
+// Referrer is a manifest that refers to another manifest, like a signature,
+// SBOM or attestation
+type Referrer struct {
+	Descriptor
+
+	// Tag is the tag that the referrer was found by, for referrers that
+	// are stored with a tag scheme rather than the referrers API.
+	Tag string `json:"tag,omitempty"`
+}
+
+// ReferrerTags are the suffixes of the tags that cosign stores referrers in,
+// with the artifact type of each
+var ReferrerTags = []struct {
+	Suffix       string
+	ArtifactType string
+}{
+	{".sig", ArtifactTypeCosignSignature},
+	{".att", ArtifactTypeCosignAttestation},
+	{".sbom", ArtifactTypeCosignSBOM},
+}
+
+// ReferrerTag returns the tag that cosign stores a referrer for the digest in,
+// like sha256-<hex>.sig
+func ReferrerTag(digest, suffix string) string {
+	return strings.Replace(digest, ":", "-", 1) + suffix
+}
+
+var referrerTagRegexp = regexp.MustCompile(`^(sha256|sha512)-([a-f0-9]{64}|[a-f0-9]{128})(\.[a-z]+)?$`)
+
+// ParseReferrerTag parses a tag in one of the referrer tag schemes: cosign's
+// sha256-<hex>.sig, .att and .sbom tags, or the sha256-<hex> tag that the OCI
+// distribution spec uses for registries that don't support the referrers API.
+// It returns the digest of the subject and the artifact type, which is empty
+// for the OCI tag.
+func ParseReferrerTag(tag string) (subject, artifactType string, ok bool) {
+	m := referrerTagRegexp.FindStringSubmatch(tag)
+	if m == nil {
+		return "", "", false
+	}
+	subject = m[1] + ":" + m[2]
+
+	if m[3] == "" {
+		return subject, "", true
+	}
+	for _, rt := range ReferrerTags {
+		if rt.Suffix == m[3] {
+			return subject, rt.ArtifactType, true
+		}
+	}
+
+	return "", "", false
+}
+
+// AddReferrers adds the referrers to the manifest, skipping any that it
+// already has
+func (m *Manifest) AddReferrers(referrers ...Referrer) {
+	for _, r := range referrers {
+		if slices.ContainsFunc(m.Referrers, func(o Referrer) bool { return o.Digest == r.Digest }) {
+			continue
+		}
+		m.Referrers = append(m.Referrers, r)
+	}
+}
+
+// GroupArtifactPages removes the tags in the referrer tag schemes from the
+// pages, if the options ask for it, and adds the artifacts that they point to
+// to the referrers of their subject manifest. Manifests that are only tagged
+// with those tags aren't listed, and neither are artifacts whose subject isn't
+// listed.
+//
+// An artifact may be listed before its subject, so the pages are collected and
+// yielded as a single page once every page has been listed.
+func GroupArtifactPages(pages iter.Seq2[*ManifestList, error], opts *ManifestListOptions) iter.Seq2[*ManifestList, error] {
+	if opts == nil || !opts.HideArtifacts {
+		return pages
+	}
+
+	return func(yield func(*ManifestList, error) bool) {
+		list, err := CollectManifests(pages)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		var manifests []Manifest
+		referrers := map[string][]Referrer{}
+		for _, m := range list.Manifests {
+			var tags []string
+			for _, tag := range m.Tags {
+				subject, artifactType, ok := ParseReferrerTag(tag)
+				if !ok {
+					tags = append(tags, tag)
+					continue
+				}
+
+				// The OCI tag holds an index of the referrers,
+				// rather than being one
+				if artifactType == "" {
+					continue
+				}
+				referrers[subject] = append(referrers[subject], Referrer{
+					Descriptor: Descriptor{
+						Digest:       m.Digest,
+						MediaType:    m.MediaType,
+						ArtifactType: artifactType,
+						Size:         m.Size,
+					},
+					Tag: tag,
+				})
+			}
+			if len(m.Tags) > 0 && len(tags) == 0 {
+				continue
+			}
+			m.Tags = tags
+
+			manifests = append(manifests, m)
+		}
+
+		for i := range manifests {
+			manifests[i].AddReferrers(referrers[manifests[i].Digest]...)
+		}
+
+		yield(&ManifestList{Manifests: manifests}, nil)
+	}
+}
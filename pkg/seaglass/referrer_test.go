@@ -0,0 +1,145 @@
+package seaglass
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseReferrerTag(t *testing.T) {
+	hex := strings.Repeat("a", 64)
+
+	testCases := map[string]struct {
+		wantSubject      string
+		wantArtifactType string
+		wantOK           bool
+	}{
+		"sha256-" + hex + ".sig": {
+			wantSubject:      "sha256:" + hex,
+			wantArtifactType: ArtifactTypeCosignSignature,
+			wantOK:           true,
+		},
+		"sha256-" + hex + ".att": {
+			wantSubject:      "sha256:" + hex,
+			wantArtifactType: ArtifactTypeCosignAttestation,
+			wantOK:           true,
+		},
+		"sha256-" + hex + ".sbom": {
+			wantSubject:      "sha256:" + hex,
+			wantArtifactType: ArtifactTypeCosignSBOM,
+			wantOK:           true,
+		},
+		"sha256-" + hex: {
+			wantSubject: "sha256:" + hex,
+			wantOK:      true,
+		},
+		"sha256-" + hex + ".foo": {},
+		"sha256-abc.sig":         {},
+		"v1.0.0":                 {},
+		"latest":                 {},
+	}
+	for tag, tc := range testCases {
+		subject, artifactType, ok := ParseReferrerTag(tag)
+		if subject != tc.wantSubject || artifactType != tc.wantArtifactType || ok != tc.wantOK {
+			t.Errorf("unexpected result for %q: %q, %q, %t", tag, subject, artifactType, ok)
+		}
+	}
+}
+
+func TestGroupArtifactPages(t *testing.T) {
+	subject := "sha256:" + strings.Repeat("a", 64)
+	other := "sha256:" + strings.Repeat("b", 64)
+
+	manifests := []Manifest{
+		{
+			Digest:    "sha256:1111111",
+			MediaType: "application/vnd.oci.image.manifest.v1+json",
+			Tags:      []string{ReferrerTag(subject, ".sig")},
+			Size:      100,
+		},
+		{
+			Digest: subject,
+			Tags:   []string{"latest", "v1.0.0"},
+		},
+		{
+			Digest: "sha256:2222222",
+			Tags:   []string{ReferrerTag(subject, ".att")},
+		},
+		{
+			Digest: "sha256:3333333",
+			Tags:   []string{ReferrerTag(subject, "")},
+		},
+		{
+			// The subject of this signature isn't listed
+			Digest: "sha256:4444444",
+			Tags:   []string{ReferrerTag(other, ".sig")},
+		},
+		{
+			Digest: "sha256:5555555",
+		},
+	}
+
+	pages := func(yield func(*ManifestList, error) bool) {
+		for _, m := range manifests {
+			if !yield(&ManifestList{Manifests: []Manifest{m}}, nil) {
+				return
+			}
+		}
+	}
+
+	t.Run("hide artifacts", func(t *testing.T) {
+		var got []Manifest
+		for page, err := range GroupArtifactPages(pages, &ManifestListOptions{HideArtifacts: true}) {
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			got = append(got, page.Manifests...)
+		}
+
+		want := []Manifest{
+			{
+				Digest: subject,
+				Tags:   []string{"latest", "v1.0.0"},
+				Referrers: []Referrer{
+					{
+						Descriptor: Descriptor{
+							Digest:       "sha256:1111111",
+							MediaType:    "application/vnd.oci.image.manifest.v1+json",
+							ArtifactType: ArtifactTypeCosignSignature,
+							Size:         100,
+						},
+						Tag: ReferrerTag(subject, ".sig"),
+					},
+					{
+						Descriptor: Descriptor{
+							Digest:       "sha256:2222222",
+							ArtifactType: ArtifactTypeCosignAttestation,
+						},
+						Tag: ReferrerTag(subject, ".att"),
+					},
+				},
+			},
+			{
+				Digest: "sha256:5555555",
+			},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+
+	t.Run("show artifacts", func(t *testing.T) {
+		var got []Manifest
+		for page, err := range GroupArtifactPages(pages, &ManifestListOptions{}) {
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			got = append(got, page.Manifests...)
+		}
+
+		if diff := cmp.Diff(manifests, got); diff != "" {
+			t.Errorf("unexpected result:\n%s", diff)
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package seaglass
+
+import "iter"
+
+// RepositoryList describes the child repositories of a repository in the
+// registry
+type RepositoryList struct {
+	// Name is the full name of the parent repository. Will be empty if
+	// this is a list at the root of the registry.
+	Name string `json:"name,omitempty"`
+
+	// Repositories are the child repositories, relative to
+	// the parent repository.
+	//
+	// If Recursive is false, this will only include direct descendents.
+	Repositories []string `json:"repositories"`
+}
+
+// RepositoryListOptions are options for listing repositories
+type RepositoryListOptions struct {
+	ListOptions
+
+	// Recursive will list all the child repositories, not just the direct
+	// children.
+	Recursive bool `json:"recursive"`
+
+	// MaxDepth is the maximum depth of the child repositories to list,
+	// when Recursive is true. Direct children have a depth of 1. If it's
+	// zero, there's no limit.
+	MaxDepth int `json:"maxDepth,omitempty"`
+
+	// Include are glob patterns, as in path.Match, that are matched
+	// against the path of each child repository, relative to the parent.
+	// If any are set, only repositories that match one of them are
+	// listed.
+	Include []string `json:"include,omitempty"`
+
+	// Exclude are glob patterns for child repositories that shouldn't be
+	// listed.
+	Exclude []string `json:"exclude,omitempty"`
+
+	// NamePrefix only lists child repositories with a relative path that
+	// starts with the prefix.
+	NamePrefix string `json:"namePrefix,omitempty"`
+
+	// NameContains only lists child repositories with a relative path
+	// that contains the string.
+	NameContains string `json:"nameContains,omitempty"`
+}
+
+// CollectRepositories collects the pages of repositories yielded by
+// Client.ListRepositoryPages into a single list
+func CollectRepositories(repo string, pages iter.Seq2[*RepositoryList, error]) (*RepositoryList, error) {
+	list := &RepositoryList{
+		Name: repo,
+	}
+	for page, err := range pages {
+		if err != nil {
+			return nil, err
+		}
+		list.Repositories = append(list.Repositories, page.Repositories...)
+	}
+
+	return list, nil
+}
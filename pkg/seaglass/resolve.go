@@ -0,0 +1,56 @@
+package seaglass
+
+import (
+	"context"
+	"errors"
+)
+
+// ResolveTag returns the manifest that the tag points to in the repository.
+// It uses the client's TagResolver if it has one, or otherwise lists the
+// manifests with the tag.
+//
+// Returns ErrNotFound if the tag doesn't exist.
+func ResolveTag(ctx context.Context, c Client, repo, tag string) (*Manifest, error) {
+	if r, ok := c.(TagResolver); ok {
+		manifest, err := r.ResolveTag(ctx, repo, tag)
+		if !errors.Is(err, ErrNotSupported) {
+			return manifest, err
+		}
+	}
+
+	// Tags can't contain any of the special characters in a pattern, so
+	// the tag only matches itself
+	list, err := c.ListManifests(ctx, repo, &ManifestListOptions{IncludeTags: []string{tag}})
+	if err != nil {
+		return nil, err
+	}
+	for _, manifest := range list.Manifests {
+		for _, t := range manifest.Tags {
+			if t == tag {
+				return &manifest, nil
+			}
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+// FindManifest returns the manifest with the digest in the repository, with
+// every tag that points to it. Registries don't have a way to look up the
+// tags of a manifest, so this lists the repository.
+//
+// Returns ErrNotFound if the manifest isn't in the listing, which includes
+// untagged manifests for registries that only list tags.
+func FindManifest(ctx context.Context, c Client, repo, digest string) (*Manifest, error) {
+	list, err := c.ListManifests(ctx, repo, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, manifest := range list.Manifests {
+		if manifest.Digest == digest {
+			return &manifest, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
@@ -0,0 +1,125 @@
+package seaglass
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestResolveTag(t *testing.T) {
+	list := &ManifestList{
+		Manifests: []Manifest{
+			{Digest: "sha256:aaaaaaa", Tags: []string{"latest", "v1.0.0"}},
+			{Digest: "sha256:bbbbbbb", Tags: []string{"v0.9.0"}},
+		},
+	}
+
+	testCases := map[string]struct {
+		client  Client
+		tag     string
+		want    *Manifest
+		wantErr error
+	}{
+		"resolver": {
+			client: &fakeResolver{manifest: &Manifest{Digest: "sha256:ccccccc", Tags: []string{"latest"}}},
+			tag:    "latest",
+			want:   &Manifest{Digest: "sha256:ccccccc", Tags: []string{"latest"}},
+		},
+		"resolver not found": {
+			client:  &fakeResolver{err: ErrNotFound},
+			tag:     "latest",
+			wantErr: ErrNotFound,
+		},
+		"resolver not supported": {
+			client: &fakeResolver{fakeLister: fakeLister{list: list}, err: ErrNotSupported},
+			tag:    "v0.9.0",
+			want:   &Manifest{Digest: "sha256:bbbbbbb", Tags: []string{"v0.9.0"}},
+		},
+		"listing": {
+			client: &fakeLister{list: list},
+			tag:    "v1.0.0",
+			want:   &Manifest{Digest: "sha256:aaaaaaa", Tags: []string{"latest", "v1.0.0"}},
+		},
+		"listing not found": {
+			client:  &fakeLister{list: list},
+			tag:     "v2.0.0",
+			wantErr: ErrNotFound,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := ResolveTag(context.Background(), tc.client, "foo/bar", tc.tag)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("unexpected manifest:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFindManifest(t *testing.T) {
+	c := &fakeLister{
+		list: &ManifestList{
+			Manifests: []Manifest{
+				{Digest: "sha256:aaaaaaa", Tags: []string{"latest", "v1.0.0"}},
+				{Digest: "sha256:bbbbbbb", Tags: []string{"v0.9.0"}},
+			},
+		},
+	}
+
+	got, err := FindManifest(context.Background(), c, "foo/bar", "sha256:aaaaaaa")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want := &Manifest{Digest: "sha256:aaaaaaa", Tags: []string{"latest", "v1.0.0"}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected manifest:\n%s", diff)
+	}
+
+	if _, err := FindManifest(context.Background(), c, "foo/bar", "sha256:ccccccc"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got: %v", err)
+	}
+}
+
+// fakeLister is a client that lists the same manifests for every repository,
+// keeping those with a tag that matches the options
+type fakeLister struct {
+	Client
+
+	list *ManifestList
+}
+
+func (c *fakeLister) ListManifests(ctx context.Context, repo string, opts *ManifestListOptions) (*ManifestList, error) {
+	f, err := NewManifestFilter(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &ManifestList{}
+	for _, manifest := range c.list.Manifests {
+		for _, tag := range manifest.Tags {
+			if f.MatchTag(tag) {
+				list.Manifests = append(list.Manifests, manifest)
+				break
+			}
+		}
+	}
+
+	return list, nil
+}
+
+// fakeResolver is a fakeLister that resolves every tag to the same manifest
+type fakeResolver struct {
+	fakeLister
+
+	manifest *Manifest
+	err      error
+}
+
+func (c *fakeResolver) ResolveTag(ctx context.Context, repo, tag string) (*Manifest, error) {
+	return c.manifest, c.err
+}